@@ -2,15 +2,28 @@ package golibmsg
 
 import (
 	"context"
+	"fmt"
 	"github.com/Shopify/sarama"
 	"github.com/golibs-starter/golib"
+	"github.com/golibs-starter/golib-message-bus/kafka/codec"
 	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"github.com/golibs-starter/golib-message-bus/kafka/dedup"
+	"github.com/golibs-starter/golib-message-bus/kafka/encryption"
 	"github.com/golibs-starter/golib-message-bus/kafka/handler"
 	"github.com/golibs-starter/golib-message-bus/kafka/impl"
+	"github.com/golibs-starter/golib-message-bus/kafka/interceptor"
+	"github.com/golibs-starter/golib-message-bus/kafka/largemessage"
+	"github.com/golibs-starter/golib-message-bus/kafka/outbox"
 	"github.com/golibs-starter/golib-message-bus/kafka/properties"
 	"github.com/golibs-starter/golib-message-bus/kafka/relayer"
+	"github.com/golibs-starter/golib-message-bus/kafka/schemaregistry"
+	"github.com/golibs-starter/golib-message-bus/kafka/spool"
+	"github.com/golibs-starter/golib-message-bus/kafka/validator"
+	"github.com/golibs-starter/golib/config"
+	"github.com/golibs-starter/golib/event"
 	"github.com/golibs-starter/golib/log"
 	"go.uber.org/fx"
+	"io/fs"
 )
 
 func KafkaCommonOpt() fx.Option {
@@ -18,6 +31,8 @@ func KafkaCommonOpt() fx.Option {
 		golib.ProvideProps(properties.NewClient),
 		fx.Provide(impl.NewSaramaMapper),
 		fx.Provide(impl.NewDebugLogger),
+		fx.Provide(NewJsonSchemaLoader),
+		fx.Provide(NewCodecRegistry),
 		fx.Invoke(func(props *properties.Client, debugLogger *impl.DebugLogger) {
 			if props.Debug {
 				log.Debug("Kafka debug mode is enabled")
@@ -30,42 +45,262 @@ func KafkaCommonOpt() fx.Option {
 func KafkaAdminOpt() fx.Option {
 	return fx.Options(
 		golib.ProvideProps(properties.NewTopicAdmin),
-		fx.Provide(impl.NewSaramaAdmin),
-		fx.Invoke(handler.CreateKafkaTopicHandler),
+		// The admin is also provided by the focused interfaces it embeds
+		fx.Provide(fx.Annotate(impl.NewSaramaAdmin, fx.As(new(core.Admin)), fx.As(new(core.TopicAdmin)),
+			fx.As(new(core.GroupAdmin)), fx.As(new(core.PartitionAdmin)), fx.As(new(core.AclAdmin)))),
+		fx.Provide(CreateKafkaTopics),
+		fx.Invoke(func(KafkaTopicsCreated) {}),
+		fx.Invoke(handler.CreateKafkaAclHandler),
 	)
 }
 
+type CreateKafkaTopicsIn struct {
+	fx.In
+	Admin              core.Admin
+	Props              *properties.TopicAdmin
+	ConsumerProps      *properties.KafkaConsumer `optional:"true"`
+	EventProducerProps *properties.EventProducer `optional:"true"`
+}
+
+// KafkaTopicsCreated is provided once topics are created, depend on it to run after topic creation
+type KafkaTopicsCreated struct{}
+
+// CreateKafkaTopics creates the configured topics, plus the referenced topics when TopicAdmin.DeriveTopics is enabled
+func CreateKafkaTopics(in CreateKafkaTopicsIn) (KafkaTopicsCreated, error) {
+	props := *in.Props
+	props.Topics = handler.DeriveTopics(in.Props, handler.ReferencedTopics(in.ConsumerProps, in.EventProducerProps))
+	return KafkaTopicsCreated{}, handler.CreateKafkaTopicHandler(in.Admin, &props)
+}
+
+// KafkaTopicsReadyWaitOpt blocks the startup until the cluster is reachable and the topics referenced
+// by consumer and producer mappings exist with leaders.
+// Requires KafkaAdminOpt(), topics are waited for once it has created them whatever the order of options.
+func KafkaTopicsReadyWaitOpt() fx.Option {
+	return fx.Options(
+		golib.ProvideProps(properties.NewTopicReadiness),
+		fx.Invoke(WaitForKafkaTopics),
+	)
+}
+
+type WaitForKafkaTopicsIn struct {
+	fx.In
+	TopicsCreated      KafkaTopicsCreated // Topics are only waited for once they are created
+	Admin              core.Admin
+	Props              *properties.TopicReadiness
+	ConsumerProps      *properties.KafkaConsumer `optional:"true"`
+	EventProducerProps *properties.EventProducer `optional:"true"`
+}
+
+func WaitForKafkaTopics(in WaitForKafkaTopicsIn) error {
+	topics := handler.ReferencedTopics(in.ConsumerProps, in.EventProducerProps)
+	return handler.WaitForKafkaTopics(in.Admin, topics, in.Props)
+}
+
 func KafkaProducerOpt() fx.Option {
 	return fx.Options(
 		fx.Provide(fx.Annotated{
 			Name:   "sarama_producer_client",
 			Target: impl.NewSaramaProducerClient,
 		}),
+		fx.Provide(NewSyncProducer),
+		fx.Provide(NewAsyncProducer),
+		fx.Provide(NewEventConverter),
+		golib.ProvideProps(properties.NewEventProducer),
+		golib.ProvideEventListener(relayer.NewEventMessageRelayerWithAsyncProducer),
+		fx.Invoke(handler.AsyncProducerErrorLogHandler),
+		fx.Invoke(handler.AsyncProducerSuccessLogHandler),
+	)
+}
+
+// KafkaSchemaRegistryOpt serializes events implementing schemaregistry.AvroEvent
+// or schemaregistry.ProtobufEvent in the schema registry wire format,
+// other events are still serialized by the default converter.
+// It must come with KafkaProducerOpt, see NewEventConverter.
+func KafkaSchemaRegistryOpt() fx.Option {
+	return fx.Options(
+		golib.ProvideProps(properties.NewSchemaRegistry),
 		fx.Provide(fx.Annotate(
-			impl.NewSaramaSyncProducer,
-			fx.As(new(core.SyncProducer)),
-			fx.ParamTags(`name:"sarama_producer_client"`),
+			schemaregistry.NewHttpClient,
+			fx.As(new(schemaregistry.Client)),
 		)),
+	)
+}
+
+type ProducerIn struct {
+	fx.In
+	Client       sarama.Client `name:"sarama_producer_client"`
+	Mapper       *impl.SaramaMapper
+	EventProps   *event.Properties
+	Spool        spool.Spool                `optional:"true"`
+	LargeMessage *largemessage.Encoder      `optional:"true"`
+	Interceptors []core.ProducerInterceptor `group:"kafka_producer_interceptor"`
+}
+
+// NewSyncProducer creates the sarama sync producer, wrapped by the spool
+// and the large message encoder when they are enabled, then by the interceptors.
+func NewSyncProducer(in ProducerIn) (core.SyncProducer, error) {
+	saramaProducer, err := impl.NewSaramaSyncProducer(in.Client, in.Mapper)
+	if err != nil {
+		return nil, err
+	}
+	var producer core.SyncProducer = saramaProducer
+	if in.Spool != nil {
+		producer = spool.NewSyncProducer(producer, in.Spool, impl.IsRetriableProducerError, in.EventProps)
+	}
+	if in.LargeMessage != nil {
+		// Large messages are encoded first, so the spool only contains messages the cluster accepts
+		producer = largemessage.NewSyncProducer(producer, in.LargeMessage)
+	}
+	if len(in.Interceptors) > 0 {
+		producer = interceptor.NewSyncProducer(producer, in.Interceptors)
+	}
+	return producer, nil
+}
+
+// NewAsyncProducer creates the sarama async producer, wrapped by the spool
+// and the large message encoder when they are enabled, then by the interceptors.
+func NewAsyncProducer(in ProducerIn) (core.AsyncProducer, error) {
+	saramaProducer, err := impl.NewSaramaAsyncProducer(in.Client, in.Mapper)
+	if err != nil {
+		return nil, err
+	}
+	var producer core.AsyncProducer = saramaProducer
+	if in.Spool != nil {
+		producer = spool.NewAsyncProducer(producer, in.Spool, impl.IsRetriableProducerError, in.EventProps)
+	}
+	if in.LargeMessage != nil {
+		producer = largemessage.NewAsyncProducer(producer, in.LargeMessage)
+	}
+	if len(in.Interceptors) > 0 {
+		producer = interceptor.NewAsyncProducer(producer, in.Interceptors)
+	}
+	return producer, nil
+}
+
+// KafkaLargeMessageOpt enables producing messages larger than the configured max message bytes,
+// either by chunking them or with the claim-check pattern, and consuming them.
+// The claim-check mode requires a store registered with ProvideLargeMessageStore.
+func KafkaLargeMessageOpt() fx.Option {
+	return fx.Options(
+		golib.ProvideProps(properties.NewLargeMessage),
+		fx.Provide(NewLargeMessageEncoder),
+		fx.Provide(NewLargeMessageDecoder),
+		fx.Provide(NewLargeMessageStoreCleaner),
+		fx.Invoke(LargeMessageStoreCleanerHook),
+	)
+}
+
+// ProvideLargeMessageStore registers the store of claim-check messages, eg: largemessage.NewFileStore
+func ProvideLargeMessageStore(constructor interface{}) fx.Option {
+	return fx.Provide(fx.Annotate(constructor, fx.As(new(largemessage.Store))))
+}
+
+type LargeMessageIn struct {
+	fx.In
+	Props *properties.LargeMessage
+	Store largemessage.Store `optional:"true"`
+}
+
+func NewLargeMessageEncoder(in LargeMessageIn) (*largemessage.Encoder, error) {
+	return largemessage.NewEncoder(in.Props, in.Store)
+}
+
+func NewLargeMessageDecoder(in LargeMessageIn) *largemessage.Decoder {
+	return largemessage.NewDecoder(in.Props, in.Store)
+}
+
+func NewLargeMessageStoreCleaner(in LargeMessageIn) *largemessage.StoreCleaner {
+	return largemessage.NewStoreCleaner(in.Store, in.Props)
+}
+
+func LargeMessageStoreCleanerHook(lc fx.Lifecycle, cleaner *largemessage.StoreCleaner, golibCtx context.Context) {
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			cleaner.Start(golibCtx)
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			cleaner.Stop()
+			return nil
+		},
+	})
+}
+
+// KafkaEncryptionOpt enables the envelope encryption of events having an encryption key id
+// in their mapping, consumers decrypt encrypted messages before invoking their handler.
+// A Kms has to be registered with ProvideKms.
+func KafkaEncryptionOpt() fx.Option {
+	return fx.Options(
+		golib.ProvideProps(properties.NewEncryption),
+		fx.Provide(encryption.NewEncrypter),
+		fx.Provide(encryption.NewDecrypter),
+	)
+}
+
+// ProvideKms registers the Kms wrapping the data keys, eg: encryption.NewLocalKms
+func ProvideKms(constructor interface{}) fx.Option {
+	return fx.Provide(fx.Annotate(constructor, fx.As(new(encryption.Kms))))
+}
+
+// KafkaProducerSpoolOpt enables spooling messages which cannot be produced
+// because the cluster is unreachable into a local journal,
+// they are replayed in order once the cluster is reachable again.
+// It must come with KafkaProducerOpt.
+func KafkaProducerSpoolOpt() fx.Option {
+	return fx.Options(
+		golib.ProvideProps(properties.NewSpool),
 		fx.Provide(fx.Annotate(
-			impl.NewSaramaAsyncProducer,
-			fx.As(new(core.AsyncProducer)),
-			fx.ParamTags(`name:"sarama_producer_client"`),
+			spool.NewFileSpool,
+			fx.As(new(spool.Spool)),
 		)),
 		fx.Provide(fx.Annotate(
-			relayer.NewDefaultEventConverter,
-			fx.As(new(relayer.EventConverter)),
+			NewSpoolReplayer,
+			fx.ParamTags(`name:"sarama_producer_client"`),
 		)),
-		golib.ProvideProps(properties.NewEventProducer),
-		golib.ProvideEventListener(relayer.NewEventMessageRelayer),
-		fx.Invoke(handler.AsyncProducerErrorLogHandler),
-		fx.Invoke(handler.AsyncProducerSuccessLogHandler),
+		golib.ProvideInformer(spool.NewInformer),
+		fx.Invoke(OnStartSpoolReplayerHook),
 	)
 }
 
+// NewSpoolReplayer creates the spool replayer with a dedicated sync producer,
+// so replayed messages are never spooled again.
+func NewSpoolReplayer(
+	client sarama.Client,
+	mapper *impl.SaramaMapper,
+	s spool.Spool,
+	props *properties.Spool,
+	eventProps *event.Properties,
+) (*spool.Replayer, error) {
+	producer, err := impl.NewSaramaSyncProducer(client, mapper)
+	if err != nil {
+		return nil, err
+	}
+	return spool.NewReplayer(s, producer, impl.IsRetriableProducerError, props, eventProps), nil
+}
+
+// KafkaOutboxOpt enables the transactional outbox, events are written with outbox.EventWriter
+// inside the caller's transaction and published by the dispatcher.
+// It requires an outbox.Outbox to be provided, see ProvideOutbox.
+func KafkaOutboxOpt() fx.Option {
+	return fx.Options(
+		golib.ProvideProps(properties.NewOutbox),
+		fx.Provide(outbox.NewEventWriter),
+		fx.Provide(outbox.NewDispatcher),
+		fx.Invoke(OnStartOutboxDispatcherHook),
+	)
+}
+
+// ProvideOutbox registers the outbox.Outbox implementation, eg: outbox.NewSqlOutbox
+func ProvideOutbox(constructor interface{}) fx.Option {
+	return fx.Provide(fx.Annotate(constructor, fx.As(new(outbox.Outbox))))
+}
+
 func KafkaConsumerOpt() fx.Option {
 	return fx.Options(
 		golib.ProvideProps(properties.NewKafkaConsumer),
 		fx.Provide(NewSaramaConsumers),
+		fx.Provide(NewConsumerSeeker),
+		golib.ProvideInformer(impl.NewConsumerInformer),
 		fx.Invoke(OnStartConsumerHook),
 	)
 }
@@ -92,22 +327,198 @@ func OnStopConsumerOpt() fx.Option {
 	return fx.Invoke(OnStopConsumerHook)
 }
 
+func OnStopProducerSpoolOpt() fx.Option {
+	return fx.Invoke(OnStopSpoolReplayerHook)
+}
+
+func OnStopOutboxOpt() fx.Option {
+	return fx.Invoke(OnStopOutboxDispatcherHook)
+}
+
 type KafkaConsumersIn struct {
 	fx.In
 	GlobalProps   *properties.Client
 	ConsumerProps *properties.KafkaConsumer
 	SaramaMapper  *impl.SaramaMapper
 	Handlers      []core.ConsumerHandler `group:"kafka_consumer_handler"`
+	SchemaLoader  *validator.SchemaLoader
+	SyncProducer  core.SyncProducer `optional:"true"`
+	Codecs        *codec.Registry
+	Decrypter     *encryption.Decrypter     `optional:"true"`
+	LargeMessage  *largemessage.Decoder     `optional:"true"`
+	Middlewares   []core.ConsumerMiddleware `group:"kafka_consumer_middleware"`
 }
 
 func NewSaramaConsumers(in KafkaConsumersIn) (core.Consumer, error) {
-	return impl.NewSaramaConsumers(in.GlobalProps, in.ConsumerProps, in.SaramaMapper, in.Handlers)
+	return impl.NewSaramaConsumers(in.GlobalProps, in.ConsumerProps, in.SaramaMapper, in.Handlers,
+		impl.ConsumerOptions{
+			SchemaLoader: in.SchemaLoader,
+			Producer:     in.SyncProducer,
+			Codecs:       in.Codecs,
+			Decrypter:    in.Decrypter,
+			LargeMessage: in.LargeMessage,
+			Middlewares:  in.Middlewares,
+		})
+}
+
+// NewConsumerSeeker exposes the seek of the consumer as core.Seeker
+func NewConsumerSeeker(consumer core.Consumer) (core.Seeker, error) {
+	seeker, ok := consumer.(core.Seeker)
+	if !ok {
+		return nil, fmt.Errorf("consumer [%T] does not support seek", consumer)
+	}
+	return seeker, nil
+}
+
+type EventConverterIn struct {
+	fx.In
+	AppProps            *config.AppProperties
+	EventProducerProps  *properties.EventProducer
+	SchemaLoader        *validator.SchemaLoader
+	Codecs              *codec.Registry
+	Encrypter           *encryption.Encrypter      `optional:"true"`
+	Decrypter           *encryption.Decrypter      `optional:"true"`
+	SchemaRegistry      schemaregistry.Client      `optional:"true"`
+	SchemaRegistryProps *properties.SchemaRegistry `optional:"true"`
+}
+
+// NewEventConverter creates the default event converter which encodes events
+// as CloudEvents when it is enabled, validates them against the JSON schema of their mapping,
+// serializes them with the schema registry when it is enabled and encrypts them when encryption is enabled.
+// Encryption is always the last step, so no serializer can replace the encrypted value.
+func NewEventConverter(in EventConverterIn) (relayer.EventConverter, error) {
+	cloudEventsConverter, err := relayer.NewCloudEventsConverter(
+		relayer.NewDefaultEventConverterWithCodecs(in.AppProps, in.EventProducerProps, in.Codecs),
+		in.AppProps,
+		in.EventProducerProps,
+	)
+	if err != nil {
+		return nil, err
+	}
+	validatingConverter, err := validator.NewEventConverter(cloudEventsConverter, in.EventProducerProps, in.SchemaLoader)
+	if err != nil {
+		return nil, err
+	}
+	var converter relayer.EventConverter = validatingConverter
+	if in.SchemaRegistry != nil && in.SchemaRegistryProps != nil {
+		converter = schemaregistry.NewEventConverter(converter, in.SchemaRegistry, in.SchemaRegistryProps,
+			schemaregistry.NewAvroSerde(), schemaregistry.NewProtobufSerde())
+	}
+	if in.Encrypter == nil || in.Decrypter == nil {
+		for event, eventTopic := range in.EventProducerProps.EventMappings {
+			if eventTopic.EncryptionKeyId != "" {
+				return nil, fmt.Errorf("encryption of event [%s] requires KafkaEncryptionOpt", event)
+			}
+		}
+		return converter, nil
+	}
+	return encryption.NewEventConverter(converter, in.EventProducerProps, in.Encrypter, in.Decrypter)
+}
+
+type JsonSchemaLoaderIn struct {
+	fx.In
+	FS fs.FS `name:"kafka_json_schema_fs" optional:"true"`
+}
+
+func NewJsonSchemaLoader(in JsonSchemaLoaderIn) *validator.SchemaLoader {
+	return validator.NewSchemaLoader(in.FS)
+}
+
+// ProvideJsonSchemaFS registers the file system used to load embed://<path> JSON schemas,
+// it's usually an embed.FS
+func ProvideJsonSchemaFS(fsys fs.FS) fx.Option {
+	return fx.Supply(fx.Annotated{Name: "kafka_json_schema_fs", Target: fsys})
+}
+
+type CodecRegistryIn struct {
+	fx.In
+	Codecs []codec.Codec `group:"kafka_codec"`
+}
+
+func NewCodecRegistry(in CodecRegistryIn) *codec.Registry {
+	return codec.NewRegistry(in.Codecs...)
+}
+
+// ProvideCodec registers a codec which can be selected by name in event mappings and handler mappings,
+// it replaces the builtin codec having the same name.
+func ProvideCodec(constructor interface{}) fx.Option {
+	return fx.Provide(fx.Annotate(constructor, fx.As(new(codec.Codec)), fx.ResultTags(`group:"kafka_codec"`)))
 }
 
 func ProvideConsumer(handler interface{}) fx.Option {
 	return fx.Provide(fx.Annotated{Group: "kafka_consumer_handler", Target: handler})
 }
 
+// KafkaConsumerDedupOpt skips the messages already processed by a handler,
+// messages are identified by their event id header unless a key extractor is provided with ProvideDedupKeyExtractor.
+// A store has to be registered with ProvideDedupStore.
+func KafkaConsumerDedupOpt() fx.Option {
+	return fx.Options(
+		golib.ProvideProps(properties.NewDedup),
+		ProvideConsumerMiddleware(NewDedupMiddleware),
+		fx.Provide(NewDedupStoreCleaner),
+		fx.Invoke(DedupStoreCleanerHook),
+	)
+}
+
+// ProvideDedupStore registers the store of processed messages, eg: dedup.NewMemoryStore, dedup.NewSqlStore
+func ProvideDedupStore(constructor interface{}) fx.Option {
+	return fx.Provide(fx.Annotate(constructor, fx.As(new(dedup.DedupStore))))
+}
+
+// ProvideDedupKeyExtractor registers the function returning the deduplication key of messages
+func ProvideDedupKeyExtractor(keyExtractor dedup.KeyExtractor) fx.Option {
+	return fx.Supply(keyExtractor)
+}
+
+type DedupMiddlewareIn struct {
+	fx.In
+	Store        dedup.DedupStore
+	Props        *properties.Dedup
+	KeyExtractor dedup.KeyExtractor `optional:"true"`
+}
+
+func NewDedupMiddleware(in DedupMiddlewareIn) *dedup.Middleware {
+	return dedup.NewMiddleware(in.Store, in.Props, in.KeyExtractor)
+}
+
+func NewDedupStoreCleaner(store dedup.DedupStore, props *properties.Dedup) *dedup.StoreCleaner {
+	return dedup.NewStoreCleaner(store, props)
+}
+
+func DedupStoreCleanerHook(lc fx.Lifecycle, cleaner *dedup.StoreCleaner, golibCtx context.Context) {
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			cleaner.Start(golibCtx)
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			cleaner.Stop()
+			return nil
+		},
+	})
+}
+
+// ProvideProducerInterceptor registers an interceptor called around the send of messages.
+// The constructor has to return a core.ProducerInterceptor implementation.
+func ProvideProducerInterceptor(constructor interface{}) fx.Option {
+	return fx.Provide(fx.Annotate(
+		constructor,
+		fx.As(new(core.ProducerInterceptor)),
+		fx.ResultTags(`group:"kafka_producer_interceptor"`),
+	))
+}
+
+// ProvideConsumerMiddleware registers a middleware called around the handlers of all consumers.
+// The constructor has to return a core.ConsumerMiddleware implementation.
+func ProvideConsumerMiddleware(constructor interface{}) fx.Option {
+	return fx.Provide(fx.Annotate(
+		constructor,
+		fx.As(new(core.ConsumerMiddleware)),
+		fx.ResultTags(`group:"kafka_consumer_middleware"`),
+	))
+}
+
 type OnStopProducerIn struct {
 	fx.In
 	Lc             fx.Lifecycle
@@ -167,3 +578,63 @@ func OnStopConsumerHook(in OnStopConsumerIn) {
 		},
 	})
 }
+
+func OnStartOutboxDispatcherHook(lc fx.Lifecycle, dispatcher *outbox.Dispatcher, golibCtx context.Context) {
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			dispatcher.Start(golibCtx)
+			return nil
+		},
+	})
+}
+
+type OnStopOutboxIn struct {
+	fx.In
+	Lc         fx.Lifecycle
+	Dispatcher *outbox.Dispatcher `optional:"true"`
+}
+
+func OnStopOutboxDispatcherHook(in OnStopOutboxIn) {
+	in.Lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			log.Infof("Receive stop signal for outbox dispatcher")
+			if in.Dispatcher != nil {
+				in.Dispatcher.Stop()
+			}
+			return nil
+		},
+	})
+}
+
+func OnStartSpoolReplayerHook(lc fx.Lifecycle, replayer *spool.Replayer, golibCtx context.Context) {
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			replayer.Start(golibCtx)
+			return nil
+		},
+	})
+}
+
+type OnStopSpoolIn struct {
+	fx.In
+	Lc       fx.Lifecycle
+	Replayer *spool.Replayer `optional:"true"`
+	Spool    spool.Spool     `optional:"true"`
+}
+
+func OnStopSpoolReplayerHook(in OnStopSpoolIn) {
+	in.Lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			log.Infof("Receive stop signal for kafka spool")
+			if in.Replayer != nil {
+				in.Replayer.Stop()
+			}
+			if in.Spool != nil {
+				if err := in.Spool.Close(); err != nil {
+					log.Errorf("Cannot close kafka spool. Error [%v]", err)
+				}
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,24 @@
+package codec
+
+const Json = "json"
+const GzipJson = "gzip-json"
+const Msgpack = "msgpack"
+const Protobuf = "protobuf"
+const Raw = "raw"
+
+// Codec serializes and deserializes message values
+type Codec interface {
+
+	// Name is used to select the codec in EventTopic and TopicConsumer
+	Name() string
+
+	// ContentType is advertised in the content-type header of produced messages,
+	// consumers use it to pick the codec automatically
+	ContentType() string
+
+	// Marshal the value
+	Marshal(v interface{}) ([]byte, error)
+
+	// Unmarshal the data into the value
+	Unmarshal(data []byte, v interface{}) error
+}
@@ -0,0 +1,44 @@
+package codec
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+)
+
+// GzipJsonCodec serializes values to JSON compressed with gzip
+type GzipJsonCodec struct {
+}
+
+func NewGzipJsonCodec() *GzipJsonCodec {
+	return &GzipJsonCodec{}
+}
+
+func (g GzipJsonCodec) Name() string {
+	return GzipJson
+}
+
+func (g GzipJsonCodec) ContentType() string {
+	return "application/json+gzip"
+}
+
+func (g GzipJsonCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(writer).Encode(v); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (g GzipJsonCodec) Unmarshal(data []byte, v interface{}) error {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	return json.NewDecoder(reader).Decode(v)
+}
@@ -0,0 +1,26 @@
+package codec
+
+import "encoding/json"
+
+type JsonCodec struct {
+}
+
+func NewJsonCodec() *JsonCodec {
+	return &JsonCodec{}
+}
+
+func (j JsonCodec) Name() string {
+	return Json
+}
+
+func (j JsonCodec) ContentType() string {
+	return "application/json"
+}
+
+func (j JsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (j JsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,39 @@
+package codec
+
+import (
+	"bytes"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// MsgpackCodec serializes values to MessagePack,
+// fields are named by their json tags so events keep the same shape as in JSON.
+type MsgpackCodec struct {
+}
+
+func NewMsgpackCodec() *MsgpackCodec {
+	return &MsgpackCodec{}
+}
+
+func (m MsgpackCodec) Name() string {
+	return Msgpack
+}
+
+func (m MsgpackCodec) ContentType() string {
+	return "application/msgpack"
+}
+
+func (m MsgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := msgpack.NewEncoder(&buf)
+	encoder.SetCustomStructTag("json")
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (m MsgpackCodec) Unmarshal(data []byte, v interface{}) error {
+	decoder := msgpack.NewDecoder(bytes.NewReader(data))
+	decoder.SetCustomStructTag("json")
+	return decoder.Decode(v)
+}
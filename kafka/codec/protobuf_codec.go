@@ -0,0 +1,62 @@
+package codec
+
+import (
+	"fmt"
+	"google.golang.org/protobuf/proto"
+)
+
+// ProtoMessageProvider is a value whose payload is serialized with Protobuf
+type ProtoMessageProvider interface {
+
+	// ProtoPayload returns the message to serialize.
+	// When unmarshalling, it has to return a non nil message to deserialize into.
+	ProtoPayload() proto.Message
+}
+
+// ProtobufCodec serializes proto messages or ProtoMessageProvider values without schema registry
+type ProtobufCodec struct {
+}
+
+func NewProtobufCodec() *ProtobufCodec {
+	return &ProtobufCodec{}
+}
+
+func (p ProtobufCodec) Name() string {
+	return Protobuf
+}
+
+func (p ProtobufCodec) ContentType() string {
+	return "application/x-protobuf"
+}
+
+func (p ProtobufCodec) Marshal(v interface{}) ([]byte, error) {
+	message, err := p.message(v)
+	if err != nil {
+		return nil, err
+	}
+	return proto.Marshal(message)
+}
+
+func (p ProtobufCodec) Unmarshal(data []byte, v interface{}) error {
+	message, err := p.message(v)
+	if err != nil {
+		return err
+	}
+	return proto.Unmarshal(data, message)
+}
+
+func (p ProtobufCodec) message(v interface{}) (proto.Message, error) {
+	var message proto.Message
+	switch value := v.(type) {
+	case proto.Message:
+		message = value
+	case ProtoMessageProvider:
+		message = value.ProtoPayload()
+	default:
+		return nil, fmt.Errorf("type [%T] is neither a proto.Message nor a ProtoMessageProvider", v)
+	}
+	if message == nil {
+		return nil, fmt.Errorf("protobuf payload of [%T] is nil", v)
+	}
+	return message, nil
+}
@@ -0,0 +1,48 @@
+package codec
+
+import (
+	"encoding"
+	"fmt"
+)
+
+// RawCodec passes bytes through, values have to be []byte, *[]byte
+// or implement encoding.BinaryMarshaler and encoding.BinaryUnmarshaler.
+type RawCodec struct {
+}
+
+func NewRawCodec() *RawCodec {
+	return &RawCodec{}
+}
+
+func (r RawCodec) Name() string {
+	return Raw
+}
+
+func (r RawCodec) ContentType() string {
+	return "application/octet-stream"
+}
+
+func (r RawCodec) Marshal(v interface{}) ([]byte, error) {
+	switch value := v.(type) {
+	case []byte:
+		return value, nil
+	case *[]byte:
+		return *value, nil
+	case encoding.BinaryMarshaler:
+		return value.MarshalBinary()
+	default:
+		return nil, fmt.Errorf("type [%T] cannot be marshalled as raw bytes", v)
+	}
+}
+
+func (r RawCodec) Unmarshal(data []byte, v interface{}) error {
+	switch value := v.(type) {
+	case *[]byte:
+		*value = append((*value)[:0], data...)
+		return nil
+	case encoding.BinaryUnmarshaler:
+		return value.UnmarshalBinary(data)
+	default:
+		return fmt.Errorf("raw bytes cannot be unmarshalled into type [%T]", v)
+	}
+}
@@ -0,0 +1,51 @@
+package codec
+
+import (
+	"fmt"
+	"mime"
+	"strings"
+)
+
+// Registry holds the codecs by name and by content type
+type Registry struct {
+	byName        map[string]Codec
+	byContentType map[string]Codec
+}
+
+// NewRegistry creates a registry of the builtin codecs and the provided codecs,
+// a provided codec replaces the builtin codec having the same name.
+func NewRegistry(codecs ...Codec) *Registry {
+	r := &Registry{
+		byName:        make(map[string]Codec),
+		byContentType: make(map[string]Codec),
+	}
+	builtin := []Codec{NewJsonCodec(), NewGzipJsonCodec(), NewMsgpackCodec(), NewProtobufCodec(), NewRawCodec()}
+	for _, codec := range append(builtin, codecs...) {
+		r.byName[strings.ToLower(codec.Name())] = codec
+		r.byContentType[strings.ToLower(codec.ContentType())] = codec
+	}
+	return r
+}
+
+// Get returns the codec registered with the name
+func (r Registry) Get(name string) (Codec, error) {
+	codec, exists := r.byName[strings.ToLower(name)]
+	if !exists {
+		return nil, fmt.Errorf("codec [%s] is not registered", name)
+	}
+	return codec, nil
+}
+
+// GetByContentType returns the codec advertising the content type,
+// parameters of the content type such as charset are ignored.
+func (r Registry) GetByContentType(contentType string) (Codec, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = contentType
+	}
+	codec, exists := r.byContentType[strings.ToLower(mediaType)]
+	if !exists {
+		return nil, fmt.Errorf("no codec is registered for content type [%s]", contentType)
+	}
+	return codec, nil
+}
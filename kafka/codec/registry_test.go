@@ -0,0 +1,70 @@
+package codec
+
+import (
+	assert "github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"testing"
+)
+
+type testEvent struct {
+	Id      string                 `json:"id"`
+	Payload map[string]interface{} `json:"payload"`
+}
+
+func TestRegistry_WhenGetByContentTypeWithParameters_ShouldReturnCodec(t *testing.T) {
+	registry := NewRegistry()
+	codec, err := registry.GetByContentType("application/json; charset=utf-8")
+	assert.NoError(t, err)
+	assert.Equal(t, Json, codec.Name())
+
+	_, err = registry.GetByContentType("text/plain")
+	assert.Error(t, err)
+}
+
+func TestRegistry_WhenCodecHasBuiltinName_ShouldReplaceBuiltinCodec(t *testing.T) {
+	custom := &customJsonCodec{}
+	registry := NewRegistry(custom)
+	codec, err := registry.Get(Json)
+	assert.NoError(t, err)
+	assert.Same(t, custom, codec)
+}
+
+func TestCodecs_WhenMarshalThenUnmarshal_ShouldRestoreValue(t *testing.T) {
+	for _, name := range []string{Json, GzipJson, Msgpack} {
+		t.Run(name, func(t *testing.T) {
+			codec, err := NewRegistry().Get(name)
+			assert.NoError(t, err)
+			data, err := codec.Marshal(&testEvent{Id: "1", Payload: map[string]interface{}{"field1": "val1"}})
+			assert.NoError(t, err)
+			var restored testEvent
+			assert.NoError(t, codec.Unmarshal(data, &restored))
+			assert.Equal(t, "1", restored.Id)
+			assert.Equal(t, map[string]interface{}{"field1": "val1"}, restored.Payload)
+		})
+	}
+}
+
+func TestProtobufCodec_WhenMarshalThenUnmarshal_ShouldRestoreMessage(t *testing.T) {
+	codec := NewProtobufCodec()
+	data, err := codec.Marshal(wrapperspb.String("val1"))
+	assert.NoError(t, err)
+	restored := &wrapperspb.StringValue{}
+	assert.NoError(t, codec.Unmarshal(data, restored))
+	assert.Equal(t, "val1", restored.Value)
+
+	_, err = codec.Marshal(&testEvent{})
+	assert.Error(t, err)
+}
+
+func TestRawCodec_WhenMarshalThenUnmarshal_ShouldPassBytesThrough(t *testing.T) {
+	codec := NewRawCodec()
+	data, err := codec.Marshal([]byte("raw"))
+	assert.NoError(t, err)
+	var restored []byte
+	assert.NoError(t, codec.Unmarshal(data, &restored))
+	assert.Equal(t, []byte("raw"), restored)
+}
+
+type customJsonCodec struct {
+	JsonCodec
+}
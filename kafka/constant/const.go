@@ -3,9 +3,55 @@ package constant
 const EventId = "id"
 const EventName = "event"
 const LoggingContext = "logging_context"
+const DeliveryMode = "delivery_mode"
 
 const InitialOffsetNewest = -1
 const InitialOffsetOldest = -2
 
 const CommitModeAutoInterval = "AUTO_COMMIT_INTERVAL"
 const CommitModeAutoImmediately = "AUTO_COMMIT_IMMEDIATELY"
+
+const DeliveryModeSync = "sync"
+const DeliveryModeAsync = "async"
+const DeliveryModeFireAndForget = "fire-and-forget"
+const DeliveryModeOutbox = "outbox"
+
+const CloudEventsModeBinary = "binary"
+const CloudEventsModeStructured = "structured"
+
+const HeaderContentType = "content-type"
+
+// HeaderEventName is the name of the event of a message, written by the default event converter
+const HeaderEventName = "Event-Name"
+
+const HeaderEncryptionKeyId = "x-encryption-key-id"
+const HeaderEncryptionDataKey = "x-encryption-data-key"
+const HeaderEncryptionFields = "x-encryption-fields"
+
+const LargeMessageModeChunk = "chunk"
+const LargeMessageModeClaimCheck = "claim-check"
+
+const HeaderChunkId = "x-chunk-id"
+const HeaderChunkIndex = "x-chunk-index"
+const HeaderChunkCount = "x-chunk-count"
+const HeaderChunkKeyless = "x-chunk-keyless"
+const HeaderClaimCheckRef = "x-claim-check-ref"
+
+const FailurePolicySkip = "skip"
+const FailurePolicyRetry = "retry"
+const FailurePolicyDeadLetter = "dead-letter"
+const FailurePolicyStop = "stop"
+
+const HeaderOriginalTopic = "x-original-topic"
+const HeaderOriginalPartition = "x-original-partition"
+const HeaderOriginalOffset = "x-original-offset"
+const HeaderValidationError = "x-validation-error"
+const HeaderException = "x-exception"
+
+const SeekEarliest = "earliest"
+const SeekLatest = "latest"
+const SeekOffset = "offset"
+const SeekTimestamp = "timestamp"
+
+// OffsetResetShiftBy moves the committed offsets of a group by a number of messages, it's only used by the admin
+const OffsetResetShiftBy = "shift-by"
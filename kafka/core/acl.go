@@ -0,0 +1,51 @@
+package core
+
+const AclResourceTopic = "topic"
+const AclResourceGroup = "group"
+const AclResourceCluster = "cluster"
+const AclResourceTransactionalId = "transactionalid"
+
+const AclPatternLiteral = "literal"
+const AclPatternPrefixed = "prefixed"
+
+const AclOperationAll = "all"
+const AclOperationRead = "read"
+const AclOperationWrite = "write"
+const AclOperationDescribe = "describe"
+
+const AclPermissionAllow = "allow"
+const AclPermissionDeny = "deny"
+
+// Acl allows or denies an operation on a resource to a principal
+type Acl struct {
+	// ResourceType is one of topic, group, cluster, transactionalid
+	ResourceType string
+	ResourceName string
+
+	// PatternType is one of literal, prefixed. Default: literal
+	PatternType string
+
+	// Principal is the user the ACL applies to, eg: User:order-service
+	Principal string
+
+	// Host is the host the principal connects from. Default: *
+	Host string
+
+	// Operation is one of all, read, write, create, delete, alter, describe,
+	// clusteraction, describeconfigs, alterconfigs, idempotentwrite
+	Operation string
+
+	// Permission is one of allow, deny. Default: allow
+	Permission string
+}
+
+// AclFilter matches ACLs, an empty field matches any value
+type AclFilter struct {
+	ResourceType string
+	ResourceName string
+	PatternType  string
+	Principal    string
+	Host         string
+	Operation    string
+	Permission   string
+}
@@ -4,7 +4,29 @@ import (
 	"time"
 )
 
+// Admin manages topics, consumer groups, partitions and ACLs of the cluster.
+// Depend on the focused interfaces it embeds when only a part of it is used.
 type Admin interface {
+	TopicAdmin
+	GroupAdmin
+	PartitionAdmin
+	AclAdmin
+
+	// DeleteGroups delete multiple groups at once.
+	// Returns error if any error occurred
+	DeleteGroups(groupIds []string) error
+
+	// CountPartitions count number of partitions of a topic.
+	// Returns a map of broker address and number of partitions.
+	CountPartitions(topic string) (map[string]int32, error)
+
+	// DescribeCluster describe brokers and the controller of the cluster.
+	// Returns error if any error occurred
+	DescribeCluster() (*ClusterDescription, error)
+}
+
+// TopicAdmin manages topics and their configs
+type TopicAdmin interface {
 
 	// CreateTopics create multiple topics at once with custom configurations.
 	// Returns error if any error occurred
@@ -14,18 +36,126 @@ type Admin interface {
 	// Returns error if any error occurred
 	DeleteTopics(topics []string) error
 
-	// DeleteGroups delete multiple groups at once.
+	// ListTopics list names of all topics.
+	// Returns the sorted topic names
+	ListTopics() ([]string, error)
+
+	// DescribeTopics describe partitions and configs of topics.
+	// Returns error if any topic does not exist
+	DescribeTopics(topics []string) ([]TopicDescription, error)
+
+	// DescribeTopicConfig describe the effective configs of a topic.
+	// Returns a map of config name and value
+	DescribeTopicConfig(topic string) (map[string]string, error)
+
+	// AlterTopicConfig set configs of a topic, other configs of the topic are kept.
+	// Returns error if a config is unknown
+	AlterTopicConfig(topic string, configs map[string]string) error
+
+	// PlanTopics compares the configurations with the existing topics.
+	// Returns the changes to reconcile them
+	PlanTopics(configurations []TopicConfiguration) (*TopicPlan, error)
+
+	// ApplyTopicPlan applies the reconcilable changes of the plan,
+	// partitions of topics having records are only increased when forcePartitions is set like CreatePartitions.
 	// Returns error if any error occurred
-	DeleteGroups(groupIds []string) error
+	ApplyTopicPlan(plan *TopicPlan, forcePartitions bool) error
+}
 
-	// CountPartitions count number of partitions of a topic.
-	// Returns a map of server address and number of partitions.
-	CountPartitions(topic string) (map[string]int32, error)
+// GroupAdmin inspects consumer groups and manages their committed offsets
+type GroupAdmin interface {
+
+	// ListConsumerGroups list ids of all consumer groups.
+	// Returns the sorted group ids
+	ListConsumerGroups() ([]string, error)
+
+	// DescribeConsumerGroups describe state, members and assignments of consumer groups.
+	// Returns error if any error occurred
+	DescribeConsumerGroups(groupIds []string) ([]ConsumerGroupDescription, error)
+
+	// ListConsumerGroupOffsets list committed offsets and lag of a consumer group.
+	// When topics are empty, all partitions having committed offsets are listed
+	ListConsumerGroupOffsets(groupId string, topics []string) (*ConsumerGroupOffsets, error)
+
+	// ResetConsumerGroupOffsets reset offsets of a consumer group on all partitions of topics.
+	// Returns error if the group has active members and the reset is not forced
+	ResetConsumerGroupOffsets(groupId string, topics []string, reset OffsetReset) (*ConsumerGroupOffsets, error)
+
+	// DeleteConsumerGroupOffsets delete committed offsets of a consumer group by topic.
+	// Returns error if any error occurred
+	DeleteConsumerGroupOffsets(groupId string, partitions map[string][]int32) error
+}
+
+// PartitionAdmin manages partitions of topics and their records
+type PartitionAdmin interface {
+
+	// CreatePartitions increase the number of partitions of a topic to newTotal.
+	// Increasing partitions of a topic having records breaks the key-to-partition affinity of keyed messages,
+	// so it's refused unless force is true.
+	// Returns error if newTotal is less than the current number of partitions
+	CreatePartitions(topic string, newTotal int32, force bool) error
+
+	// AlterPartitionReassignments reassign replicas of partitions of a topic,
+	// the index of assignment is the partition id, a nil entry cancels the ongoing reassignment of the partition.
+	// Returns error if any error occurred
+	AlterPartitionReassignments(topic string, assignment [][]int32) error
+
+	// ListPartitionReassignments list ongoing reassignments of partitions of a topic.
+	// Returns error if any error occurred
+	ListPartitionReassignments(topic string, partitions []int32) ([]PartitionReassignment, error)
+
+	// DeleteRecords delete records of partitions of a topic before their offset.
+	// Returns error if any error occurred
+	DeleteRecords(topic string, beforeOffsets map[int32]int64) error
+
+	// OffsetsForTimestamp find the offset of the first record produced at or after the timestamp
+	// on each partition of a topic, or the end offset when there is no such record.
+	// Returns a map of partition and offset
+	OffsetsForTimestamp(topic string, timestamp time.Time) (map[int32]int64, error)
+}
+
+// AclAdmin manages ACLs of the cluster
+type AclAdmin interface {
+
+	// CreateACLs create multiple ACLs at once, existing ACLs are kept.
+	// Returns error if any error occurred
+	CreateACLs(acls []Acl) error
+
+	// ListACLs list ACLs matching the filter.
+	// Returns error if any error occurred
+	ListACLs(filter AclFilter) ([]Acl, error)
+
+	// DeleteACLs delete ACLs matching the filter.
+	// Returns the deleted ACLs
+	DeleteACLs(filter AclFilter) ([]Acl, error)
 }
 
 type TopicConfiguration struct {
-	Name          string
-	Partitions    int32 `default:"1"`
-	ReplicaFactor int16 `default:"1"`
+	Name string
+
+	// Partitions and ReplicaFactor are 1 when the topic is created without them,
+	// reconciliation only compares them with the existing topic when they are set.
+	Partitions    int32
+	ReplicaFactor int16
 	Retention     time.Duration
+
+	// Keyed marks topics whose messages are partitioned by key,
+	// increasing their partitions breaks the key-to-partition affinity.
+	Keyed bool
+
+	// Configs is the Kafka topic configs by their name, eg: cleanup.policy.
+	// Note that the property loader splits keys on dots, so prefer the typed fields below in config files.
+	Configs map[string]string
+
+	// CleanupPolicy is one of compact, delete or compact,delete
+	CleanupPolicy     string
+	MinInsyncReplicas int
+	SegmentBytes      int64
+	MaxMessageBytes   int
+
+	// CompressionType is one of uncompressed, zstd, lz4, snappy, gzip, producer
+	CompressionType string
+
+	// RetentionBytes is the maximum size of a partition, -1 means unlimited
+	RetentionBytes int64
 }
@@ -0,0 +1,51 @@
+package core
+
+// TopicDescription describes an existing topic
+type TopicDescription struct {
+	Name       string
+	Internal   bool
+	Partitions []PartitionDescription
+
+	// Configs is the effective configs of the topic by their name
+	Configs map[string]string
+}
+
+type PartitionDescription struct {
+	Id              int32
+	Leader          int32
+	Replicas        []int32
+	Isr             []int32
+	OfflineReplicas []int32
+}
+
+// ConsumerGroupDescription describes an existing consumer group
+type ConsumerGroupDescription struct {
+	GroupId string
+
+	// State is one of Empty, Stable, PreparingRebalance, CompletingRebalance, Dead
+	State        string
+	ProtocolType string
+	Protocol     string
+	Members      []ConsumerGroupMember
+}
+
+type ConsumerGroupMember struct {
+	MemberId   string
+	ClientId   string
+	ClientHost string
+
+	// Assignments is the assigned partitions by topic
+	Assignments map[string][]int32
+}
+
+// ClusterDescription describes the brokers of the cluster
+type ClusterDescription struct {
+	Brokers      []BrokerDescription
+	ControllerId int32
+}
+
+type BrokerDescription struct {
+	Id   int32
+	Addr string
+	Rack string
+}
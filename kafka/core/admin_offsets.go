@@ -0,0 +1,45 @@
+package core
+
+import "time"
+
+// ConsumerGroupOffsets is the committed offsets of a consumer group
+type ConsumerGroupOffsets struct {
+	GroupId string
+	Offsets []PartitionOffset
+
+	// Lag is the total lag of the group
+	Lag int64
+}
+
+type PartitionOffset struct {
+	Topic     string
+	Partition int32
+
+	// Offset is the committed offset, -1 when the group has no committed offset
+	Offset   int64
+	Metadata string
+
+	// EndOffset is the offset of the next message produced to the partition
+	EndOffset int64
+
+	// Lag is the number of messages after the committed offset, 0 when there is no committed offset
+	Lag int64
+}
+
+// OffsetReset is the position the offsets of a consumer group are reset to
+type OffsetReset struct {
+	// Type is one of earliest, latest, timestamp, offset, shift-by
+	Type string
+
+	// Offset is the offset when Type is offset,
+	// or the number of messages to move the committed offsets by when Type is shift-by, it can be negative.
+	Offset int64
+
+	// Timestamp is used when Type is timestamp, offsets are reset to
+	// the first message produced at or after it.
+	Timestamp time.Time
+
+	// Force resets offsets even if the group has active members.
+	// Active members may commit their own offsets over the reset ones.
+	Force bool
+}
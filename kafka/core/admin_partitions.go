@@ -0,0 +1,28 @@
+package core
+
+// PartitionReassignment is an ongoing reassignment of the replicas of a partition
+type PartitionReassignment struct {
+	Topic            string
+	Partition        int32
+	Replicas         []int32
+	AddingReplicas   []int32
+	RemovingReplicas []int32
+}
+
+// KeyedExpansions returns the partition increases of the plan on keyed topics,
+// they break the key-to-partition affinity of messages already produced.
+func (p TopicPlan) KeyedExpansions(configurations []TopicConfiguration) []TopicPartitionsChange {
+	keyedTopics := make(map[string]bool)
+	for _, configuration := range configurations {
+		if configuration.Keyed {
+			keyedTopics[configuration.Name] = true
+		}
+	}
+	expansions := make([]TopicPartitionsChange, 0)
+	for _, change := range p.IncreasePartitions {
+		if keyedTopics[change.Topic] {
+			expansions = append(expansions, change)
+		}
+	}
+	return expansions
+}
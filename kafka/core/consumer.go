@@ -1,6 +1,9 @@
 package core
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type Consumer interface {
 	Start(ctx context.Context)
@@ -8,7 +11,27 @@ type Consumer interface {
 	Stop()
 }
 
+// Seeker resets consumer groups to replay messages, it's implemented by the Consumer provided by KafkaConsumerOpt
+type Seeker interface {
+	// Seek resets the group of the handler to the position.
+	// It's applied to the partitions claimed by this instance when the group is re-joined.
+	Seek(handlerName string, position SeekPosition) error
+}
+
 type ConsumerHandler interface {
 	HandlerFunc(*ConsumerMessage)
 	Close()
 }
+
+// SeekPosition is the position a consumer group is reset to
+type SeekPosition struct {
+	// Type is one of earliest, latest, offset, timestamp
+	Type string
+
+	// Offset is used when Type is offset
+	Offset int64
+
+	// Timestamp is used when Type is timestamp, the group is reset to
+	// the first message produced at or after it.
+	Timestamp time.Time
+}
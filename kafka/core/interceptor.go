@@ -0,0 +1,24 @@
+package core
+
+// ProducerInterceptor is called around the send of each message,
+// it can mutate the message, reject it by returning an error without calling next, or measure the send.
+type ProducerInterceptor interface {
+
+	// Order of the interceptor in the chain, lower orders are called first
+	Order() int
+
+	// Intercept the message, next sends it.
+	// With async producers, next returns once the message is enqueued.
+	Intercept(m *Message, next func(m *Message) error) error
+}
+
+// ConsumerMiddleware is called around the HandlerFunc of consumers,
+// it can recover, log, measure or filter messages by not calling next.
+type ConsumerMiddleware interface {
+
+	// Order of the middleware in the chain, lower orders are called first
+	Order() int
+
+	// Handle the message consumed by the handler, next invokes the rest of the chain
+	Handle(handler ConsumerHandler, msg *ConsumerMessage, next func(msg *ConsumerMessage))
+}
@@ -1,5 +1,9 @@
 package core
 
+// PartitionSpooled is the partition returned by SyncProducer.Send when the message
+// is spooled to be produced later instead of being produced, its offset is -1 too.
+const PartitionSpooled int32 = -1
+
 // SyncProducer publishes messages to the brokers
 type SyncProducer interface {
 
@@ -0,0 +1,121 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const CleanupPolicyCompact = "compact"
+const CleanupPolicyDelete = "delete"
+
+// knownTopicConfigs is the topic level configs supported by Kafka
+var knownTopicConfigs = map[string]bool{
+	"cleanup.policy":       true,
+	"compression.type":     true,
+	"delete.retention.ms":  true,
+	"file.delete.delay.ms": true,
+	"flush.messages":       true,
+	"flush.ms":             true,
+	"follower.replication.throttled.replicas": true,
+	"index.interval.bytes":                    true,
+	"leader.replication.throttled.replicas":   true,
+	"local.retention.bytes":                   true,
+	"local.retention.ms":                      true,
+	"max.compaction.lag.ms":                   true,
+	"max.message.bytes":                       true,
+	"message.downconversion.enable":           true,
+	"message.format.version":                  true,
+	"message.timestamp.after.max.ms":          true,
+	"message.timestamp.before.max.ms":         true,
+	"message.timestamp.difference.max.ms":     true,
+	"message.timestamp.type":                  true,
+	"min.cleanable.dirty.ratio":               true,
+	"min.compaction.lag.ms":                   true,
+	"min.insync.replicas":                     true,
+	"preallocate":                             true,
+	"remote.storage.enable":                   true,
+	"retention.bytes":                         true,
+	"retention.ms":                            true,
+	"segment.bytes":                           true,
+	"segment.index.bytes":                     true,
+	"segment.jitter.ms":                       true,
+	"segment.ms":                              true,
+	"unclean.leader.election.enable":          true,
+}
+
+var compressionTypes = map[string]bool{
+	"uncompressed": true, "zstd": true, "lz4": true, "snappy": true, "gzip": true, "producer": true,
+}
+
+// IsKnownTopicConfig returns true when the name is a topic level config supported by Kafka
+func IsKnownTopicConfig(name string) bool {
+	return knownTopicConfigs[name]
+}
+
+// NumPartitions returns the number of partitions the topic is created with
+func (c TopicConfiguration) NumPartitions() int32 {
+	if c.Partitions > 0 {
+		return c.Partitions
+	}
+	return 1
+}
+
+// NumReplicas returns the replication factor the topic is created with
+func (c TopicConfiguration) NumReplicas() int16 {
+	if c.ReplicaFactor > 0 {
+		return c.ReplicaFactor
+	}
+	return 1
+}
+
+// ConfigEntries returns the Kafka configs of the topic, built from Configs and the typed fields.
+// Returns error when a config is unknown, invalid, or set twice with different values.
+func (c TopicConfiguration) ConfigEntries() (map[string]string, error) {
+	entries := make(map[string]string, len(c.Configs))
+	for name, value := range c.Configs {
+		name = strings.TrimSpace(name)
+		if !knownTopicConfigs[name] {
+			return nil, fmt.Errorf("unknown config [%s] of topic [%s]", name, c.Name)
+		}
+		entries[name] = value
+	}
+	typedEntries := make(map[string]string)
+	if c.Retention > 0 {
+		typedEntries["retention.ms"] = strconv.FormatInt(c.Retention.Milliseconds(), 10)
+	}
+	if c.CleanupPolicy != "" {
+		for _, policy := range strings.Split(c.CleanupPolicy, ",") {
+			if policy = strings.TrimSpace(policy); policy != CleanupPolicyCompact && policy != CleanupPolicyDelete {
+				return nil, fmt.Errorf("invalid cleanup policy [%s] of topic [%s]", policy, c.Name)
+			}
+		}
+		typedEntries["cleanup.policy"] = c.CleanupPolicy
+	}
+	if c.MinInsyncReplicas > 0 {
+		typedEntries["min.insync.replicas"] = strconv.Itoa(c.MinInsyncReplicas)
+	}
+	if c.SegmentBytes > 0 {
+		typedEntries["segment.bytes"] = strconv.FormatInt(c.SegmentBytes, 10)
+	}
+	if c.MaxMessageBytes > 0 {
+		typedEntries["max.message.bytes"] = strconv.Itoa(c.MaxMessageBytes)
+	}
+	if c.CompressionType != "" {
+		if !compressionTypes[c.CompressionType] {
+			return nil, fmt.Errorf("invalid compression type [%s] of topic [%s]", c.CompressionType, c.Name)
+		}
+		typedEntries["compression.type"] = c.CompressionType
+	}
+	if c.RetentionBytes != 0 {
+		typedEntries["retention.bytes"] = strconv.FormatInt(c.RetentionBytes, 10)
+	}
+	for name, value := range typedEntries {
+		if configured, exists := entries[name]; exists && configured != value {
+			return nil, fmt.Errorf("config [%s] of topic [%s] is set to both [%s] and [%s]",
+				name, c.Name, configured, value)
+		}
+		entries[name] = value
+	}
+	return entries, nil
+}
@@ -0,0 +1,38 @@
+package core
+
+import (
+	assert "github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestTopicConfiguration_WhenTypedFieldsAreSet_ShouldMergeWithConfigs(t *testing.T) {
+	entries, err := TopicConfiguration{
+		Name:            "orders",
+		Retention:       time.Hour,
+		CleanupPolicy:   "compact,delete",
+		MaxMessageBytes: 2097152,
+		Configs:         map[string]string{"segment.ms": "600000"},
+	}.ConfigEntries()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"retention.ms":      "3600000",
+		"cleanup.policy":    "compact,delete",
+		"max.message.bytes": "2097152",
+		"segment.ms":        "600000",
+	}, entries)
+}
+
+func TestTopicConfiguration_WhenConfigIsUnknown_ShouldReturnError(t *testing.T) {
+	_, err := TopicConfiguration{Name: "orders", Configs: map[string]string{"retention.hours": "1"}}.ConfigEntries()
+	assert.Error(t, err)
+}
+
+func TestTopicConfiguration_WhenConfigConflictsWithTypedField_ShouldReturnError(t *testing.T) {
+	_, err := TopicConfiguration{
+		Name:          "orders",
+		CleanupPolicy: CleanupPolicyCompact,
+		Configs:       map[string]string{"cleanup.policy": "delete"},
+	}.ConfigEntries()
+	assert.Error(t, err)
+}
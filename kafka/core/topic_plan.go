@@ -0,0 +1,83 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// TopicPlan is the list of changes to reconcile existing topics with their configurations
+type TopicPlan struct {
+	// Create is the topics to create
+	Create []TopicConfiguration
+
+	// IncreasePartitions is the topics whose partition count has to be increased
+	IncreasePartitions []TopicPartitionsChange
+
+	// AlterConfigs is the topics whose configs drift from their configurations
+	AlterConfigs []TopicConfigsChange
+
+	// Irreconcilable is the differences that cannot be applied,
+	// such as a reduced partition count or a changed replication factor
+	Irreconcilable []TopicDifference
+}
+
+type TopicPartitionsChange struct {
+	Topic string
+	From  int32
+	To    int32
+}
+
+type TopicConfigsChange struct {
+	Topic string
+
+	// Configs is the drifted configs, the key is the config name
+	Configs map[string]TopicConfigChange
+}
+
+type TopicConfigChange struct {
+	From string
+	To   string
+}
+
+type TopicDifference struct {
+	Topic  string
+	Reason string
+}
+
+// IsEmpty returns true when topics are already reconciled
+func (p TopicPlan) IsEmpty() bool {
+	return len(p.Create) == 0 && len(p.IncreasePartitions) == 0 &&
+		len(p.AlterConfigs) == 0 && len(p.Irreconcilable) == 0
+}
+
+// String returns the plan in a human-readable form, one change per line
+func (p TopicPlan) String() string {
+	if p.IsEmpty() {
+		return "No changes, topics are up-to-date"
+	}
+	lines := make([]string, 0)
+	for _, topic := range p.Create {
+		lines = append(lines, fmt.Sprintf("+ create topic [%s] with [%d] partitions, replication factor [%d]",
+			topic.Name, topic.NumPartitions(), topic.NumReplicas()))
+	}
+	for _, change := range p.IncreasePartitions {
+		lines = append(lines, fmt.Sprintf("~ increase partitions of topic [%s] from [%d] to [%d]",
+			change.Topic, change.From, change.To))
+	}
+	for _, change := range p.AlterConfigs {
+		names := make([]string, 0, len(change.Configs))
+		for name := range change.Configs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			lines = append(lines, fmt.Sprintf("~ alter config [%s] of topic [%s] from [%s] to [%s]",
+				name, change.Topic, change.Configs[name].From, change.Configs[name].To))
+		}
+	}
+	for _, difference := range p.Irreconcilable {
+		lines = append(lines, fmt.Sprintf("! cannot reconcile topic [%s]: %s", difference.Topic, difference.Reason))
+	}
+	return strings.Join(lines, "\n")
+}
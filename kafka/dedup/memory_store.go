@@ -0,0 +1,69 @@
+package dedup
+
+import (
+	"container/list"
+	"context"
+	"github.com/golibs-starter/golib-message-bus/kafka/properties"
+	"sync"
+	"time"
+)
+
+// MemoryStore is a DedupStore keeping keys in memory with a LRU eviction and a TTL.
+// Keys are lost on restart, so it only protects against redeliveries within the process lifetime.
+type MemoryStore struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	capacity int
+	items    map[string]*list.Element
+	lru      *list.List
+}
+
+type memoryItem struct {
+	key       string
+	expiresAt time.Time
+}
+
+func NewMemoryStore(props *properties.Dedup) *MemoryStore {
+	return &MemoryStore{
+		ttl:      props.Ttl,
+		capacity: props.Capacity,
+		items:    make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+func (m *MemoryStore) Exists(_ context.Context, key string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	element, exists := m.items[key]
+	if !exists {
+		return false, nil
+	}
+	if time.Now().After(element.Value.(*memoryItem).expiresAt) {
+		m.remove(element)
+		return false, nil
+	}
+	m.lru.MoveToFront(element)
+	return true, nil
+}
+
+func (m *MemoryStore) Record(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	expiresAt := time.Now().Add(m.ttl)
+	if element, exists := m.items[key]; exists {
+		element.Value.(*memoryItem).expiresAt = expiresAt
+		m.lru.MoveToFront(element)
+		return nil
+	}
+	m.items[key] = m.lru.PushFront(&memoryItem{key: key, expiresAt: expiresAt})
+	for m.capacity > 0 && m.lru.Len() > m.capacity {
+		m.remove(m.lru.Back())
+	}
+	return nil
+}
+
+func (m *MemoryStore) remove(element *list.Element) {
+	m.lru.Remove(element)
+	delete(m.items, element.Value.(*memoryItem).key)
+}
@@ -0,0 +1,72 @@
+package dedup
+
+import (
+	"context"
+	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"github.com/golibs-starter/golib-message-bus/kafka/properties"
+	"github.com/golibs-starter/golib/log"
+	coreUtils "github.com/golibs-starter/golib/utils"
+	"github.com/golibs-starter/golib/web/constant"
+)
+
+// KeyExtractor returns the deduplication key of a message,
+// messages with an empty key are not deduplicated.
+type KeyExtractor func(msg *core.ConsumerMessage) string
+
+// EventIdKeyExtractor uses the event id header written by the default event converter
+func EventIdKeyExtractor(msg *core.ConsumerMessage) string {
+	for _, header := range msg.Headers {
+		if string(header.Key) == constant.HeaderEventId {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+// Middleware is a core.ConsumerMiddleware skipping the messages already processed by the handler.
+// Keys are scoped by handler, and recorded only after the handler returns,
+// so a message whose handler panics is processed again when it's redelivered.
+// Deduplication is best effort: the key is not reserved while the handler runs, so redeliveries
+// handled concurrently, eg: by another member of the group during a rebalance, are all processed.
+// Handlers which must never process a message twice have to be idempotent themselves.
+type Middleware struct {
+	store        DedupStore
+	props        *properties.Dedup
+	keyExtractor KeyExtractor
+}
+
+// NewMiddleware creates the middleware, the keyExtractor is optional, EventIdKeyExtractor is used by default.
+func NewMiddleware(store DedupStore, props *properties.Dedup, keyExtractor KeyExtractor) *Middleware {
+	if keyExtractor == nil {
+		keyExtractor = EventIdKeyExtractor
+	}
+	return &Middleware{store: store, props: props, keyExtractor: keyExtractor}
+}
+
+func (m Middleware) Order() int {
+	return m.props.Order
+}
+
+func (m Middleware) Handle(handler core.ConsumerHandler, msg *core.ConsumerMessage, next func(msg *core.ConsumerMessage)) {
+	key := m.keyExtractor(msg)
+	if key == "" {
+		next(msg)
+		return
+	}
+	handlerName := coreUtils.GetStructShortName(handler)
+	key = handlerName + "/" + key
+	ctx := context.Background()
+	exists, err := m.store.Exists(ctx, key)
+	if err != nil {
+		// Prefer processing twice than losing the message
+		log.WithErrors(err).Errorf("Consumer [%s] cannot check whether message [%s] is processed", handlerName, key)
+	} else if exists {
+		log.Infof("Consumer [%s] skips already processed message [%s] at topic [%s], partition [%d], offset [%d]",
+			handlerName, key, msg.Topic, msg.Partition, msg.Offset)
+		return
+	}
+	next(msg)
+	if err := m.store.Record(ctx, key); err != nil {
+		log.WithErrors(err).Errorf("Consumer [%s] cannot record processed message [%s]", handlerName, key)
+	}
+}
@@ -0,0 +1,77 @@
+package dedup
+
+import (
+	"context"
+	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"github.com/golibs-starter/golib-message-bus/kafka/properties"
+	"github.com/golibs-starter/golib/web/constant"
+	assert "github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+type testHandler struct {
+	calls int
+	panic bool
+}
+
+func (t *testHandler) HandlerFunc(_ *core.ConsumerMessage) {
+	t.calls++
+	if t.panic {
+		panic("failed")
+	}
+}
+
+func (t *testHandler) Close() {
+}
+
+func newTestMessage(eventId string) *core.ConsumerMessage {
+	return &core.ConsumerMessage{Headers: []core.MessageHeader{
+		{Key: []byte(constant.HeaderEventId), Value: []byte(eventId)},
+	}}
+}
+
+func TestMiddleware_WhenMessageIsRedelivered_ShouldSkipIt(t *testing.T) {
+	props := &properties.Dedup{Ttl: time.Hour, Capacity: 10}
+	middleware := NewMiddleware(NewMemoryStore(props), props, nil)
+	handler := &testHandler{}
+
+	middleware.Handle(handler, newTestMessage("e1"), handler.HandlerFunc)
+	middleware.Handle(handler, newTestMessage("e1"), handler.HandlerFunc)
+	middleware.Handle(handler, newTestMessage("e2"), handler.HandlerFunc)
+	middleware.Handle(handler, &core.ConsumerMessage{}, handler.HandlerFunc)
+	assert.Equal(t, 3, handler.calls)
+}
+
+func TestMiddleware_WhenHandlerPanics_ShouldNotRecordMessage(t *testing.T) {
+	props := &properties.Dedup{Ttl: time.Hour, Capacity: 10}
+	store := NewMemoryStore(props)
+	middleware := NewMiddleware(store, props, nil)
+	handler := &testHandler{panic: true}
+
+	assert.Panics(t, func() {
+		middleware.Handle(handler, newTestMessage("e1"), handler.HandlerFunc)
+	})
+	exists, err := store.Exists(context.Background(), "testHandler/e1")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestMemoryStore_WhenCapacityIsReachedOrKeyExpires_ShouldForgetKeys(t *testing.T) {
+	store := NewMemoryStore(&properties.Dedup{Ttl: time.Hour, Capacity: 2})
+	ctx := context.Background()
+	assert.NoError(t, store.Record(ctx, "k1"))
+	assert.NoError(t, store.Record(ctx, "k2"))
+	exists, _ := store.Exists(ctx, "k1") // k1 becomes the most recently used
+	assert.True(t, exists)
+	assert.NoError(t, store.Record(ctx, "k3"))
+	exists, _ = store.Exists(ctx, "k2")
+	assert.False(t, exists)
+	exists, _ = store.Exists(ctx, "k1")
+	assert.True(t, exists)
+
+	store = NewMemoryStore(&properties.Dedup{Ttl: -time.Second, Capacity: 2})
+	assert.NoError(t, store.Record(ctx, "k1"))
+	exists, _ = store.Exists(ctx, "k1")
+	assert.False(t, exists)
+}
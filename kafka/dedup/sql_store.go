@@ -0,0 +1,67 @@
+package dedup
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/golibs-starter/golib-message-bus/kafka/internal/sqldialect"
+	"github.com/golibs-starter/golib-message-bus/kafka/properties"
+	"github.com/pkg/errors"
+	"time"
+)
+
+const DialectMysql = sqldialect.Mysql
+const DialectPostgres = sqldialect.Postgres
+
+// SqlStore is a DedupStore backed by a database/sql table.
+// Times are stored as unix milliseconds to stay independent of the driver.
+// Expired keys are deleted by Purge, which the StoreCleaner calls every purge interval.
+type SqlStore struct {
+	db    *sql.DB
+	props *properties.Dedup
+}
+
+func NewSqlStore(db *sql.DB, props *properties.Dedup) (*SqlStore, error) {
+	if !sqldialect.Supported(props.Dialect) {
+		return nil, fmt.Errorf("dedup dialect [%s] is not supported", props.Dialect)
+	}
+	return &SqlStore{db: db, props: props}, nil
+}
+
+func (s SqlStore) Exists(ctx context.Context, key string) (bool, error) {
+	query := s.bind(fmt.Sprintf("SELECT COUNT(1) FROM %s WHERE dedup_key = ? AND processed_at > ?", s.props.TableName))
+	var count int
+	if err := s.db.QueryRowContext(ctx, query, key, time.Now().Add(-s.props.Ttl).UnixMilli()).Scan(&count); err != nil {
+		return false, errors.WithMessagef(err, "query dedup key [%s] failed", key)
+	}
+	return count > 0, nil
+}
+
+func (s SqlStore) Record(ctx context.Context, key string) error {
+	var query string
+	if s.props.Dialect == DialectPostgres {
+		query = fmt.Sprintf("INSERT INTO %s (dedup_key, processed_at) VALUES ($1, $2) "+
+			"ON CONFLICT (dedup_key) DO UPDATE SET processed_at = EXCLUDED.processed_at", s.props.TableName)
+	} else {
+		query = fmt.Sprintf("INSERT INTO %s (dedup_key, processed_at) VALUES (?, ?) "+
+			"ON DUPLICATE KEY UPDATE processed_at = VALUES(processed_at)", s.props.TableName)
+	}
+	if _, err := s.db.ExecContext(ctx, query, key, time.Now().UnixMilli()); err != nil {
+		return errors.WithMessagef(err, "record dedup key [%s] failed", key)
+	}
+	return nil
+}
+
+// Purge deletes the expired keys
+func (s SqlStore) Purge(ctx context.Context) (int64, error) {
+	query := s.bind(fmt.Sprintf("DELETE FROM %s WHERE processed_at <= ?", s.props.TableName))
+	result, err := s.db.ExecContext(ctx, query, time.Now().Add(-s.props.Ttl).UnixMilli())
+	if err != nil {
+		return 0, errors.WithMessage(err, "purge dedup keys failed")
+	}
+	return result.RowsAffected()
+}
+
+func (s SqlStore) bind(query string) string {
+	return sqldialect.Bind(s.props.Dialect, query)
+}
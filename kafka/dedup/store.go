@@ -0,0 +1,13 @@
+package dedup
+
+import "context"
+
+// DedupStore remembers the keys of processed messages
+type DedupStore interface {
+
+	// Exists reports whether the key has been recorded and is not expired
+	Exists(ctx context.Context, key string) (bool, error)
+
+	// Record the key of a processed message
+	Record(ctx context.Context, key string) error
+}
@@ -0,0 +1,71 @@
+package dedup
+
+import (
+	"context"
+	"github.com/golibs-starter/golib-message-bus/kafka/properties"
+	"github.com/golibs-starter/golib/log"
+	"sync"
+	"time"
+)
+
+// Purger is implemented by the stores which have to delete their expired keys, eg: SqlStore
+type Purger interface {
+
+	// Purge deletes the expired keys and returns the number of deleted keys
+	Purge(ctx context.Context) (int64, error)
+}
+
+// StoreCleaner purges the expired keys every purge interval,
+// it does nothing when the store doesn't implement Purger.
+type StoreCleaner struct {
+	store    DedupStore
+	props    *properties.Dedup
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+func NewStoreCleaner(store DedupStore, props *properties.Dedup) *StoreCleaner {
+	return &StoreCleaner{store: store, props: props, stopCh: make(chan struct{})}
+}
+
+// Start purges the store in background until ctx is done or Stop is called.
+func (c *StoreCleaner) Start(ctx context.Context) {
+	purger, ok := c.store.(Purger)
+	if !ok {
+		return
+	}
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		c.run(ctx, purger)
+	}()
+}
+
+func (c *StoreCleaner) run(ctx context.Context, purger Purger) {
+	ticker := time.NewTicker(c.props.PurgeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			deleted, err := purger.Purge(ctx)
+			if err != nil {
+				log.WithErrors(err).Warnf("Dedup store cannot be purged")
+			} else if deleted > 0 {
+				log.Infof("Dedup store purged [%d] expired keys", deleted)
+			}
+		case <-ctx.Done():
+			return
+		case <-c.stopCh:
+			return
+		}
+	}
+}
+
+// Stop the cleaner and wait for the in-flight purge to finish
+func (c *StoreCleaner) Stop() {
+	c.stopOnce.Do(func() {
+		close(c.stopCh)
+	})
+	c.wg.Wait()
+}
@@ -0,0 +1,38 @@
+package dedup
+
+import (
+	"context"
+	"github.com/golibs-starter/golib-message-bus/kafka/properties"
+	assert "github.com/stretchr/testify/require"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type testPurgingStore struct {
+	*MemoryStore
+	purges int32
+}
+
+func (s *testPurgingStore) Purge(context.Context) (int64, error) {
+	atomic.AddInt32(&s.purges, 1)
+	return 0, nil
+}
+
+func TestStoreCleaner_WhenStoreIsPurger_ShouldPurgeEveryInterval(t *testing.T) {
+	props := &properties.Dedup{Ttl: time.Hour, Capacity: 10, PurgeInterval: 10 * time.Millisecond}
+	store := &testPurgingStore{MemoryStore: NewMemoryStore(props)}
+	cleaner := NewStoreCleaner(store, props)
+	cleaner.Start(context.Background())
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&store.purges) >= 2
+	}, time.Second, 5*time.Millisecond)
+	cleaner.Stop()
+}
+
+func TestStoreCleaner_WhenStoreIsNotPurger_ShouldDoNothing(t *testing.T) {
+	props := &properties.Dedup{Ttl: time.Hour, Capacity: 10, PurgeInterval: time.Millisecond}
+	cleaner := NewStoreCleaner(NewMemoryStore(props), props)
+	cleaner.Start(context.Background())
+	cleaner.Stop()
+}
@@ -0,0 +1,95 @@
+package encryption
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	kafkaConstant "github.com/golibs-starter/golib-message-bus/kafka/constant"
+	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"github.com/pkg/errors"
+	"strings"
+)
+
+// Decrypter decrypts messages encrypted by the Encrypter
+type Decrypter struct {
+	kms Kms
+}
+
+func NewDecrypter(kms Kms) *Decrypter {
+	return &Decrypter{kms: kms}
+}
+
+// IsEncrypted reports whether the message has been encrypted by the Encrypter
+func IsEncrypted(msg *core.ConsumerMessage) bool {
+	_, exists := headerValue(msg.Headers, kafkaConstant.HeaderEncryptionKeyId)
+	return exists
+}
+
+// Decrypt the message in place and remove the encryption headers,
+// messages which are not encrypted are left untouched.
+func (d Decrypter) Decrypt(ctx context.Context, msg *core.ConsumerMessage) error {
+	keyId, exists := headerValue(msg.Headers, kafkaConstant.HeaderEncryptionKeyId)
+	if !exists {
+		return nil
+	}
+	encodedKey, exists := headerValue(msg.Headers, kafkaConstant.HeaderEncryptionDataKey)
+	if !exists {
+		return missingHeaderError(kafkaConstant.HeaderEncryptionDataKey)
+	}
+	wrappedKey, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil {
+		return errors.WithMessage(err, "decode data key failed")
+	}
+	dataKey, err := d.kms.UnwrapKey(ctx, keyId, wrappedKey)
+	if err != nil {
+		return errors.WithMessagef(err, "unwrap data key with master key [%s] failed", keyId)
+	}
+	var value []byte
+	if fields, exists := headerValue(msg.Headers, kafkaConstant.HeaderEncryptionFields); exists {
+		if value, err = d.decryptFields(dataKey, msg.Value, strings.Split(fields, ",")); err != nil {
+			return err
+		}
+	} else if value, err = open(dataKey, msg.Value); err != nil {
+		return errors.WithMessage(err, "decrypt message value failed")
+	}
+	headers := make([]core.MessageHeader, 0, len(msg.Headers))
+	for _, header := range msg.Headers {
+		if !isEncryptionHeader(header.Key) {
+			headers = append(headers, header)
+		}
+	}
+	msg.Value = value
+	msg.Headers = headers
+	return nil
+}
+
+func (d Decrypter) decryptFields(dataKey []byte, value []byte, fields []string) ([]byte, error) {
+	document, err := unmarshalDocument(value)
+	if err != nil {
+		return nil, errors.WithMessage(err, "unmarshal message value failed")
+	}
+	for _, field := range fields {
+		parent, name := lookupParent(document, field)
+		if parent == nil {
+			continue
+		}
+		encoded, ok := parent[name].(string)
+		if !ok {
+			continue
+		}
+		ciphertext, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "decode field [%s] failed", field)
+		}
+		plaintext, err := open(dataKey, ciphertext)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "decrypt field [%s] failed", field)
+		}
+		var fieldValue json.RawMessage
+		if err := json.Unmarshal(plaintext, &fieldValue); err != nil {
+			return nil, errors.WithMessagef(err, "unmarshal field [%s] failed", field)
+		}
+		parent[name] = fieldValue
+	}
+	return json.Marshal(document)
+}
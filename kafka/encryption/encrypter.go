@@ -0,0 +1,135 @@
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	kafkaConstant "github.com/golibs-starter/golib-message-bus/kafka/constant"
+	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"github.com/pkg/errors"
+	"strings"
+)
+
+// Encrypter encrypts messages with a random data key wrapped by the Kms.
+// The key id, the wrapped data key and the encrypted fields are stored in the message headers.
+type Encrypter struct {
+	kms Kms
+}
+
+func NewEncrypter(kms Kms) *Encrypter {
+	return &Encrypter{kms: kms}
+}
+
+// Encrypt the whole message value, or only the provided fields when the value is a JSON object.
+// A field is a dot separated path, eg: payload.email, missing fields are ignored.
+func (e Encrypter) Encrypt(ctx context.Context, msg *core.Message, keyId string, fields []string) error {
+	dataKey, err := newDataKey()
+	if err != nil {
+		return errors.WithMessage(err, "generate data key failed")
+	}
+	wrappedKey, err := e.kms.WrapKey(ctx, keyId, dataKey)
+	if err != nil {
+		return errors.WithMessagef(err, "wrap data key with master key [%s] failed", keyId)
+	}
+	if len(fields) == 0 {
+		value, err := seal(dataKey, msg.Value)
+		if err != nil {
+			return errors.WithMessage(err, "encrypt message value failed")
+		}
+		msg.Value = value
+	} else {
+		value, err := e.encryptFields(dataKey, msg.Value, fields)
+		if err != nil {
+			return err
+		}
+		msg.Value = value
+		msg.Headers = append(msg.Headers, core.MessageHeader{
+			Key:   []byte(kafkaConstant.HeaderEncryptionFields),
+			Value: []byte(strings.Join(fields, ",")),
+		})
+	}
+	msg.Headers = append(msg.Headers,
+		core.MessageHeader{Key: []byte(kafkaConstant.HeaderEncryptionKeyId), Value: []byte(keyId)},
+		core.MessageHeader{
+			Key:   []byte(kafkaConstant.HeaderEncryptionDataKey),
+			Value: []byte(base64.StdEncoding.EncodeToString(wrappedKey)),
+		},
+	)
+	return nil
+}
+
+// encryptFields replaces each field by the base64 encoded ciphertext of its JSON value
+func (e Encrypter) encryptFields(dataKey []byte, value []byte, fields []string) ([]byte, error) {
+	document, err := unmarshalDocument(value)
+	if err != nil {
+		return nil, errors.WithMessage(err, "field encryption requires a JSON object value")
+	}
+	for _, field := range fields {
+		parent, name := lookupParent(document, field)
+		if parent == nil {
+			continue
+		}
+		fieldValue, exists := parent[name]
+		if !exists {
+			continue
+		}
+		plaintext, err := json.Marshal(fieldValue)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "marshal field [%s] failed", field)
+		}
+		ciphertext, err := seal(dataKey, plaintext)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "encrypt field [%s] failed", field)
+		}
+		parent[name] = base64.StdEncoding.EncodeToString(ciphertext)
+	}
+	return json.Marshal(document)
+}
+
+// unmarshalDocument keeps numbers as json.Number to not lose their precision
+func unmarshalDocument(value []byte) (map[string]interface{}, error) {
+	var document map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(value))
+	decoder.UseNumber()
+	if err := decoder.Decode(&document); err != nil {
+		return nil, err
+	}
+	return document, nil
+}
+
+// lookupParent returns the object containing the last segment of the path
+func lookupParent(document map[string]interface{}, path string) (map[string]interface{}, string) {
+	segments := strings.Split(path, ".")
+	current := document
+	for _, segment := range segments[:len(segments)-1] {
+		next, ok := current[segment].(map[string]interface{})
+		if !ok {
+			return nil, ""
+		}
+		current = next
+	}
+	return current, segments[len(segments)-1]
+}
+
+func headerValue(headers []core.MessageHeader, key string) (string, bool) {
+	for _, header := range headers {
+		if strings.EqualFold(string(header.Key), key) {
+			return string(header.Value), true
+		}
+	}
+	return "", false
+}
+
+func isEncryptionHeader(key []byte) bool {
+	switch strings.ToLower(string(key)) {
+	case kafkaConstant.HeaderEncryptionKeyId, kafkaConstant.HeaderEncryptionDataKey, kafkaConstant.HeaderEncryptionFields:
+		return true
+	}
+	return false
+}
+
+func missingHeaderError(key string) error {
+	return fmt.Errorf("encryption header [%s] is missing", key)
+}
@@ -0,0 +1,62 @@
+package encryption
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"github.com/golibs-starter/golib-message-bus/kafka/properties"
+	assert "github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newTestLocalKms(t *testing.T) *LocalKms {
+	key := make([]byte, DataKeySize)
+	keyFile := filepath.Join(t.TempDir(), "keys.json")
+	content, err := json.Marshal(map[string]string{"key-1": base64.StdEncoding.EncodeToString(key)})
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(keyFile, content, 0600))
+	kms, err := NewLocalKms(&properties.Encryption{KeyFile: keyFile})
+	assert.NoError(t, err)
+	return kms
+}
+
+func TestEncrypter_WhenEncryptWholeValue_ShouldDecryptToOriginalValue(t *testing.T) {
+	kms := newTestLocalKms(t)
+	msg := &core.Message{Value: []byte(`{"id":"1","payload":{"email":"user@example.com"}}`)}
+
+	assert.NoError(t, NewEncrypter(kms).Encrypt(context.Background(), msg, "key-1", nil))
+	assert.NotContains(t, string(msg.Value), "user@example.com")
+
+	consumerMsg := &core.ConsumerMessage{Value: msg.Value, Headers: msg.Headers}
+	assert.True(t, IsEncrypted(consumerMsg))
+	assert.NoError(t, NewDecrypter(kms).Decrypt(context.Background(), consumerMsg))
+	assert.Equal(t, `{"id":"1","payload":{"email":"user@example.com"}}`, string(consumerMsg.Value))
+	assert.False(t, IsEncrypted(consumerMsg))
+	assert.Empty(t, consumerMsg.Headers)
+}
+
+func TestEncrypter_WhenEncryptFields_ShouldOnlyEncryptTheseFields(t *testing.T) {
+	kms := newTestLocalKms(t)
+	msg := &core.Message{
+		Value: []byte(`{"id":"1","payload":{"email":"user@example.com","amount":12345678901234567890}}`),
+	}
+
+	err := NewEncrypter(kms).Encrypt(context.Background(), msg, "key-1", []string{"payload.email", "payload.missing"})
+	assert.NoError(t, err)
+	assert.NotContains(t, string(msg.Value), "user@example.com")
+	assert.Contains(t, string(msg.Value), `"id":"1"`)
+
+	consumerMsg := &core.ConsumerMessage{Value: msg.Value, Headers: msg.Headers}
+	assert.NoError(t, NewDecrypter(kms).Decrypt(context.Background(), consumerMsg))
+	assert.JSONEq(t, `{"id":"1","payload":{"email":"user@example.com","amount":12345678901234567890}}`,
+		string(consumerMsg.Value))
+}
+
+func TestEncrypter_WhenMasterKeyIsUnknown_ShouldReturnError(t *testing.T) {
+	kms := newTestLocalKms(t)
+	msg := &core.Message{Value: []byte(`{}`)}
+	assert.Error(t, NewEncrypter(kms).Encrypt(context.Background(), msg, "key-2", nil))
+}
@@ -0,0 +1,76 @@
+package encryption
+
+import (
+	"context"
+	"fmt"
+	"github.com/golibs-starter/golib-message-bus/kafka/codec"
+	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"github.com/golibs-starter/golib-message-bus/kafka/properties"
+	"github.com/golibs-starter/golib-message-bus/kafka/relayer"
+	"github.com/golibs-starter/golib/pubsub"
+	"github.com/pkg/errors"
+	"strings"
+)
+
+// EventConverter is a relayer.EventConverter which encrypts the messages of events
+// whose EventTopic has an encryption key id. Encrypted messages are decrypted before being restored.
+type EventConverter struct {
+	delegate           relayer.EventConverter
+	eventProducerProps *properties.EventProducer
+	encrypter          *Encrypter
+	decrypter          *Decrypter
+}
+
+func NewEventConverter(
+	delegate relayer.EventConverter,
+	eventProducerProps *properties.EventProducer,
+	encrypter *Encrypter,
+	decrypter *Decrypter,
+) (*EventConverter, error) {
+	for event, eventTopic := range eventProducerProps.EventMappings {
+		if len(eventTopic.EncryptedFields) == 0 {
+			continue
+		}
+		if eventTopic.EncryptionKeyId == "" {
+			return nil, fmt.Errorf("encrypted fields of event [%s] require an encryption key id", event)
+		}
+		if eventTopic.Codec != "" && eventTopic.Codec != codec.Json {
+			return nil, fmt.Errorf("encrypted fields of event [%s] cannot be used with codec [%s]", event, eventTopic.Codec)
+		}
+	}
+	return &EventConverter{
+		delegate:           delegate,
+		eventProducerProps: eventProducerProps,
+		encrypter:          encrypter,
+		decrypter:          decrypter,
+	}, nil
+}
+
+func (c EventConverter) Convert(event pubsub.Event) (*core.Message, error) {
+	message, err := c.delegate.Convert(event)
+	if err != nil {
+		return nil, err
+	}
+	eventTopic := c.eventProducerProps.EventMappings[strings.ToLower(event.Name())]
+	if eventTopic.EncryptionKeyId == "" {
+		return message, nil
+	}
+	// The event context is not used since it may be done when events are handled asynchronously
+	if err := c.encrypter.Encrypt(context.Background(), message, eventTopic.EncryptionKeyId, eventTopic.EncryptedFields); err != nil {
+		return nil, errors.WithMessagef(err, "encrypt event [%s] failed", event.Name())
+	}
+	return message, nil
+}
+
+func (c EventConverter) Restore(msg *core.ConsumerMessage, dest pubsub.Event) error {
+	if err := c.decrypter.Decrypt(context.Background(), msg); err != nil {
+		return errors.WithMessage(err, "decrypt consumer message failed")
+	}
+	return c.delegate.Restore(msg, dest)
+}
+
+func (c EventConverter) RestoreAttributes(msg *core.ConsumerMessage, dest pubsub.Event) {
+	if restorer, ok := c.delegate.(relayer.EventAttributesRestorer); ok {
+		restorer.RestoreAttributes(msg, dest)
+	}
+}
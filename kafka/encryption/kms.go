@@ -0,0 +1,13 @@
+package encryption
+
+import "context"
+
+// Kms wraps and unwraps the data keys used to encrypt messages
+type Kms interface {
+
+	// WrapKey encrypts the data key with the master key identified by keyId
+	WrapKey(ctx context.Context, keyId string, dataKey []byte) ([]byte, error)
+
+	// UnwrapKey decrypts the data key wrapped by the master key identified by keyId
+	UnwrapKey(ctx context.Context, keyId string, wrappedKey []byte) ([]byte, error)
+}
@@ -0,0 +1,65 @@
+package encryption
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/golibs-starter/golib-message-bus/kafka/properties"
+	"github.com/pkg/errors"
+	"os"
+)
+
+// LocalKms wraps data keys with master keys loaded from a local key file.
+// The key file is a JSON object of key ids to base64 encoded 256 bits keys,
+// eg: {"key-1": "<base64>"}. It's intended for tests and local environments.
+type LocalKms struct {
+	keys map[string][]byte
+}
+
+func NewLocalKms(props *properties.Encryption) (*LocalKms, error) {
+	content, err := os.ReadFile(props.KeyFile)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "read key file [%s] failed", props.KeyFile)
+	}
+	var encodedKeys map[string]string
+	if err := json.Unmarshal(content, &encodedKeys); err != nil {
+		return nil, errors.WithMessagef(err, "parse key file [%s] failed", props.KeyFile)
+	}
+	keys := make(map[string][]byte, len(encodedKeys))
+	for keyId, encodedKey := range encodedKeys {
+		key, err := base64.StdEncoding.DecodeString(encodedKey)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "decode key [%s] failed", keyId)
+		}
+		if len(key) != DataKeySize {
+			return nil, fmt.Errorf("key [%s] must be %d bytes", keyId, DataKeySize)
+		}
+		keys[keyId] = key
+	}
+	return &LocalKms{keys: keys}, nil
+}
+
+func (l LocalKms) WrapKey(_ context.Context, keyId string, dataKey []byte) ([]byte, error) {
+	masterKey, err := l.masterKey(keyId)
+	if err != nil {
+		return nil, err
+	}
+	return seal(masterKey, dataKey)
+}
+
+func (l LocalKms) UnwrapKey(_ context.Context, keyId string, wrappedKey []byte) ([]byte, error) {
+	masterKey, err := l.masterKey(keyId)
+	if err != nil {
+		return nil, err
+	}
+	return open(masterKey, wrappedKey)
+}
+
+func (l LocalKms) masterKey(keyId string) ([]byte, error) {
+	key, exists := l.keys[keyId]
+	if !exists {
+		return nil, fmt.Errorf("master key [%s] is not found", keyId)
+	}
+	return key, nil
+}
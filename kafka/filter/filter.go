@@ -0,0 +1,120 @@
+package filter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/golibs-starter/golib-message-bus/kafka/constant"
+	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"github.com/golibs-starter/golib-message-bus/kafka/properties"
+	"strings"
+)
+
+// Filter evaluates the declarative filters of a consumer
+type Filter struct {
+	filters []properties.MessageFilter
+}
+
+// New creates the filter, it returns nil when there is no filter.
+func New(filters []properties.MessageFilter) (*Filter, error) {
+	if len(filters) == 0 {
+		return nil, nil
+	}
+	for i, filter := range filters {
+		criteria := 0
+		for _, criterion := range []string{filter.Header, filter.EventName, filter.KeyPrefix, filter.JsonPath} {
+			if criterion != "" {
+				criteria++
+			}
+		}
+		if criteria != 1 {
+			return nil, fmt.Errorf("filter [%d] must have exactly one of header, eventName, keyPrefix, jsonPath", i)
+		}
+	}
+	return &Filter{filters: filters}, nil
+}
+
+// Match reports whether the message matches all filters
+func (f Filter) Match(msg *core.ConsumerMessage) bool {
+	var document *jsonDocument
+	for _, filter := range f.filters {
+		switch {
+		case filter.Header != "":
+			value, exists := headerValue(msg, filter.Header)
+			if !exists || (filter.Equals != "" && value != filter.Equals) {
+				return false
+			}
+		case filter.KeyPrefix != "":
+			if !bytes.HasPrefix(msg.Key, []byte(filter.KeyPrefix)) {
+				return false
+			}
+		case filter.EventName != "":
+			// The header is independent of the codec, the value is never decoded for it
+			eventName, _ := headerValue(msg, constant.HeaderEventName)
+			if !strings.EqualFold(eventName, filter.EventName) {
+				return false
+			}
+		case filter.JsonPath != "":
+			if document == nil {
+				document = parseDocument(msg.Value)
+			}
+			value, exists := document.lookup(filter.JsonPath)
+			if !exists || (filter.Equals != "" && value != filter.Equals) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func headerValue(msg *core.ConsumerMessage, key string) (string, bool) {
+	for _, header := range msg.Headers {
+		if strings.EqualFold(string(header.Key), key) {
+			return string(header.Value), true
+		}
+	}
+	return "", false
+}
+
+// jsonDocument is the JSON value of a message, it's empty when the value is not a JSON object
+type jsonDocument struct {
+	root map[string]interface{}
+}
+
+func parseDocument(value []byte) *jsonDocument {
+	var root map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(value))
+	decoder.UseNumber()
+	if err := decoder.Decode(&root); err != nil {
+		return &jsonDocument{}
+	}
+	return &jsonDocument{root: root}
+}
+
+// lookup returns the string form of the value at the path
+func (d jsonDocument) lookup(path string) (string, bool) {
+	var current interface{} = d.root
+	for _, segment := range strings.Split(path, ".") {
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return "", false
+		}
+		if current, ok = object[segment]; !ok {
+			return "", false
+		}
+	}
+	switch value := current.(type) {
+	case string:
+		return value, true
+	case json.Number, bool:
+		return fmt.Sprint(value), true
+	case nil:
+		return "null", true
+	default:
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return "", false
+		}
+		return string(encoded), true
+	}
+}
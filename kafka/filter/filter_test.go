@@ -0,0 +1,53 @@
+package filter
+
+import (
+	"github.com/golibs-starter/golib-message-bus/kafka/constant"
+	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"github.com/golibs-starter/golib-message-bus/kafka/properties"
+	assert "github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestNew_WhenFilterHasManyCriteria_ShouldReturnError(t *testing.T) {
+	_, err := New([]properties.MessageFilter{{Header: "x-tenant", KeyPrefix: "order-"}})
+	assert.Error(t, err)
+}
+
+func TestFilter_WhenAllFiltersMatch_ShouldMatch(t *testing.T) {
+	f, err := New([]properties.MessageFilter{
+		{Header: "x-tenant", Equals: "acme"},
+		{KeyPrefix: "order-"},
+		{EventName: "OrderCreatedEvent"},
+		{JsonPath: "payload.amount", Equals: "10"},
+		{JsonPath: "payload.paid", Equals: "true"},
+	})
+	assert.NoError(t, err)
+	msg := &core.ConsumerMessage{
+		Key:   []byte("order-1"),
+		Value: []byte(`{"event":"OrderCreatedEvent","payload":{"amount":10,"paid":true}}`),
+		Headers: []core.MessageHeader{
+			{Key: []byte("x-tenant"), Value: []byte("acme")},
+			{Key: []byte(constant.HeaderEventName), Value: []byte("OrderCreatedEvent")},
+		},
+	}
+	assert.True(t, f.Match(msg))
+
+	msg.Key = []byte("invoice-1")
+	assert.False(t, f.Match(msg))
+}
+
+func TestFilter_WhenValueIsNotJson_ShouldNotMatchJsonPath(t *testing.T) {
+	f, err := New([]properties.MessageFilter{{JsonPath: "payload"}})
+	assert.NoError(t, err)
+	assert.False(t, f.Match(&core.ConsumerMessage{Value: []byte("raw")}))
+}
+
+func TestFilter_WhenEventNameHeaderIsAbsent_ShouldNotMatchEventName(t *testing.T) {
+	f, err := New([]properties.MessageFilter{{EventName: "OrderCreatedEvent"}})
+	assert.NoError(t, err)
+	assert.False(t, f.Match(&core.ConsumerMessage{Value: []byte(`{"event":"OrderCreatedEvent"}`)}))
+	assert.True(t, f.Match(&core.ConsumerMessage{
+		Value:   []byte{0x81, 0xa1, 0x61},
+		Headers: []core.MessageHeader{{Key: []byte("event-name"), Value: []byte("OrderCreatedEvent")}},
+	}))
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	kafkaConstant "github.com/golibs-starter/golib-message-bus/kafka/constant"
 	"github.com/golibs-starter/golib-message-bus/kafka/core"
 	"github.com/golibs-starter/golib-message-bus/kafka/log"
 	"github.com/golibs-starter/golib/event"
@@ -11,8 +12,12 @@ func AsyncProducerSuccessLogHandler(producer core.AsyncProducer, eventProps *eve
 	go func() {
 		messageFormat := "Success to produce to kafka partition [%d], offset [%d], message %s"
 		for msg := range producer.Successes() {
+			metadata, ok := msg.Metadata.(map[string]interface{})
+			if ok && metadata[kafkaConstant.DeliveryMode] == kafkaConstant.DeliveryModeFireAndForget {
+				continue
+			}
 			descMessage := log.DescMessage(msg, eventProps.Log.NotLogPayloadForEvents)
-			if metadata, ok := msg.Metadata.(map[string]interface{}); ok {
+			if ok {
 				coreLog.WithField(log.GetLoggingContext(metadata)...).
 					Infof(messageFormat, msg.Partition, msg.Offset, descMessage)
 			} else {
@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"github.com/golibs-starter/golib-message-bus/kafka/properties"
+	"github.com/pkg/errors"
+)
+
+func CreateKafkaAclHandler(admin core.AclAdmin, props *properties.TopicAdmin) error {
+	acls := BuildServiceAcls(props.Acls)
+	if len(acls) == 0 {
+		return nil
+	}
+	if err := admin.CreateACLs(acls); err != nil {
+		return errors.WithMessage(err, "create ACLs failed")
+	}
+	return nil
+}
+
+// BuildServiceAcls returns the ACLs allowing each principal to read
+// consumed topics and consumer groups, and to write produced topics.
+func BuildServiceAcls(props properties.Acls) []core.Acl {
+	acls := make([]core.Acl, 0)
+	for _, principal := range props.Principals {
+		newAcl := func(resourceType string, resourceName string, operation string) core.Acl {
+			return core.Acl{
+				ResourceType: resourceType,
+				ResourceName: resourceName,
+				PatternType:  core.AclPatternLiteral,
+				Principal:    principal,
+				Host:         props.Host,
+				Operation:    operation,
+				Permission:   core.AclPermissionAllow,
+			}
+		}
+		for _, topic := range props.ConsumedTopics {
+			acls = append(acls, newAcl(core.AclResourceTopic, topic, core.AclOperationRead))
+		}
+		for _, group := range props.ConsumerGroups {
+			acls = append(acls, newAcl(core.AclResourceGroup, group, core.AclOperationRead))
+		}
+		for _, topic := range props.ProducedTopics {
+			acls = append(acls, newAcl(core.AclResourceTopic, topic, core.AclOperationWrite))
+		}
+	}
+	return acls
+}
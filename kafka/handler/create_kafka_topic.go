@@ -1,15 +1,49 @@
 package handler
 
 import (
+	"fmt"
 	"github.com/golibs-starter/golib-message-bus/kafka/core"
 	"github.com/golibs-starter/golib-message-bus/kafka/properties"
+	"github.com/golibs-starter/golib/log"
 	"github.com/pkg/errors"
 )
 
-func CreateKafkaTopicHandler(admin core.Admin, props *properties.TopicAdmin) error {
+func CreateKafkaTopicHandler(admin core.TopicAdmin, props *properties.TopicAdmin) error {
+	if props.Reconcile {
+		return reconcileKafkaTopics(admin, props)
+	}
 	err := admin.CreateTopics(props.Topics)
 	if err != nil {
 		return errors.WithMessage(err, "create topics failed")
 	}
 	return nil
 }
+
+func reconcileKafkaTopics(admin core.TopicAdmin, props *properties.TopicAdmin) error {
+	plan, err := admin.PlanTopics(props.Topics)
+	if err != nil {
+		return errors.WithMessage(err, "reconcile topics failed")
+	}
+	WarnKeyedTopicExpansions(plan, props.Topics)
+	if props.DryRun {
+		log.Infof("Kafka topics reconciliation plan (dry run):\n%s", plan)
+		return nil
+	}
+	log.Infof("Kafka topics reconciliation plan:\n%s", plan)
+	if len(plan.Irreconcilable) > 0 && props.FailOnIrreconcilable {
+		return fmt.Errorf("reconcile topics failed: [%d] differences cannot be reconciled", len(plan.Irreconcilable))
+	}
+	if err := admin.ApplyTopicPlan(plan, props.ForcePartitionIncrease); err != nil {
+		return errors.WithMessage(err, "reconcile topics failed")
+	}
+	return nil
+}
+
+// WarnKeyedTopicExpansions logs a warning for each keyed topic whose partitions are increased by the plan,
+// because messages with the same key are not routed to the same partition anymore.
+func WarnKeyedTopicExpansions(plan *core.TopicPlan, configurations []core.TopicConfiguration) {
+	for _, expansion := range plan.KeyedExpansions(configurations) {
+		log.Warnf("Increasing partitions of keyed topic [%s] from [%d] to [%d] breaks the key-to-partition affinity, "+
+			"messages with the same key may be consumed out of order", expansion.Topic, expansion.From, expansion.To)
+	}
+}
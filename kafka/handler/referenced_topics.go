@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"github.com/golibs-starter/golib-message-bus/kafka/properties"
+	"sort"
+	"strings"
+)
+
+// ReferencedTopics returns the sorted topics referenced by enabled consumer and producer mappings,
+// including invalid message and dead letter topics. Topic patterns are not included.
+// Both properties are optional.
+func ReferencedTopics(consumerProps *properties.KafkaConsumer, eventProducerProps *properties.EventProducer) []string {
+	topicSet := make(map[string]bool)
+	addTopic := func(topic string) {
+		if topic = strings.TrimSpace(topic); topic != "" {
+			topicSet[topic] = true
+		}
+	}
+	if consumerProps != nil {
+		for _, topicConsumer := range consumerProps.HandlerMappings {
+			if !topicConsumer.Enable {
+				continue
+			}
+			addTopic(topicConsumer.Topic)
+			for _, topic := range topicConsumer.Topics {
+				addTopic(topic)
+			}
+			addTopic(topicConsumer.InvalidMessageTopic)
+			addTopic(topicConsumer.DeadLetterTopic)
+		}
+	}
+	if eventProducerProps != nil {
+		for _, eventTopic := range eventProducerProps.EventMappings {
+			if !eventTopic.Disable {
+				addTopic(eventTopic.TopicName)
+			}
+		}
+	}
+	topics := make([]string, 0, len(topicSet))
+	for topic := range topicSet {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	return topics
+}
+
+// DeriveTopics returns the configured topics, plus the referenced topics built from
+// the topic defaults when topics derivation is enabled.
+func DeriveTopics(props *properties.TopicAdmin, referencedTopics []string) []core.TopicConfiguration {
+	if !props.DeriveTopics {
+		return props.Topics
+	}
+	topics := append(make([]core.TopicConfiguration, 0, len(props.Topics)+len(referencedTopics)), props.Topics...)
+	configuredTopics := make(map[string]bool, len(props.Topics))
+	for _, topic := range props.Topics {
+		configuredTopics[topic.Name] = true
+	}
+	for _, name := range referencedTopics {
+		if configuredTopics[name] {
+			continue
+		}
+		topic := props.TopicDefaults
+		topic.Name = name
+		topics = append(topics, topic)
+	}
+	return topics
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"github.com/golibs-starter/golib-message-bus/kafka/properties"
+	"github.com/golibs-starter/golib/log"
+	"strings"
+	"time"
+)
+
+// WaitForKafkaTopics blocks until the cluster is reachable and all topics exist with a leader on each partition.
+// Returns an error reporting what is not ready when the timeout is exceeded.
+func WaitForKafkaTopics(admin core.Admin, topics []string, props *properties.TopicReadiness) error {
+	log.Infof("Wait for Kafka topics [%v] are ready", topics)
+	deadline := time.Now().Add(props.Timeout)
+	for {
+		problems := checkKafkaTopics(admin, topics)
+		if len(problems) == 0 {
+			log.Infof("Kafka topics [%v] are ready", topics)
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("kafka topics are not ready after [%s]: %s", props.Timeout, strings.Join(problems, "; "))
+		}
+		log.Infof("Kafka topics are not ready, retry after [%s]: %s", props.Interval, strings.Join(problems, "; "))
+		time.Sleep(props.Interval)
+	}
+}
+
+// checkKafkaTopics returns the reasons the cluster or the topics are not ready
+func checkKafkaTopics(admin core.Admin, topics []string) []string {
+	if _, err := admin.DescribeCluster(); err != nil {
+		return []string{fmt.Sprintf("cluster is not reachable: %s", err)}
+	}
+	existingTopics, err := admin.ListTopics()
+	if err != nil {
+		return []string{fmt.Sprintf("cannot list topics: %s", err)}
+	}
+	existingTopicSet := make(map[string]bool, len(existingTopics))
+	for _, topic := range existingTopics {
+		existingTopicSet[topic] = true
+	}
+	problems := make([]string, 0)
+	presentTopics := make([]string, 0, len(topics))
+	for _, topic := range topics {
+		if existingTopicSet[topic] {
+			presentTopics = append(presentTopics, topic)
+		} else {
+			problems = append(problems, fmt.Sprintf("topic [%s] does not exist", topic))
+		}
+	}
+	if len(presentTopics) == 0 {
+		return problems
+	}
+	descriptions, err := admin.DescribeTopics(presentTopics)
+	if err != nil {
+		return append(problems, fmt.Sprintf("cannot describe topics: %s", err))
+	}
+	for _, description := range descriptions {
+		for _, partition := range description.Partitions {
+			if partition.Leader < 0 {
+				problems = append(problems, fmt.Sprintf("partition [%d] of topic [%s] has no leader",
+					partition.Id, description.Name))
+			}
+		}
+	}
+	return problems
+}
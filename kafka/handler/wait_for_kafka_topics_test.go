@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"errors"
+	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"github.com/golibs-starter/golib-message-bus/kafka/properties"
+	assert "github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+type testAdmin struct {
+	core.Admin
+	clusterErr   error
+	topics       []string
+	descriptions []core.TopicDescription
+}
+
+func (t testAdmin) DescribeCluster() (*core.ClusterDescription, error) {
+	return &core.ClusterDescription{}, t.clusterErr
+}
+
+func (t testAdmin) ListTopics() ([]string, error) {
+	return t.topics, nil
+}
+
+func (t testAdmin) DescribeTopics(_ []string) ([]core.TopicDescription, error) {
+	return t.descriptions, nil
+}
+
+func TestReferencedTopics_ShouldCollectTopicsOfEnabledMappings(t *testing.T) {
+	topics := ReferencedTopics(&properties.KafkaConsumer{HandlerMappings: map[string]properties.TopicConsumer{
+		"OrderHandler":    {Enable: true, Topic: "orders", DeadLetterTopic: "orders.dlt"},
+		"PaymentHandler":  {Enable: true, Topics: []string{"payments", "orders"}},
+		"DisabledHandler": {Topic: "disabled"},
+	}}, &properties.EventProducer{EventMappings: map[string]properties.EventTopic{
+		"OrderCreatedEvent": {TopicName: "orders"},
+		"InvoiceEvent":      {TopicName: "invoices"},
+		"DisabledEvent":     {TopicName: "disabled", Disable: true},
+	}})
+	assert.Equal(t, []string{"invoices", "orders", "orders.dlt", "payments"}, topics)
+}
+
+func TestWaitForKafkaTopics_WhenTopicsAreNotReady_ShouldReportThem(t *testing.T) {
+	admin := testAdmin{
+		topics: []string{"orders"},
+		descriptions: []core.TopicDescription{{Name: "orders", Partitions: []core.PartitionDescription{
+			{Id: 0, Leader: 1}, {Id: 1, Leader: -1},
+		}}},
+	}
+	err := WaitForKafkaTopics(admin, []string{"orders", "payments"},
+		&properties.TopicReadiness{Timeout: 10 * time.Millisecond, Interval: time.Millisecond})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "topic [payments] does not exist")
+	assert.Contains(t, err.Error(), "partition [1] of topic [orders] has no leader")
+}
+
+func TestWaitForKafkaTopics_WhenClusterIsNotReachable_ShouldReportIt(t *testing.T) {
+	err := WaitForKafkaTopics(testAdmin{clusterErr: errors.New("connection refused")}, []string{"orders"},
+		&properties.TopicReadiness{Timeout: 10 * time.Millisecond, Interval: time.Millisecond})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "cluster is not reachable")
+}
+
+func TestWaitForKafkaTopics_WhenTopicsAreReady_ShouldReturn(t *testing.T) {
+	admin := testAdmin{
+		topics:       []string{"orders"},
+		descriptions: []core.TopicDescription{{Name: "orders", Partitions: []core.PartitionDescription{{Leader: 1}}}},
+	}
+	assert.NoError(t, WaitForKafkaTopics(admin, []string{"orders"},
+		&properties.TopicReadiness{Timeout: time.Second, Interval: time.Millisecond}))
+}
+
+func TestDeriveTopics_WhenEnabled_ShouldAddReferencedTopicsFromDefaults(t *testing.T) {
+	topics := DeriveTopics(&properties.TopicAdmin{
+		DeriveTopics:  true,
+		Topics:        []core.TopicConfiguration{{Name: "orders", Partitions: 6, ReplicaFactor: 3}},
+		TopicDefaults: core.TopicConfiguration{Name: "ignored", Partitions: 3, ReplicaFactor: 2},
+	}, []string{"invoices", "orders"})
+	assert.Equal(t, []core.TopicConfiguration{
+		{Name: "orders", Partitions: 6, ReplicaFactor: 3},
+		{Name: "invoices", Partitions: 3, ReplicaFactor: 2},
+	}, topics)
+}
+
+func TestDeriveTopics_WhenDisabled_ShouldReturnConfiguredTopics(t *testing.T) {
+	configured := []core.TopicConfiguration{{Name: "orders"}}
+	topics := DeriveTopics(&properties.TopicAdmin{Topics: configured}, []string{"invoices"})
+	assert.Equal(t, configured, topics)
+}
@@ -0,0 +1,34 @@
+package impl
+
+import (
+	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"github.com/golibs-starter/golib/actuator"
+)
+
+type ConsumerStats struct {
+	// Panics is the number of panics recovered from the handler
+	Panics int64 `json:"panics"`
+
+	// Filtered is the number of messages skipped by the filters
+	Filtered int64 `json:"filtered"`
+}
+
+// ConsumerInformer exposes the consumer statistics through the actuator info endpoint
+type ConsumerInformer struct {
+	consumer core.Consumer
+}
+
+func NewConsumerInformer(consumer core.Consumer) actuator.Informer {
+	return &ConsumerInformer{consumer: consumer}
+}
+
+func (i ConsumerInformer) Key() string {
+	return "kafka_consumers"
+}
+
+func (i ConsumerInformer) Value() interface{} {
+	if consumers, ok := i.consumer.(*SaramaConsumers); ok {
+		return consumers.Stats()
+	}
+	return nil
+}
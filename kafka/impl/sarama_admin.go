@@ -7,6 +7,8 @@ import (
 	"github.com/golibs-starter/golib-message-bus/kafka/properties"
 	"github.com/golibs-starter/golib/log"
 	"github.com/pkg/errors"
+	"sort"
+	"strings"
 )
 
 type SaramaAdmin struct {
@@ -19,163 +21,176 @@ func NewSaramaAdmin(props *properties.Client) (core.Admin, error) {
 	if err != nil {
 		return nil, errors.WithMessage(err, "create sarama config error")
 	}
+	if props.Admin.CreateTopicTimeout > 0 {
+		config.Admin.Timeout = props.Admin.CreateTopicTimeout
+	}
 	return &SaramaAdmin{props: props, config: config}, nil
 }
 
+// CreateTopics creates topics through the controller, existing topics are skipped.
 func (s SaramaAdmin) CreateTopics(configurations []core.TopicConfiguration) error {
 	if len(configurations) == 0 {
 		log.Infof("Skip create Kafka topics. No topics are defined")
 		return nil
 	}
-	topicDetails := s.buildTopicDetails(configurations)
-	for _, server := range s.props.Admin.BootstrapServers {
-		if err := s.createTopics(server, topicDetails); err != nil {
-			return errors.WithMessage(err, "create topics failed")
-		}
-	}
-	return nil
-}
-
-func (s SaramaAdmin) createTopics(server string, topicDetails map[string]*sarama.TopicDetail) error {
-	broker, err := s.connectBroker(server, s.config)
+	topicDetails, err := s.buildTopicDetails(configurations)
 	if err != nil {
-		return err
-	}
-	defer func() {
-		if err := broker.Close(); err != nil {
-			log.Errorf("Cannot close kafka admin connection on server [%s], err [%s]", server, err)
+		return errors.WithMessage(err, "create topics failed")
+	}
+	return s.withClusterAdmin(func(admin sarama.ClusterAdmin) error {
+		topicErrors := make(map[string]error)
+		for topic, detail := range topicDetails {
+			err := admin.CreateTopic(topic, detail, false)
+			if errors.Is(err, sarama.ErrTopicAlreadyExists) {
+				log.Infof("Kafka topic [%s] already exists", topic)
+				continue
+			}
+			if err != nil {
+				topicErrors[topic] = err
+				continue
+			}
+			log.Infof("Kafka topic [%s] has been created", topic)
 		}
-	}()
-	if _, err = broker.CreateTopics(&sarama.CreateTopicsRequest{
-		Timeout:      s.props.Admin.CreateTopicTimeout,
-		TopicDetails: topicDetails,
-	}); err != nil {
-		return errors.WithMessagef(err, "create topics failed on server [%s]", server)
-	}
-	log.Infof("All Kafka topics have been created on server [%s]", server)
-	return nil
+		return newAdminError("create topics failed", topicErrors)
+	})
 }
 
+// DeleteTopics deletes topics through the controller, missing topics are skipped.
 func (s SaramaAdmin) DeleteTopics(topics []string) error {
 	if len(topics) == 0 {
 		log.Infof("No topics are defined for deletion")
 		return nil
 	}
-	for _, server := range s.props.Admin.BootstrapServers {
-		if err := s.deleteTopics(server, topics); err != nil {
-			return errors.WithMessage(err, "delete topics failed")
+	return s.withClusterAdmin(func(admin sarama.ClusterAdmin) error {
+		topicErrors := make(map[string]error)
+		for _, topic := range topics {
+			err := admin.DeleteTopic(topic)
+			if errors.Is(err, sarama.ErrUnknownTopicOrPartition) {
+				log.Infof("Kafka topic [%s] does not exist", topic)
+				continue
+			}
+			if err != nil {
+				topicErrors[topic] = err
+				continue
+			}
+			log.Infof("Kafka topic [%s] has been deleted", topic)
 		}
-	}
-	return nil
-}
-
-func (s SaramaAdmin) deleteTopics(server string, topics []string) error {
-	broker, err := s.connectBroker(server, s.config)
-	if err != nil {
-		return err
-	}
-	defer func() {
-		if err := broker.Close(); err != nil {
-			log.Errorf("Cannot close kafka admin connection on server [%s], err [%s]", server, err)
-		}
-	}()
-	if _, err = broker.DeleteTopics(&sarama.DeleteTopicsRequest{Topics: topics}); err != nil {
-		return errors.WithMessagef(err, "delete topics failed on server [%s]", server)
-	}
-	log.Infof("Kafka topics [%v] have been deleted on server [%s]", topics, server)
-	return nil
+		return newAdminError("delete topics failed", topicErrors)
+	})
 }
 
+// DeleteGroups deletes groups through their coordinator, missing groups are skipped.
 func (s SaramaAdmin) DeleteGroups(groupIds []string) error {
 	if len(groupIds) == 0 {
 		log.Infof("No group ids are defined for deletion")
 		return nil
 	}
-	for _, server := range s.props.Admin.BootstrapServers {
-		if err := s.deleteGroups(server, groupIds); err != nil {
-			return errors.WithMessage(err, "delete groups failed")
+	return s.withClusterAdmin(func(admin sarama.ClusterAdmin) error {
+		groupErrors := make(map[string]error)
+		for _, groupId := range groupIds {
+			err := admin.DeleteConsumerGroup(groupId)
+			if errors.Is(err, sarama.ErrGroupIDNotFound) {
+				log.Infof("Kafka group [%s] does not exist", groupId)
+				continue
+			}
+			if err != nil {
+				groupErrors[groupId] = err
+				continue
+			}
+			log.Infof("Kafka group [%s] has been deleted", groupId)
 		}
-	}
-	return nil
-}
-
-func (s SaramaAdmin) deleteGroups(server string, groupIds []string) error {
-	broker, err := s.connectBroker(server, s.config)
-	if err != nil {
-		return err
-	}
-	defer func() {
-		if err := broker.Close(); err != nil {
-			log.Errorf("Cannot close kafka admin connection on server [%s], err [%s]", server, err)
-		}
-	}()
-	if _, err = broker.DeleteGroups(&sarama.DeleteGroupsRequest{Groups: groupIds}); err != nil {
-		return errors.WithMessagef(err, "delete groups failed on server [%s]", server)
-	}
-	log.Infof("Kafka groups [%v] have been deleted on server [%s]", groupIds, server)
-	return nil
+		return newAdminError("delete groups failed", groupErrors)
+	})
 }
 
+// CountPartitions counts partitions of the topic in the refreshed cluster metadata,
+// every broker of the cluster shares the same count.
 func (s SaramaAdmin) CountPartitions(topic string) (map[string]int32, error) {
 	partitions := make(map[string]int32)
-	for _, server := range s.props.Admin.BootstrapServers {
-		count, err := s.countPartition(server, topic)
+	err := s.withClient(func(client sarama.Client, _ sarama.ClusterAdmin) error {
+		if err := client.RefreshMetadata(topic); err != nil {
+			return errors.WithMessagef(err, "refresh metadata of topic [%s] failed", topic)
+		}
+		topicPartitions, err := client.Partitions(topic)
 		if err != nil {
-			return nil, errors.WithMessagef(err, "count partition failed on server [%s]", server)
+			return errors.WithMessagef(err, "list partitions of topic [%s] failed", topic)
+		}
+		for _, broker := range client.Brokers() {
+			partitions[broker.Addr()] = int32(len(topicPartitions))
 		}
-		partitions[server] = count
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 	return partitions, nil
 }
 
-func (s SaramaAdmin) countPartition(server string, topic string) (int32, error) {
-	broker, err := s.connectBroker(server, s.config)
+// withClusterAdmin opens a cluster admin for the operation, it's closed when the operation returns.
+func (s SaramaAdmin) withClusterAdmin(operation func(admin sarama.ClusterAdmin) error) error {
+	return s.withClient(func(_ sarama.Client, admin sarama.ClusterAdmin) error {
+		return operation(admin)
+	})
+}
+
+// withClient opens a client and a cluster admin on it for the operation,
+// they are closed when the operation returns.
+func (s SaramaAdmin) withClient(operation func(client sarama.Client, admin sarama.ClusterAdmin) error) error {
+	client, err := sarama.NewClient(s.props.Admin.BootstrapServers, s.config)
 	if err != nil {
-		return 0, err
+		return errors.WithMessage(err, "connect to kafka cluster failed")
 	}
-	defer func() {
-		if err := broker.Close(); err != nil {
-			log.Errorf("Cannot close kafka admin connection on server [%s], err [%s]", server, err)
-		}
-	}()
-
-	request := &sarama.MetadataRequest{Topics: []string{topic}}
-	response, err := broker.GetMetadata(request)
+	admin, err := sarama.NewClusterAdminFromClient(client)
 	if err != nil {
-		return 0, errors.WithMessage(err, "failed to get metadata")
+		_ = client.Close()
+		return errors.WithMessage(err, "connect to kafka cluster admin failed")
 	}
-
-	for _, topicMetadata := range response.Topics {
-		if topicMetadata.Name == topic {
-			return int32(len(topicMetadata.Partitions)), nil
+	defer func() {
+		// Closing the admin closes its client
+		if err := admin.Close(); err != nil {
+			log.Errorf("Cannot close kafka cluster admin, err [%s]", err)
 		}
-	}
-
-	return 0, fmt.Errorf("topic %s not found", topic)
-}
-
-func (s SaramaAdmin) connectBroker(server string, config *sarama.Config) (*sarama.Broker, error) {
-	broker := sarama.NewBroker(server)
-	if err := broker.Open(config); err != nil {
-		return nil, errors.WithMessagef(err, "connect to kafka admin broker [%s] failed", server)
-	}
-	return broker, nil
+	}()
+	return operation(client, admin)
 }
 
-func (s SaramaAdmin) buildTopicDetails(configurations []core.TopicConfiguration) map[string]*sarama.TopicDetail {
+// buildTopicDetails returns the topic details by topic name,
+// returns error when a topic config is invalid so that no request is sent.
+func (s SaramaAdmin) buildTopicDetails(configurations []core.TopicConfiguration) (map[string]*sarama.TopicDetail, error) {
 	topicDetails := make(map[string]*sarama.TopicDetail)
 	for _, configuration := range configurations {
-		configEntries := map[string]*string{}
-		if configuration.Retention > 0 {
-			retentionMs := fmt.Sprintf("%d", configuration.Retention.Milliseconds())
-			configEntries["retention.ms"] = &retentionMs
+		entries, err := configuration.ConfigEntries()
+		if err != nil {
+			return nil, err
+		}
+		configEntries := make(map[string]*string, len(entries))
+		for name, value := range entries {
+			value := value
+			configEntries[name] = &value
 		}
 		topicDetails[configuration.Name] = &sarama.TopicDetail{
-			NumPartitions:     configuration.Partitions,
-			ReplicationFactor: configuration.ReplicaFactor,
+			NumPartitions:     configuration.NumPartitions(),
+			ReplicationFactor: configuration.NumReplicas(),
 			ConfigEntries:     configEntries,
 		}
 		log.Infof("Init Kafka topic [%s] with config [%+v]", configuration.Name, configuration)
 	}
-	return topicDetails
+	return topicDetails, nil
+}
+
+// newAdminError aggregates errors of an operation by their resource name, it returns nil when there is no error.
+func newAdminError(message string, resourceErrors map[string]error) error {
+	if len(resourceErrors) == 0 {
+		return nil
+	}
+	resources := make([]string, 0, len(resourceErrors))
+	for resource := range resourceErrors {
+		resources = append(resources, resource)
+	}
+	sort.Strings(resources)
+	details := make([]string, 0, len(resources))
+	for _, resource := range resources {
+		details = append(details, fmt.Sprintf("[%s]: %s", resource, resourceErrors[resource]))
+	}
+	return fmt.Errorf("%s: %s", message, strings.Join(details, ", "))
 }
@@ -0,0 +1,173 @@
+package impl
+
+import (
+	"fmt"
+	"github.com/Shopify/sarama"
+	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"github.com/golibs-starter/golib/log"
+	"github.com/pkg/errors"
+	"strings"
+)
+
+func (s SaramaAdmin) CreateACLs(acls []core.Acl) error {
+	if len(acls) == 0 {
+		log.Infof("No ACLs are defined for creation")
+		return nil
+	}
+	resourceAcls := make(map[sarama.Resource]*sarama.ResourceAcls)
+	for _, acl := range acls {
+		resource, saramaAcl, err := toSaramaAcl(acl)
+		if err != nil {
+			return errors.WithMessagef(err, "invalid ACL [%+v]", acl)
+		}
+		if _, exists := resourceAcls[resource]; !exists {
+			resourceAcls[resource] = &sarama.ResourceAcls{Resource: resource}
+		}
+		resourceAcls[resource].Acls = append(resourceAcls[resource].Acls, saramaAcl)
+	}
+	request := make([]*sarama.ResourceAcls, 0, len(resourceAcls))
+	for _, acls := range resourceAcls {
+		request = append(request, acls)
+	}
+	return s.withClusterAdmin(func(admin sarama.ClusterAdmin) error {
+		if err := admin.CreateACLs(request); err != nil {
+			return errors.WithMessage(err, "create ACLs failed")
+		}
+		log.Infof("[%d] Kafka ACLs have been created", len(acls))
+		return nil
+	})
+}
+
+func (s SaramaAdmin) ListACLs(filter core.AclFilter) ([]core.Acl, error) {
+	saramaFilter, err := toSaramaAclFilter(filter)
+	if err != nil {
+		return nil, errors.WithMessage(err, "invalid ACL filter")
+	}
+	var acls []core.Acl
+	err = s.withClusterAdmin(func(admin sarama.ClusterAdmin) error {
+		resourceAcls, err := admin.ListAcls(saramaFilter)
+		if err != nil {
+			return errors.WithMessage(err, "list ACLs failed")
+		}
+		acls = make([]core.Acl, 0)
+		for _, resource := range resourceAcls {
+			for _, acl := range resource.Acls {
+				acls = append(acls, toCoreAcl(resource.Resource, *acl))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return acls, nil
+}
+
+func (s SaramaAdmin) DeleteACLs(filter core.AclFilter) ([]core.Acl, error) {
+	saramaFilter, err := toSaramaAclFilter(filter)
+	if err != nil {
+		return nil, errors.WithMessage(err, "invalid ACL filter")
+	}
+	var acls []core.Acl
+	err = s.withClusterAdmin(func(admin sarama.ClusterAdmin) error {
+		matchingAcls, err := admin.DeleteACL(saramaFilter, false)
+		if err != nil {
+			return errors.WithMessage(err, "delete ACLs failed")
+		}
+		acls = make([]core.Acl, 0, len(matchingAcls))
+		aclErrors := make(map[string]error)
+		for _, matchingAcl := range matchingAcls {
+			acl := toCoreAcl(matchingAcl.Resource, matchingAcl.Acl)
+			if !errors.Is(matchingAcl.Err, sarama.ErrNoError) {
+				aclErrors[fmt.Sprintf("%s:%s/%s/%s", acl.ResourceType, acl.ResourceName, acl.Principal, acl.Operation)] =
+					matchingAcl.Err
+				continue
+			}
+			acls = append(acls, acl)
+		}
+		log.Infof("[%d] Kafka ACLs have been deleted", len(acls))
+		return newAdminError("delete ACLs failed", aclErrors)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return acls, nil
+}
+
+func toSaramaAcl(acl core.Acl) (sarama.Resource, *sarama.Acl, error) {
+	resource := sarama.Resource{ResourceName: acl.ResourceName}
+	if err := parseAclEnum(&resource.ResourceType, acl.ResourceType, ""); err != nil {
+		return resource, nil, err
+	}
+	if err := parseAclEnum(&resource.ResourcePatternType, acl.PatternType, core.AclPatternLiteral); err != nil {
+		return resource, nil, err
+	}
+	saramaAcl := &sarama.Acl{Principal: acl.Principal, Host: acl.Host}
+	if saramaAcl.Host == "" {
+		saramaAcl.Host = "*"
+	}
+	if err := parseAclEnum(&saramaAcl.Operation, acl.Operation, ""); err != nil {
+		return resource, nil, err
+	}
+	if err := parseAclEnum(&saramaAcl.PermissionType, acl.Permission, core.AclPermissionAllow); err != nil {
+		return resource, nil, err
+	}
+	return resource, saramaAcl, nil
+}
+
+func toSaramaAclFilter(filter core.AclFilter) (sarama.AclFilter, error) {
+	saramaFilter := sarama.AclFilter{}
+	if err := parseAclEnum(&saramaFilter.ResourceType, filter.ResourceType, "any"); err != nil {
+		return saramaFilter, err
+	}
+	if err := parseAclEnum(&saramaFilter.ResourcePatternTypeFilter, filter.PatternType, "any"); err != nil {
+		return saramaFilter, err
+	}
+	if err := parseAclEnum(&saramaFilter.Operation, filter.Operation, "any"); err != nil {
+		return saramaFilter, err
+	}
+	if err := parseAclEnum(&saramaFilter.PermissionType, filter.Permission, "any"); err != nil {
+		return saramaFilter, err
+	}
+	if filter.ResourceName != "" {
+		saramaFilter.ResourceName = &filter.ResourceName
+	}
+	if filter.Principal != "" {
+		saramaFilter.Principal = &filter.Principal
+	}
+	if filter.Host != "" {
+		saramaFilter.Host = &filter.Host
+	}
+	return saramaFilter, nil
+}
+
+type aclEnum interface {
+	String() string
+	UnmarshalText(text []byte) error
+}
+
+// parseAclEnum parses the value or the default value when it's empty, an unknown value is an error
+func parseAclEnum(enum aclEnum, value string, defaultValue string) error {
+	if value == "" {
+		value = defaultValue
+	}
+	if err := enum.UnmarshalText([]byte(value)); err != nil {
+		return err
+	}
+	if enum.String() == "Unknown" {
+		return fmt.Errorf("unknown ACL value [%s]", value)
+	}
+	return nil
+}
+
+func toCoreAcl(resource sarama.Resource, acl sarama.Acl) core.Acl {
+	return core.Acl{
+		ResourceType: strings.ToLower(resource.ResourceType.String()),
+		ResourceName: resource.ResourceName,
+		PatternType:  strings.ToLower(resource.ResourcePatternType.String()),
+		Principal:    acl.Principal,
+		Host:         acl.Host,
+		Operation:    strings.ToLower(acl.Operation.String()),
+		Permission:   strings.ToLower(acl.PermissionType.String()),
+	}
+}
@@ -0,0 +1,189 @@
+package impl
+
+import (
+	"github.com/Shopify/sarama"
+	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"github.com/pkg/errors"
+	"sort"
+)
+
+func (s SaramaAdmin) ListTopics() ([]string, error) {
+	var topics []string
+	err := s.withClusterAdmin(func(admin sarama.ClusterAdmin) error {
+		details, err := admin.ListTopics()
+		if err != nil {
+			return errors.WithMessage(err, "list topics failed")
+		}
+		topics = make([]string, 0, len(details))
+		for topic := range details {
+			topics = append(topics, topic)
+		}
+		sort.Strings(topics)
+		return nil
+	})
+	return topics, err
+}
+
+func (s SaramaAdmin) DescribeTopics(topics []string) ([]core.TopicDescription, error) {
+	descriptions := make([]core.TopicDescription, 0, len(topics))
+	if len(topics) == 0 {
+		return descriptions, nil
+	}
+	err := s.withClusterAdmin(func(admin sarama.ClusterAdmin) error {
+		metadata, err := admin.DescribeTopics(topics)
+		if err != nil {
+			return errors.WithMessage(err, "describe topics failed")
+		}
+		topicErrors := make(map[string]error)
+		for _, topicMetadata := range metadata {
+			if !errors.Is(topicMetadata.Err, sarama.ErrNoError) {
+				topicErrors[topicMetadata.Name] = topicMetadata.Err
+				continue
+			}
+			entries, err := admin.DescribeConfig(sarama.ConfigResource{Type: sarama.TopicResource, Name: topicMetadata.Name})
+			if err != nil {
+				topicErrors[topicMetadata.Name] = errors.WithMessage(err, "describe configs failed")
+				continue
+			}
+			descriptions = append(descriptions, toTopicDescription(topicMetadata, entries))
+		}
+		return newAdminError("describe topics failed", topicErrors)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return descriptions, nil
+}
+
+func toTopicDescription(metadata *sarama.TopicMetadata, entries []sarama.ConfigEntry) core.TopicDescription {
+	description := core.TopicDescription{
+		Name:       metadata.Name,
+		Internal:   metadata.IsInternal,
+		Partitions: make([]core.PartitionDescription, 0, len(metadata.Partitions)),
+		Configs:    make(map[string]string, len(entries)),
+	}
+	for _, partition := range metadata.Partitions {
+		description.Partitions = append(description.Partitions, core.PartitionDescription{
+			Id:              partition.ID,
+			Leader:          partition.Leader,
+			Replicas:        partition.Replicas,
+			Isr:             partition.Isr,
+			OfflineReplicas: partition.OfflineReplicas,
+		})
+	}
+	sort.Slice(description.Partitions, func(i, j int) bool {
+		return description.Partitions[i].Id < description.Partitions[j].Id
+	})
+	for _, entry := range entries {
+		if !entry.Sensitive {
+			description.Configs[entry.Name] = entry.Value
+		}
+	}
+	return description
+}
+
+func (s SaramaAdmin) ListConsumerGroups() ([]string, error) {
+	var groupIds []string
+	err := s.withClusterAdmin(func(admin sarama.ClusterAdmin) error {
+		groups, err := admin.ListConsumerGroups()
+		if err != nil {
+			return errors.WithMessage(err, "list consumer groups failed")
+		}
+		groupIds = make([]string, 0, len(groups))
+		for groupId := range groups {
+			groupIds = append(groupIds, groupId)
+		}
+		sort.Strings(groupIds)
+		return nil
+	})
+	return groupIds, err
+}
+
+func (s SaramaAdmin) DescribeConsumerGroups(groupIds []string) ([]core.ConsumerGroupDescription, error) {
+	descriptions := make([]core.ConsumerGroupDescription, 0, len(groupIds))
+	if len(groupIds) == 0 {
+		return descriptions, nil
+	}
+	err := s.withClusterAdmin(func(admin sarama.ClusterAdmin) error {
+		groups, err := admin.DescribeConsumerGroups(groupIds)
+		if err != nil {
+			return errors.WithMessage(err, "describe consumer groups failed")
+		}
+		groupErrors := make(map[string]error)
+		for _, group := range groups {
+			if !errors.Is(group.Err, sarama.ErrNoError) {
+				groupErrors[group.GroupId] = group.Err
+				continue
+			}
+			description, err := toConsumerGroupDescription(group)
+			if err != nil {
+				groupErrors[group.GroupId] = err
+				continue
+			}
+			descriptions = append(descriptions, description)
+		}
+		return newAdminError("describe consumer groups failed", groupErrors)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return descriptions, nil
+}
+
+func toConsumerGroupDescription(group *sarama.GroupDescription) (core.ConsumerGroupDescription, error) {
+	description := core.ConsumerGroupDescription{
+		GroupId:      group.GroupId,
+		State:        group.State,
+		ProtocolType: group.ProtocolType,
+		Protocol:     group.Protocol,
+		Members:      make([]core.ConsumerGroupMember, 0, len(group.Members)),
+	}
+	for memberId, member := range group.Members {
+		groupMember := core.ConsumerGroupMember{
+			MemberId:    memberId,
+			ClientId:    member.ClientId,
+			ClientHost:  member.ClientHost,
+			Assignments: make(map[string][]int32),
+		}
+		if len(member.MemberAssignment) > 0 {
+			assignment, err := member.GetMemberAssignment()
+			if err != nil {
+				return description, errors.WithMessagef(err, "decode assignment of member [%s] failed", memberId)
+			}
+			for topic, partitions := range assignment.Topics {
+				groupMember.Assignments[topic] = partitions
+			}
+		}
+		description.Members = append(description.Members, groupMember)
+	}
+	sort.Slice(description.Members, func(i, j int) bool {
+		return description.Members[i].MemberId < description.Members[j].MemberId
+	})
+	return description, nil
+}
+
+func (s SaramaAdmin) DescribeCluster() (*core.ClusterDescription, error) {
+	var description *core.ClusterDescription
+	err := s.withClusterAdmin(func(admin sarama.ClusterAdmin) error {
+		brokers, controllerId, err := admin.DescribeCluster()
+		if err != nil {
+			return errors.WithMessage(err, "describe cluster failed")
+		}
+		description = &core.ClusterDescription{
+			Brokers:      make([]core.BrokerDescription, 0, len(brokers)),
+			ControllerId: controllerId,
+		}
+		for _, broker := range brokers {
+			description.Brokers = append(description.Brokers, core.BrokerDescription{
+				Id:   broker.ID(),
+				Addr: broker.Addr(),
+				Rack: broker.Rack(),
+			})
+		}
+		sort.Slice(description.Brokers, func(i, j int) bool {
+			return description.Brokers[i].Id < description.Brokers[j].Id
+		})
+		return nil
+	})
+	return description, err
+}
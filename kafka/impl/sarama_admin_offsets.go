@@ -0,0 +1,254 @@
+package impl
+
+import (
+	"fmt"
+	"github.com/Shopify/sarama"
+	"github.com/golibs-starter/golib-message-bus/kafka/constant"
+	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"github.com/golibs-starter/golib/log"
+	"github.com/pkg/errors"
+	"sort"
+)
+
+func (s SaramaAdmin) ListConsumerGroupOffsets(groupId string, topics []string) (*core.ConsumerGroupOffsets, error) {
+	var offsets *core.ConsumerGroupOffsets
+	err := s.withClient(func(client sarama.Client, admin sarama.ClusterAdmin) error {
+		var err error
+		offsets, err = s.listConsumerGroupOffsets(client, admin, groupId, topics)
+		return err
+	})
+	return offsets, err
+}
+
+func (s SaramaAdmin) listConsumerGroupOffsets(client sarama.Client, admin sarama.ClusterAdmin, groupId string,
+	topics []string) (*core.ConsumerGroupOffsets, error) {
+	var topicPartitions map[string][]int32
+	if len(topics) > 0 {
+		topicPartitions = make(map[string][]int32, len(topics))
+		for _, topic := range topics {
+			partitions, err := client.Partitions(topic)
+			if err != nil {
+				return nil, errors.WithMessagef(err, "list partitions of topic [%s] failed", topic)
+			}
+			topicPartitions[topic] = partitions
+		}
+	}
+	response, err := admin.ListConsumerGroupOffsets(groupId, topicPartitions)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "list offsets of consumer group [%s] failed", groupId)
+	}
+	if !errors.Is(response.Err, sarama.ErrNoError) {
+		return nil, errors.WithMessagef(response.Err, "list offsets of consumer group [%s] failed", groupId)
+	}
+	groupOffsets := &core.ConsumerGroupOffsets{GroupId: groupId, Offsets: make([]core.PartitionOffset, 0)}
+	partitionErrors := make(map[string]error)
+	for topic, blocks := range response.Blocks {
+		for partition, block := range blocks {
+			if !errors.Is(block.Err, sarama.ErrNoError) {
+				partitionErrors[partitionName(topic, partition)] = block.Err
+				continue
+			}
+			endOffset, err := client.GetOffset(topic, partition, sarama.OffsetNewest)
+			if err != nil {
+				partitionErrors[partitionName(topic, partition)] = errors.WithMessage(err, "get end offset failed")
+				continue
+			}
+			offset := core.PartitionOffset{
+				Topic:     topic,
+				Partition: partition,
+				Offset:    block.Offset,
+				Metadata:  block.Metadata,
+				EndOffset: endOffset,
+			}
+			if block.Offset >= 0 && endOffset > block.Offset {
+				offset.Lag = endOffset - block.Offset
+			}
+			groupOffsets.Offsets = append(groupOffsets.Offsets, offset)
+			groupOffsets.Lag += offset.Lag
+		}
+	}
+	sort.Slice(groupOffsets.Offsets, func(i, j int) bool {
+		if groupOffsets.Offsets[i].Topic != groupOffsets.Offsets[j].Topic {
+			return groupOffsets.Offsets[i].Topic < groupOffsets.Offsets[j].Topic
+		}
+		return groupOffsets.Offsets[i].Partition < groupOffsets.Offsets[j].Partition
+	})
+	if err := newAdminError("list consumer group offsets failed", partitionErrors); err != nil {
+		return nil, err
+	}
+	return groupOffsets, nil
+}
+
+func (s SaramaAdmin) ResetConsumerGroupOffsets(groupId string, topics []string,
+	reset core.OffsetReset) (*core.ConsumerGroupOffsets, error) {
+	if len(topics) == 0 {
+		return nil, errors.New("topics are required to reset offsets")
+	}
+	if err := validateOffsetReset(reset); err != nil {
+		return nil, err
+	}
+	var offsets *core.ConsumerGroupOffsets
+	err := s.withClient(func(client sarama.Client, admin sarama.ClusterAdmin) error {
+		if !reset.Force {
+			if err := s.ensureInactiveGroup(admin, groupId); err != nil {
+				return err
+			}
+		}
+		current, err := s.listConsumerGroupOffsets(client, admin, groupId, topics)
+		if err != nil {
+			return err
+		}
+		request := &sarama.OffsetCommitRequest{
+			Version:                 2,
+			ConsumerGroup:           groupId,
+			ConsumerGroupGeneration: sarama.GroupGenerationUndefined,
+			RetentionTime:           -1,
+		}
+		partitionErrors := make(map[string]error)
+		for _, offset := range current.Offsets {
+			target, err := s.resetOffset(client, offset, reset)
+			if err != nil {
+				partitionErrors[partitionName(offset.Topic, offset.Partition)] = err
+				continue
+			}
+			request.AddBlock(offset.Topic, offset.Partition, target, 0, 0, offset.Metadata)
+			log.Infof("Reset offset of consumer group [%s] at topic [%s], partition [%d] from [%d] to [%d]",
+				groupId, offset.Topic, offset.Partition, offset.Offset, target)
+		}
+		if err := newAdminError("reset consumer group offsets failed", partitionErrors); err != nil {
+			return err
+		}
+		if err := s.commitOffsets(client, groupId, request); err != nil {
+			return err
+		}
+		offsets, err = s.listConsumerGroupOffsets(client, admin, groupId, topics)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return offsets, nil
+}
+
+func validateOffsetReset(reset core.OffsetReset) error {
+	switch reset.Type {
+	case constant.SeekEarliest, constant.SeekLatest, constant.OffsetResetShiftBy:
+		return nil
+	case constant.SeekOffset:
+		if reset.Offset < 0 {
+			return fmt.Errorf("reset offset must not be negative, got [%d]", reset.Offset)
+		}
+		return nil
+	case constant.SeekTimestamp:
+		if reset.Timestamp.IsZero() {
+			return errors.New("reset timestamp is required")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported offset reset [%s]", reset.Type)
+	}
+}
+
+func (s SaramaAdmin) ensureInactiveGroup(admin sarama.ClusterAdmin, groupId string) error {
+	groups, err := admin.DescribeConsumerGroups([]string{groupId})
+	if err != nil {
+		return errors.WithMessagef(err, "describe consumer group [%s] failed", groupId)
+	}
+	for _, group := range groups {
+		if len(group.Members) > 0 {
+			return fmt.Errorf("consumer group [%s] has [%d] active members, stop them or force the reset",
+				groupId, len(group.Members))
+		}
+	}
+	return nil
+}
+
+// resetOffset returns the offset to reset the partition to, it's kept between the earliest and the end offset.
+func (s SaramaAdmin) resetOffset(client sarama.Client, offset core.PartitionOffset, reset core.OffsetReset) (int64, error) {
+	earliest, err := client.GetOffset(offset.Topic, offset.Partition, sarama.OffsetOldest)
+	if err != nil {
+		return 0, errors.WithMessage(err, "get earliest offset failed")
+	}
+	var target int64
+	switch reset.Type {
+	case constant.SeekEarliest:
+		return earliest, nil
+	case constant.SeekLatest:
+		return offset.EndOffset, nil
+	case constant.SeekTimestamp:
+		target, err = client.GetOffset(offset.Topic, offset.Partition, reset.Timestamp.UnixMilli())
+		if err != nil {
+			return 0, errors.WithMessage(err, "get offset for timestamp failed")
+		}
+		if target < 0 {
+			// No message is produced after the timestamp
+			return offset.EndOffset, nil
+		}
+	case constant.SeekOffset:
+		target = reset.Offset
+	case constant.OffsetResetShiftBy:
+		if offset.Offset < 0 {
+			return 0, errors.New("no committed offset to shift")
+		}
+		target = offset.Offset + reset.Offset
+	}
+	if target < earliest {
+		return earliest, nil
+	}
+	if target > offset.EndOffset {
+		return offset.EndOffset, nil
+	}
+	return target, nil
+}
+
+// commitOffsets commits offsets through the group coordinator as a client outside the group
+func (s SaramaAdmin) commitOffsets(client sarama.Client, groupId string, request *sarama.OffsetCommitRequest) error {
+	coordinator, err := client.Coordinator(groupId)
+	if err != nil {
+		return errors.WithMessagef(err, "find coordinator of consumer group [%s] failed", groupId)
+	}
+	response, err := coordinator.CommitOffset(request)
+	if err != nil {
+		return errors.WithMessagef(err, "commit offsets of consumer group [%s] failed", groupId)
+	}
+	partitionErrors := make(map[string]error)
+	for topic, partitions := range response.Errors {
+		for partition, err := range partitions {
+			if !errors.Is(err, sarama.ErrNoError) {
+				partitionErrors[partitionName(topic, partition)] = err
+			}
+		}
+	}
+	return newAdminError("commit offsets failed", partitionErrors)
+}
+
+func (s SaramaAdmin) DeleteConsumerGroupOffsets(groupId string, partitions map[string][]int32) error {
+	if len(partitions) == 0 {
+		log.Infof("No partitions are defined for offset deletion")
+		return nil
+	}
+	return s.withClusterAdmin(func(admin sarama.ClusterAdmin) error {
+		partitionErrors := make(map[string]error)
+		for topic, topicPartitions := range partitions {
+			for _, partition := range topicPartitions {
+				err := admin.DeleteConsumerGroupOffset(groupId, topic, partition)
+				if errors.Is(err, sarama.ErrGroupIDNotFound) {
+					log.Infof("Kafka group [%s] does not exist", groupId)
+					return nil
+				}
+				if err != nil {
+					partitionErrors[partitionName(topic, partition)] = err
+				}
+			}
+		}
+		if err := newAdminError("delete consumer group offsets failed", partitionErrors); err != nil {
+			return err
+		}
+		log.Infof("Offsets of Kafka group [%s] have been deleted", groupId)
+		return nil
+	})
+}
+
+func partitionName(topic string, partition int32) string {
+	return fmt.Sprintf("%s/%d", topic, partition)
+}
@@ -0,0 +1,108 @@
+package impl
+
+import (
+	"fmt"
+	"github.com/Shopify/sarama"
+	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"github.com/golibs-starter/golib/log"
+	"github.com/pkg/errors"
+	"sort"
+)
+
+// CreatePartitions increases partitions of the topic, it's a no-op when the topic already has newTotal partitions.
+// A topic having records is only expanded when it's forced.
+func (s SaramaAdmin) CreatePartitions(topic string, newTotal int32, force bool) error {
+	return s.withClient(func(client sarama.Client, admin sarama.ClusterAdmin) error {
+		partitions, err := client.Partitions(topic)
+		if err != nil {
+			return errors.WithMessagef(err, "list partitions of topic [%s] failed", topic)
+		}
+		current := int32(len(partitions))
+		if current == newTotal {
+			log.Infof("Kafka topic [%s] already has [%d] partitions", topic, newTotal)
+			return nil
+		}
+		if newTotal < current {
+			return fmt.Errorf("partitions of topic [%s] cannot be reduced from [%d] to [%d]", topic, current, newTotal)
+		}
+		hasRecords, err := s.hasRecords(client, topic, partitions)
+		if err != nil {
+			return err
+		}
+		if hasRecords && !force {
+			return fmt.Errorf("topic [%s] has records, increasing its partitions from [%d] to [%d] breaks "+
+				"the key-to-partition affinity of keyed messages, force it to increase them anyway", topic, current, newTotal)
+		}
+		if hasRecords {
+			log.Warnf("Increasing partitions of topic [%s] having records from [%d] to [%d] breaks the key-to-partition "+
+				"affinity, keyed messages with the same key may be consumed out of order", topic, current, newTotal)
+		}
+		if err := admin.CreatePartitions(topic, newTotal, nil, false); err != nil {
+			return errors.WithMessagef(err, "create partitions of topic [%s] failed", topic)
+		}
+		log.Infof("Partitions of Kafka topic [%s] have been increased from [%d] to [%d]", topic, current, newTotal)
+		return nil
+	})
+}
+
+// hasRecords returns true when a partition of the topic has records
+func (s SaramaAdmin) hasRecords(client sarama.Client, topic string, partitions []int32) (bool, error) {
+	for _, partition := range partitions {
+		oldest, err := client.GetOffset(topic, partition, sarama.OffsetOldest)
+		if err != nil {
+			return false, errors.WithMessagef(err, "get oldest offset of topic [%s], partition [%d] failed", topic, partition)
+		}
+		newest, err := client.GetOffset(topic, partition, sarama.OffsetNewest)
+		if err != nil {
+			return false, errors.WithMessagef(err, "get newest offset of topic [%s], partition [%d] failed", topic, partition)
+		}
+		if newest > oldest {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s SaramaAdmin) AlterPartitionReassignments(topic string, assignment [][]int32) error {
+	if len(assignment) == 0 {
+		log.Infof("No partitions are defined for reassignment")
+		return nil
+	}
+	return s.withClusterAdmin(func(admin sarama.ClusterAdmin) error {
+		if err := admin.AlterPartitionReassignments(topic, assignment); err != nil {
+			return errors.WithMessagef(err, "alter partition reassignments of topic [%s] failed", topic)
+		}
+		log.Infof("Partition reassignments of Kafka topic [%s] have been submitted", topic)
+		return nil
+	})
+}
+
+func (s SaramaAdmin) ListPartitionReassignments(topic string, partitions []int32) ([]core.PartitionReassignment, error) {
+	var reassignments []core.PartitionReassignment
+	err := s.withClusterAdmin(func(admin sarama.ClusterAdmin) error {
+		statuses, err := admin.ListPartitionReassignments(topic, partitions)
+		if err != nil {
+			return errors.WithMessagef(err, "list partition reassignments of topic [%s] failed", topic)
+		}
+		reassignments = make([]core.PartitionReassignment, 0)
+		for reassignedTopic, topicStatuses := range statuses {
+			for partition, status := range topicStatuses {
+				reassignments = append(reassignments, core.PartitionReassignment{
+					Topic:            reassignedTopic,
+					Partition:        partition,
+					Replicas:         status.Replicas,
+					AddingReplicas:   status.AddingReplicas,
+					RemovingReplicas: status.RemovingReplicas,
+				})
+			}
+		}
+		sort.Slice(reassignments, func(i, j int) bool {
+			return reassignments[i].Partition < reassignments[j].Partition
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reassignments, nil
+}
@@ -0,0 +1,97 @@
+package impl
+
+import (
+	"fmt"
+	"github.com/Shopify/sarama"
+	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"github.com/golibs-starter/golib/log"
+	"github.com/pkg/errors"
+	"time"
+)
+
+func (s SaramaAdmin) DeleteRecords(topic string, beforeOffsets map[int32]int64) error {
+	if len(beforeOffsets) == 0 {
+		log.Infof("No partitions are defined for records deletion")
+		return nil
+	}
+	return s.withClusterAdmin(func(admin sarama.ClusterAdmin) error {
+		if err := admin.DeleteRecords(topic, beforeOffsets); err != nil {
+			return errors.WithMessagef(err, "delete records of topic [%s] failed", topic)
+		}
+		log.Infof("Records of Kafka topic [%s] before offsets [%v] have been deleted", topic, beforeOffsets)
+		return nil
+	})
+}
+
+func (s SaramaAdmin) OffsetsForTimestamp(topic string, timestamp time.Time) (map[int32]int64, error) {
+	var offsets map[int32]int64
+	err := s.withClient(func(client sarama.Client, _ sarama.ClusterAdmin) error {
+		partitions, err := client.Partitions(topic)
+		if err != nil {
+			return errors.WithMessagef(err, "list partitions of topic [%s] failed", topic)
+		}
+		offsets = make(map[int32]int64, len(partitions))
+		partitionErrors := make(map[string]error)
+		for _, partition := range partitions {
+			offset, err := client.GetOffset(topic, partition, timestamp.UnixMilli())
+			if err == nil && offset < 0 {
+				// No record is produced after the timestamp
+				offset, err = client.GetOffset(topic, partition, sarama.OffsetNewest)
+			}
+			if err != nil {
+				partitionErrors[partitionName(topic, partition)] = err
+				continue
+			}
+			offsets[partition] = offset
+		}
+		return newAdminError("find offsets for timestamp failed", partitionErrors)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return offsets, nil
+}
+
+func (s SaramaAdmin) DescribeTopicConfig(topic string) (map[string]string, error) {
+	var configs map[string]string
+	err := s.withClusterAdmin(func(admin sarama.ClusterAdmin) error {
+		entries, err := admin.DescribeConfig(sarama.ConfigResource{Type: sarama.TopicResource, Name: topic})
+		if err != nil {
+			return errors.WithMessagef(err, "describe configs of topic [%s] failed", topic)
+		}
+		configs = make(map[string]string, len(entries))
+		for _, entry := range entries {
+			if !entry.Sensitive {
+				configs[entry.Name] = entry.Value
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return configs, nil
+}
+
+func (s SaramaAdmin) AlterTopicConfig(topic string, configs map[string]string) error {
+	if len(configs) == 0 {
+		log.Infof("No configs are defined for topic [%s]", topic)
+		return nil
+	}
+	configErrors := make(map[string]error)
+	for name := range configs {
+		if !core.IsKnownTopicConfig(name) {
+			configErrors[name] = errors.New("unknown topic config")
+		}
+	}
+	if err := newAdminError(fmt.Sprintf("alter configs of topic [%s] failed", topic), configErrors); err != nil {
+		return err
+	}
+	return s.withClusterAdmin(func(admin sarama.ClusterAdmin) error {
+		if err := s.alterTopicConfigs(admin, topic, configs); err != nil {
+			return errors.WithMessagef(err, "alter configs of topic [%s] failed", topic)
+		}
+		log.Infof("Configs of Kafka topic [%s] have been altered", topic)
+		return nil
+	})
+}
@@ -0,0 +1,290 @@
+package impl
+
+import (
+	"github.com/Shopify/sarama"
+	"github.com/golibs-starter/golib-message-bus/kafka/constant"
+	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"github.com/golibs-starter/golib-message-bus/kafka/properties"
+	assert "github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// newTestSaramaAdmin creates an admin of a single broker cluster with topics having the number of partitions
+func newTestSaramaAdmin(t *testing.T, handlers map[string]sarama.MockResponse, topics map[string]int32) core.Admin {
+	return newTestSaramaAdminOnBroker(t, sarama.NewMockBroker(t, 1), handlers, topics)
+}
+
+func newTestSaramaAdminOnBroker(t *testing.T, broker *sarama.MockBroker, handlers map[string]sarama.MockResponse,
+	topics map[string]int32) core.Admin {
+	t.Cleanup(broker.Close)
+	metadata := sarama.NewMockMetadataResponse(t).
+		SetController(broker.BrokerID()).
+		SetBroker(broker.Addr(), broker.BrokerID())
+	for topic, partitions := range topics {
+		for partition := int32(0); partition < partitions; partition++ {
+			metadata.SetLeader(topic, partition, broker.BrokerID())
+		}
+	}
+	handlers["MetadataRequest"] = metadata
+	broker.SetHandlerByMap(handlers)
+	admin, err := NewSaramaAdmin(&properties.Client{
+		Version: "2.1.0",
+		Admin:   properties.Admin{BootstrapServers: []string{broker.Addr()}},
+	})
+	assert.NoError(t, err)
+	return admin
+}
+
+func TestSaramaAdmin_WhenTopicAlreadyExists_ShouldCreateOtherTopicsAndAggregateErrors(t *testing.T) {
+	admin := newTestSaramaAdmin(t, map[string]sarama.MockResponse{
+		"CreateTopicsRequest": sarama.NewMockWrapper(&sarama.CreateTopicsResponse{
+			Version: 2,
+			TopicErrors: map[string]*sarama.TopicError{
+				"existing": {Err: sarama.ErrTopicAlreadyExists},
+				"created":  {Err: sarama.ErrNoError},
+				"invalid":  {Err: sarama.ErrInvalidReplicationFactor},
+			},
+		}),
+	}, nil)
+	err := admin.CreateTopics([]core.TopicConfiguration{
+		{Name: "existing", Partitions: 1, ReplicaFactor: 1},
+		{Name: "created", Partitions: 1, ReplicaFactor: 1},
+	})
+	assert.NoError(t, err)
+
+	err = admin.CreateTopics([]core.TopicConfiguration{
+		{Name: "created", Partitions: 1, ReplicaFactor: 1},
+		{Name: "invalid", Partitions: 1, ReplicaFactor: 3},
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "[invalid]")
+	assert.NotContains(t, err.Error(), "[created]")
+}
+
+func TestSaramaAdmin_WhenTopicIsNotFound_ShouldDeleteTopicsIdempotently(t *testing.T) {
+	admin := newTestSaramaAdmin(t, map[string]sarama.MockResponse{
+		"DeleteTopicsRequest": sarama.NewMockWrapper(&sarama.DeleteTopicsResponse{
+			Version: 1,
+			TopicErrorCodes: map[string]sarama.KError{
+				"missing": sarama.ErrUnknownTopicOrPartition,
+				"deleted": sarama.ErrNoError,
+			},
+		}),
+	}, nil)
+	assert.NoError(t, admin.DeleteTopics([]string{"missing", "deleted"}))
+}
+
+func TestSaramaAdmin_WhenCountPartitions_ShouldReturnCountByBroker(t *testing.T) {
+	broker := sarama.NewMockBroker(t, 1)
+	admin := newTestSaramaAdminOnBroker(t, broker, map[string]sarama.MockResponse{}, map[string]int32{"orders": 3})
+	partitions, err := admin.CountPartitions("orders")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int32{broker.Addr(): 3}, partitions)
+
+	_, err = admin.CountPartitions("missing")
+	assert.ErrorIs(t, err, sarama.ErrUnknownTopicOrPartition)
+}
+
+func TestSaramaAdmin_WhenTopicsDrift_ShouldPlanChanges(t *testing.T) {
+	admin := newTestSaramaAdmin(t, map[string]sarama.MockResponse{
+		"DescribeConfigsRequest": sarama.NewMockDescribeConfigsResponse(t),
+	}, map[string]int32{"orders": 1, "events": 2})
+	plan, err := admin.PlanTopics([]core.TopicConfiguration{
+		{Name: "orders", Partitions: 3, ReplicaFactor: 1, Retention: time.Hour},
+		{Name: "payments", Partitions: 1, ReplicaFactor: 1},
+		{Name: "events", Partitions: 1, ReplicaFactor: 3},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, plan.Create, 1)
+	assert.Equal(t, "payments", plan.Create[0].Name)
+	assert.Equal(t, []core.TopicPartitionsChange{{Topic: "orders", From: 1, To: 3}}, plan.IncreasePartitions)
+	assert.Equal(t, []core.TopicConfigsChange{{Topic: "orders", Configs: map[string]core.TopicConfigChange{
+		"retention.ms": {From: "5000", To: "3600000"},
+	}}}, plan.AlterConfigs)
+	assert.Len(t, plan.Irreconcilable, 2)
+}
+
+func TestSaramaAdmin_WhenPartitionsAreNotSet_ShouldNotCompareThem(t *testing.T) {
+	admin := newTestSaramaAdmin(t, map[string]sarama.MockResponse{}, map[string]int32{"orders": 3})
+	plan, err := admin.PlanTopics([]core.TopicConfiguration{{Name: "orders"}, {Name: "payments"}})
+	assert.NoError(t, err)
+	assert.Empty(t, plan.Irreconcilable)
+	assert.Empty(t, plan.IncreasePartitions)
+	assert.Len(t, plan.Create, 1)
+	assert.Contains(t, plan.String(), "create topic [payments] with [1] partitions, replication factor [1]")
+}
+
+func TestSaramaAdmin_WhenDescribeTopics_ShouldReturnPartitionsAndConfigs(t *testing.T) {
+	admin := newTestSaramaAdmin(t, map[string]sarama.MockResponse{
+		"DescribeConfigsRequest": sarama.NewMockDescribeConfigsResponse(t),
+	}, map[string]int32{"orders": 2})
+	descriptions, err := admin.DescribeTopics([]string{"orders"})
+	assert.NoError(t, err)
+	assert.Len(t, descriptions, 1)
+	assert.Equal(t, "orders", descriptions[0].Name)
+	assert.Len(t, descriptions[0].Partitions, 2)
+	assert.Equal(t, int32(1), descriptions[0].Partitions[1].Id)
+	assert.Equal(t, []int32{1}, descriptions[0].Partitions[1].Replicas)
+	assert.Equal(t, "5000", descriptions[0].Configs["retention.ms"])
+
+	cluster, err := admin.DescribeCluster()
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), cluster.ControllerId)
+	assert.Len(t, cluster.Brokers, 1)
+}
+
+func TestSaramaAdmin_WhenListConsumerGroupOffsets_ShouldComputeLag(t *testing.T) {
+	broker := sarama.NewMockBroker(t, 1)
+	admin := newTestSaramaAdminOnBroker(t, broker, map[string]sarama.MockResponse{
+		"FindCoordinatorRequest": sarama.NewMockFindCoordinatorResponse(t).
+			SetCoordinator(sarama.CoordinatorGroup, "group", broker),
+		"OffsetFetchRequest": sarama.NewMockOffsetFetchResponse(t).
+			SetOffset("group", "orders", 0, 40, "", sarama.ErrNoError).
+			SetOffset("group", "orders", 1, -1, "", sarama.ErrNoError),
+		"OffsetRequest": sarama.NewMockOffsetResponse(t).
+			SetOffset("orders", 0, sarama.OffsetNewest, 100).
+			SetOffset("orders", 1, sarama.OffsetNewest, 10),
+	}, map[string]int32{"orders": 2})
+	offsets, err := admin.ListConsumerGroupOffsets("group", []string{"orders"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(60), offsets.Lag)
+	assert.Equal(t, []core.PartitionOffset{
+		{Topic: "orders", Partition: 0, Offset: 40, EndOffset: 100, Lag: 60},
+		{Topic: "orders", Partition: 1, Offset: -1, EndOffset: 10},
+	}, offsets.Offsets)
+}
+
+func TestSaramaAdmin_WhenGroupHasActiveMembers_ShouldRefuseToResetOffsets(t *testing.T) {
+	broker := sarama.NewMockBroker(t, 1)
+	admin := newTestSaramaAdminOnBroker(t, broker, map[string]sarama.MockResponse{
+		"FindCoordinatorRequest": sarama.NewMockFindCoordinatorResponse(t).
+			SetCoordinator(sarama.CoordinatorGroup, "group", broker),
+		"DescribeGroupsRequest": sarama.NewMockDescribeGroupsResponse(t).
+			AddGroupDescription("group", &sarama.GroupDescription{
+				GroupId: "group",
+				State:   "Stable",
+				Members: map[string]*sarama.GroupMemberDescription{"member-1": {ClientId: "client-1"}},
+			}),
+	}, map[string]int32{"orders": 1})
+	_, err := admin.ResetConsumerGroupOffsets("group", []string{"orders"},
+		core.OffsetReset{Type: constant.SeekEarliest})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "active members")
+}
+
+func TestSaramaAdmin_WhenGroupIsInactive_ShouldResetOffsets(t *testing.T) {
+	broker := sarama.NewMockBroker(t, 1)
+	admin := newTestSaramaAdminOnBroker(t, broker, map[string]sarama.MockResponse{
+		"FindCoordinatorRequest": sarama.NewMockFindCoordinatorResponse(t).
+			SetCoordinator(sarama.CoordinatorGroup, "group", broker),
+		"DescribeGroupsRequest": sarama.NewMockDescribeGroupsResponse(t),
+		"OffsetFetchRequest": sarama.NewMockOffsetFetchResponse(t).
+			SetOffset("group", "orders", 0, 40, "", sarama.ErrNoError),
+		"OffsetRequest": sarama.NewMockOffsetResponse(t).
+			SetOffset("orders", 0, sarama.OffsetOldest, 0).
+			SetOffset("orders", 0, sarama.OffsetNewest, 100),
+		"OffsetCommitRequest": sarama.NewMockOffsetCommitResponse(t),
+	}, map[string]int32{"orders": 1})
+	_, err := admin.ResetConsumerGroupOffsets("group", []string{"orders"},
+		core.OffsetReset{Type: constant.OffsetResetShiftBy, Offset: -50})
+	assert.NoError(t, err)
+}
+
+func TestSaramaAdmin_WhenCreatePartitions_ShouldOnlyIncreasePartitions(t *testing.T) {
+	admin := newTestSaramaAdmin(t, map[string]sarama.MockResponse{
+		"CreatePartitionsRequest": sarama.NewMockCreatePartitionsResponse(t),
+		"OffsetRequest": sarama.NewMockOffsetResponse(t).
+			SetOffset("orders", 0, sarama.OffsetOldest, 0).
+			SetOffset("orders", 0, sarama.OffsetNewest, 0).
+			SetOffset("orders", 1, sarama.OffsetOldest, 0).
+			SetOffset("orders", 1, sarama.OffsetNewest, 0),
+	}, map[string]int32{"orders": 2})
+	assert.NoError(t, admin.CreatePartitions("orders", 2, false))
+	assert.NoError(t, admin.CreatePartitions("orders", 4, false))
+	assert.Error(t, admin.CreatePartitions("orders", 1, true))
+}
+
+func TestSaramaAdmin_WhenTopicHasRecords_ShouldOnlyIncreasePartitionsWhenForced(t *testing.T) {
+	admin := newTestSaramaAdmin(t, map[string]sarama.MockResponse{
+		"CreatePartitionsRequest": sarama.NewMockCreatePartitionsResponse(t),
+		"OffsetRequest": sarama.NewMockOffsetResponse(t).
+			SetOffset("orders", 0, sarama.OffsetOldest, 0).
+			SetOffset("orders", 0, sarama.OffsetNewest, 10),
+	}, map[string]int32{"orders": 1})
+	assert.ErrorContains(t, admin.CreatePartitions("orders", 2, false), "key-to-partition affinity")
+	assert.NoError(t, admin.CreatePartitions("orders", 2, true))
+}
+
+func TestSaramaAdmin_WhenPlanIncreasesPartitionsOfTopicHavingRecords_ShouldOnlyApplyItWhenForced(t *testing.T) {
+	admin := newTestSaramaAdmin(t, map[string]sarama.MockResponse{
+		"CreatePartitionsRequest": sarama.NewMockCreatePartitionsResponse(t),
+		"OffsetRequest": sarama.NewMockOffsetResponse(t).
+			SetOffset("orders", 0, sarama.OffsetOldest, 0).
+			SetOffset("orders", 0, sarama.OffsetNewest, 10),
+	}, map[string]int32{"orders": 1})
+	plan := &core.TopicPlan{IncreasePartitions: []core.TopicPartitionsChange{{Topic: "orders", From: 1, To: 2}}}
+	assert.ErrorContains(t, admin.ApplyTopicPlan(plan, false), "key-to-partition affinity")
+	assert.NoError(t, admin.ApplyTopicPlan(plan, true))
+}
+
+func TestSaramaAdmin_WhenAlterTopicConfigWithUnknownConfig_ShouldReturnErrorBeforeSendingRequest(t *testing.T) {
+	admin := newTestSaramaAdmin(t, map[string]sarama.MockResponse{}, nil)
+	err := admin.AlterTopicConfig("orders", map[string]string{"retention.ms": "3600000", "retention.hours": "1"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "[retention.hours]")
+}
+
+func TestSaramaAdmin_WhenNoRecordAfterTimestamp_ShouldReturnEndOffset(t *testing.T) {
+	now := time.Now()
+	admin := newTestSaramaAdmin(t, map[string]sarama.MockResponse{
+		"OffsetRequest": sarama.NewMockOffsetResponse(t).
+			SetOffset("orders", 0, now.UnixMilli(), 42).
+			SetOffset("orders", 1, now.UnixMilli(), -1).
+			SetOffset("orders", 1, sarama.OffsetNewest, 7),
+	}, map[string]int32{"orders": 2})
+	offsets, err := admin.OffsetsForTimestamp("orders", now)
+	assert.NoError(t, err)
+	assert.Equal(t, map[int32]int64{0: 42, 1: 7}, offsets)
+}
+
+func TestSaramaAdmin_WhenAclIsInvalid_ShouldReturnErrorBeforeSendingRequest(t *testing.T) {
+	admin := newTestSaramaAdmin(t, map[string]sarama.MockResponse{}, nil)
+	err := admin.CreateACLs([]core.Acl{{
+		ResourceType: core.AclResourceTopic,
+		ResourceName: "orders",
+		Principal:    "User:order-service",
+		Operation:    "publish",
+	}})
+	assert.Error(t, err)
+}
+
+func TestSaramaAdmin_WhenListACLs_ShouldReturnLibraryAcls(t *testing.T) {
+	admin := newTestSaramaAdmin(t, map[string]sarama.MockResponse{
+		"CreateAclsRequest":   sarama.NewMockCreateAclsResponse(t),
+		"DescribeAclsRequest": sarama.NewMockListAclsResponse(t),
+	}, nil)
+	assert.NoError(t, admin.CreateACLs([]core.Acl{{
+		ResourceType: core.AclResourceTopic,
+		ResourceName: "orders",
+		Principal:    "User:order-service",
+		Operation:    core.AclOperationWrite,
+	}}))
+	acls, err := admin.ListACLs(core.AclFilter{
+		ResourceType: core.AclResourceTopic,
+		ResourceName: "orders",
+		PatternType:  core.AclPatternLiteral,
+		Principal:    "User:order-service",
+		Operation:    core.AclOperationWrite,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []core.Acl{{
+		ResourceType: core.AclResourceTopic,
+		ResourceName: "orders",
+		PatternType:  core.AclPatternLiteral,
+		Principal:    "User:order-service",
+		Host:         "*",
+		Operation:    core.AclOperationWrite,
+		Permission:   core.AclPermissionAllow,
+	}}, acls)
+}
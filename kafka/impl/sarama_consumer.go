@@ -4,12 +4,22 @@ import (
 	"context"
 	"fmt"
 	"github.com/Shopify/sarama"
+	"github.com/golibs-starter/golib-message-bus/kafka/codec"
+	"github.com/golibs-starter/golib-message-bus/kafka/constant"
 	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"github.com/golibs-starter/golib-message-bus/kafka/filter"
 	"github.com/golibs-starter/golib-message-bus/kafka/properties"
+	"github.com/golibs-starter/golib-message-bus/kafka/validator"
 	"github.com/golibs-starter/golib/log"
 	coreUtils "github.com/golibs-starter/golib/utils"
 	"github.com/pkg/errors"
+	"reflect"
+	"regexp"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type SaramaConsumer struct {
@@ -19,7 +29,11 @@ type SaramaConsumer struct {
 	consumerGroupHandler *ConsumerGroupHandler
 	name                 string
 	topics               []string
-	running              bool
+	topicPattern         *regexp.Regexp
+	topicRefreshInterval time.Duration
+	running              int32
+	cancelSession        context.CancelFunc
+	mu                   sync.Mutex
 }
 
 func NewSaramaConsumer(
@@ -27,8 +41,66 @@ func NewSaramaConsumer(
 	clientProps *properties.Client,
 	topicConsumer *properties.TopicConsumer,
 	handler core.ConsumerHandler,
+	options ConsumerOptions,
 ) (*SaramaConsumer, error) {
 	handlerName := coreUtils.GetStructShortName(handler)
+	codecs := options.Codecs
+	if codecs == nil {
+		codecs = codec.NewRegistry()
+	}
+	producer := options.Producer
+	var msgCodec codec.Codec
+	if topicConsumer.Codec != "" {
+		c, err := codecs.Get(topicConsumer.Codec)
+		if err != nil {
+			return nil, errors.WithMessage(err,
+				fmt.Sprintf("Error when get codec for handler [%s]", handlerName))
+		}
+		msgCodec = c
+	}
+	if topicConsumer.JsonSchema != "" && msgCodec != nil && msgCodec.Name() != codec.Json {
+		return nil, fmt.Errorf("JSON schema of handler [%s] cannot be used with codec [%s]",
+			handlerName, msgCodec.Name())
+	}
+	for _, messageFilter := range topicConsumer.Filters {
+		if messageFilter.JsonPath != "" && msgCodec != nil && msgCodec.Name() != codec.Json {
+			return nil, fmt.Errorf("JSON path filter of handler [%s] cannot be used with codec [%s]",
+				handlerName, msgCodec.Name())
+		}
+	}
+	var payloadValidator validator.Validator
+	if topicConsumer.JsonSchema != "" {
+		if options.SchemaLoader == nil {
+			return nil, fmt.Errorf("a schema loader is required by the JSON schema of handler [%s]", handlerName)
+		}
+		jsonSchemaValidator, err := options.SchemaLoader.Load(topicConsumer.JsonSchema)
+		if err != nil {
+			return nil, errors.WithMessage(err,
+				fmt.Sprintf("Error when load JSON schema for handler [%s]", handlerName))
+		}
+		payloadValidator = jsonSchemaValidator
+	}
+	if topicConsumer.InvalidMessageTopic != "" && producer == nil {
+		return nil, fmt.Errorf("a producer is required to route invalid messages of handler [%s]", handlerName)
+	}
+	if topicConsumer.FailurePolicy == constant.FailurePolicyDeadLetter && topicConsumer.DeadLetterTopic == "" {
+		return nil, fmt.Errorf("a dead letter topic is required by the failure policy of handler [%s]", handlerName)
+	}
+	if topicConsumer.DeadLetterTopic != "" && producer == nil {
+		return nil, fmt.Errorf("a producer is required to route failed messages of handler [%s]", handlerName)
+	}
+	msgFilter, err := filter.New(topicConsumer.Filters)
+	if err != nil {
+		return nil, errors.WithMessage(err, fmt.Sprintf("Invalid filters of handler [%s]", handlerName))
+	}
+	var seekPosition *core.SeekPosition
+	if topicConsumer.Seek != "" {
+		position, err := newSeekPosition(topicConsumer)
+		if err != nil {
+			return nil, errors.WithMessage(err, fmt.Sprintf("Invalid seek position of handler [%s]", handlerName))
+		}
+		seekPosition = &position
+	}
 	client, err := NewSaramaConsumerClient(clientProps)
 	if err != nil {
 		return nil, errors.WithMessage(err,
@@ -39,18 +111,38 @@ func NewSaramaConsumer(
 		return nil, errors.WithMessage(err, "Error when create sarama consumer group")
 	}
 	topics := make([]string, 0)
+	var topicPattern *regexp.Regexp
 	if topicConsumer.Topic != "" {
 		topics = append(topics, strings.TrimSpace(topicConsumer.Topic))
-	} else {
+	} else if len(topicConsumer.Topics) > 0 {
 		for _, topic := range topicConsumer.Topics {
 			topics = append(topics, strings.TrimSpace(topic))
 		}
+	} else if topicConsumer.TopicPattern != "" {
+		topicPattern, err = compileTopicPattern(topicConsumer.TopicPattern)
+		if err != nil {
+			return nil, errors.WithMessage(err,
+				fmt.Sprintf("Invalid topic pattern of handler [%s]", handlerName))
+		}
+	}
+	consumerGroupHandler := NewConsumerGroupHandler(client, handler, mapper, topicConsumer, ConsumerGroupHandlerOptions{
+		Validator:    payloadValidator,
+		Producer:     producer,
+		Codec:        msgCodec,
+		Decrypter:    options.Decrypter,
+		LargeMessage: options.LargeMessage,
+		Middlewares:  options.Middlewares,
+		Filter:       msgFilter,
+	})
+	if seekPosition != nil {
+		consumerGroupHandler.SeekOnceTo(*seekPosition)
 	}
-	consumerGroupHandler := NewConsumerGroupHandler(client, handler, mapper)
 	return &SaramaConsumer{
 		client:               client,
 		name:                 handlerName,
 		topics:               topics,
+		topicPattern:         topicPattern,
+		topicRefreshInterval: topicConsumer.TopicRefreshInterval,
 		consumerGroup:        consumerGroup,
 		consumerHandler:      handler,
 		consumerGroupHandler: consumerGroupHandler,
@@ -67,15 +159,40 @@ func (c *SaramaConsumer) Start(ctx context.Context) {
 		}
 	}()
 
+	// Stop consuming when the failure policy requires it
+	go func() {
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.consumerGroupHandler.Stopped():
+		}
+		c.setRunning(false)
+		if err := c.consumerGroup.Close(); err != nil {
+			log.WithErrors(err).Errorf("Consumer [%s] could not stop", c.name)
+		}
+	}()
+
 	// Iterate over consumers sessions.
-	c.running = true
-	for c.running {
+	c.setRunning(true)
+	for c.isRunning() {
+		if c.topicPattern != nil && !c.subscribe(ctx) {
+			continue
+		}
 		log.Infof("Consumer [%s] with topics [%v] is running", c.name, c.topics)
-		if err := c.consumerGroup.Consume(ctx, c.topics, c.consumerGroupHandler); err != nil {
+		sessCtx, cancel := context.WithCancel(ctx)
+		c.mu.Lock()
+		c.cancelSession = cancel
+		c.mu.Unlock()
+		if c.topicPattern != nil {
+			go c.watchTopics(sessCtx, cancel, c.topics)
+		}
+		err := c.consumerGroup.Consume(sessCtx, c.topics, c.consumerGroupHandler)
+		cancel()
+		if err != nil {
 			if err == sarama.ErrClosedConsumerGroup {
 				log.Infof("Consumer [%s] is closed when consume topics [%v], detail [%s]",
 					c.name, c.topics, err.Error())
-			} else if !c.running {
+			} else if !c.isRunning() {
 				log.Infof("Consumer [%s] is closed when consume topics [%v]",
 					c.name, c.topics)
 			} else {
@@ -88,6 +205,138 @@ func (c *SaramaConsumer) Start(ctx context.Context) {
 	log.Infof("Consumer [%s] with topics [%v] is closed", c.name, c.topics)
 }
 
+// setRunning is called by Stop and the failure policy concurrently with the consuming loop
+func (c *SaramaConsumer) setRunning(running bool) {
+	var value int32
+	if running {
+		value = 1
+	}
+	atomic.StoreInt32(&c.running, value)
+}
+
+func (c *SaramaConsumer) isRunning() bool {
+	return atomic.LoadInt32(&c.running) == 1
+}
+
+// Seek resets the group to the position by re-joining the group
+func (c *SaramaConsumer) Seek(position core.SeekPosition) error {
+	if err := validateSeekPosition(position); err != nil {
+		return err
+	}
+	log.Infof("Consumer [%s] seeks to [%s]", c.name, position.Type)
+	c.consumerGroupHandler.SeekTo(position)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cancelSession != nil {
+		c.cancelSession()
+	}
+	return nil
+}
+
+func newSeekPosition(topicConsumer *properties.TopicConsumer) (core.SeekPosition, error) {
+	position := core.SeekPosition{Type: topicConsumer.Seek, Offset: topicConsumer.SeekOffset}
+	if topicConsumer.Seek == constant.SeekTimestamp {
+		timestamp, err := time.Parse(time.RFC3339, topicConsumer.SeekTimestamp)
+		if err != nil {
+			return position, errors.WithMessage(err, "invalid seek timestamp")
+		}
+		position.Timestamp = timestamp
+	}
+	return position, validateSeekPosition(position)
+}
+
+func validateSeekPosition(position core.SeekPosition) error {
+	switch position.Type {
+	case constant.SeekEarliest, constant.SeekLatest:
+		return nil
+	case constant.SeekOffset:
+		if position.Offset < 0 {
+			return fmt.Errorf("seek offset must not be negative, got [%d]", position.Offset)
+		}
+		return nil
+	case constant.SeekTimestamp:
+		if position.Timestamp.IsZero() {
+			return errors.New("seek timestamp is required")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported seek position [%s]", position.Type)
+	}
+}
+
+// subscribe resolves the topics matching the topic pattern.
+// It marks the consumer ready and returns false after waiting for the refresh interval
+// when there is no matching topic.
+func (c *SaramaConsumer) subscribe(ctx context.Context) bool {
+	if err := c.client.RefreshMetadata(); err != nil {
+		log.WithErrors(err).Warnf("Consumer [%s] cannot refresh metadata", c.name)
+	}
+	topics, err := c.client.Topics()
+	if err != nil {
+		log.WithErrors(err).Errorf("Consumer [%s] cannot list topics", c.name)
+	}
+	c.topics = matchTopics(c.topicPattern, topics)
+	if len(c.topics) > 0 {
+		return true
+	}
+	log.Infof("Consumer [%s] finds no topic matching pattern [%s], retry after [%s]",
+		c.name, c.topicPattern, c.topicRefreshInterval)
+	// There is nothing to consume yet, the application must not wait for it to start
+	c.consumerGroupHandler.MarkReady()
+	select {
+	case <-ctx.Done():
+		c.setRunning(false)
+	case <-time.After(c.topicRefreshInterval):
+	}
+	return false
+}
+
+// watchTopics periodically refreshes the cluster metadata and calls resubscribe
+// when the topics matching the topic pattern are different from the subscribed topics.
+func (c *SaramaConsumer) watchTopics(ctx context.Context, resubscribe context.CancelFunc, subscribed []string) {
+	ticker := time.NewTicker(c.topicRefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := c.client.RefreshMetadata(); err != nil {
+			log.WithErrors(err).Warnf("Consumer [%s] cannot refresh metadata", c.name)
+			continue
+		}
+		topics, err := c.client.Topics()
+		if err != nil {
+			log.WithErrors(err).Warnf("Consumer [%s] cannot list topics", c.name)
+			continue
+		}
+		matched := matchTopics(c.topicPattern, topics)
+		if !reflect.DeepEqual(matched, subscribed) {
+			log.Infof("Consumer [%s] topics are changed from [%v] to [%v], re-subscribing", c.name, subscribed, matched)
+			resubscribe()
+			return
+		}
+	}
+}
+
+// compileTopicPattern compiles the pattern to match whole topic names
+func compileTopicPattern(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile("^(?:" + strings.TrimSpace(pattern) + ")$")
+}
+
+// matchTopics returns the sorted topics matching the pattern
+func matchTopics(pattern *regexp.Regexp, topics []string) []string {
+	matched := make([]string, 0)
+	for _, topic := range topics {
+		if pattern.MatchString(topic) {
+			matched = append(matched, topic)
+		}
+	}
+	sort.Strings(matched)
+	return matched
+}
+
 func (c *SaramaConsumer) WaitForReady() chan bool {
 	return c.consumerGroupHandler.WaitForReady()
 }
@@ -95,12 +344,20 @@ func (c *SaramaConsumer) WaitForReady() chan bool {
 func (c *SaramaConsumer) Stop() {
 	log.Infof("Consumer [%s] is stopping", c.name)
 	defer log.Infof("Consumer [%s] stopped", c.name)
-	c.running = false
+	c.setRunning(false)
 	c.consumerHandler.Close()
-	if err := c.consumerGroup.Close(); err != nil {
+	if err := c.consumerGroup.Close(); err != nil && err != sarama.ErrClosedConsumerGroup {
 		log.WithErrors(err).Errorf("Consumer [%s] could not stop", c.name)
 	}
 	if err := c.client.Close(); err != nil {
 		log.WithErrors(err).Errorf("Consumer client [%s] could not stop", c.name)
 	}
 }
+
+// Stats returns the statistics of the consumer
+func (c *SaramaConsumer) Stats() ConsumerStats {
+	return ConsumerStats{
+		Panics:   c.consumerGroupHandler.Panics(),
+		Filtered: c.consumerGroupHandler.Filtered(),
+	}
+}
@@ -1,27 +1,102 @@
 package impl
 
 import (
+	"context"
+	"fmt"
 	"github.com/Shopify/sarama"
+	"github.com/golibs-starter/golib-message-bus/kafka/codec"
+	"github.com/golibs-starter/golib-message-bus/kafka/constant"
 	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"github.com/golibs-starter/golib-message-bus/kafka/encryption"
+	"github.com/golibs-starter/golib-message-bus/kafka/filter"
+	"github.com/golibs-starter/golib-message-bus/kafka/interceptor"
+	"github.com/golibs-starter/golib-message-bus/kafka/largemessage"
+	"github.com/golibs-starter/golib-message-bus/kafka/properties"
+	"github.com/golibs-starter/golib-message-bus/kafka/validator"
 	"github.com/golibs-starter/golib/log"
 	coreUtils "github.com/golibs-starter/golib/utils"
+	"github.com/pkg/errors"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type ConsumerGroupHandler struct {
-	handler     core.ConsumerHandler
-	handlerName string
-	client      sarama.Client
-	mapper      *SaramaMapper
-	unready     chan bool
+	panics        int64 // First fields to be 64-bit aligned for atomic operations
+	filtered      int64
+	handler       core.ConsumerHandler
+	handle        func(msg *core.ConsumerMessage)
+	handlerName   string
+	client        sarama.Client
+	mapper        *SaramaMapper
+	topicConsumer *properties.TopicConsumer
+	validator     validator.Validator
+	producer      core.SyncProducer
+	codec         codec.Codec
+	decrypter     *encryption.Decrypter
+	largeMessage  *largemessage.Decoder
+	filter        *filter.Filter
+	unready       chan bool
+	stopped       chan struct{}
+	stopOnce      sync.Once
+	seek          *core.SeekPosition
+	seekOnce      *core.SeekPosition
+	seekMu        sync.Mutex
+
+	// offsetMetadata is committed along with offsets, it records the position applied by SeekOnceTo
+	offsetMetadata string
 }
 
-func NewConsumerGroupHandler(client sarama.Client, handler core.ConsumerHandler, mapper *SaramaMapper) *ConsumerGroupHandler {
+// ConsumerGroupHandlerOptions are the optional dependencies of a consumer group handler
+type ConsumerGroupHandlerOptions struct {
+	// Validator validates messages, invalid messages are routed to the invalid message topic by the Producer.
+	Validator validator.Validator
+
+	// Producer routes invalid and failed messages.
+	Producer core.SyncProducer
+
+	// Codec is set as the content type of messages without content-type header.
+	Codec codec.Codec
+
+	// Decrypter decrypts encrypted messages before invoking the handler.
+	Decrypter *encryption.Decrypter
+
+	// LargeMessage restores chunked and claim-check messages.
+	LargeMessage *largemessage.Decoder
+
+	// Middlewares are called around the handler in their order.
+	Middlewares []core.ConsumerMiddleware
+
+	// Filter marks the messages not matching it as consumed without invoking the handler.
+	Filter *filter.Filter
+}
+
+// NewConsumerGroupHandler creates the sarama handler of a consumer.
+func NewConsumerGroupHandler(
+	client sarama.Client,
+	handler core.ConsumerHandler,
+	mapper *SaramaMapper,
+	topicConsumer *properties.TopicConsumer,
+	options ConsumerGroupHandlerOptions,
+) *ConsumerGroupHandler {
 	return &ConsumerGroupHandler{
-		handler:     handler,
-		handlerName: coreUtils.GetStructShortName(handler),
-		client:      client,
-		mapper:      mapper,
-		unready:     make(chan bool),
+		handler:       handler,
+		handle:        interceptor.ChainConsumerMiddlewares(handler, options.Middlewares),
+		handlerName:   coreUtils.GetStructShortName(handler),
+		client:        client,
+		mapper:        mapper,
+		topicConsumer: topicConsumer,
+		validator:     options.Validator,
+		producer:      options.Producer,
+		codec:         options.Codec,
+		decrypter:     options.Decrypter,
+		largeMessage:  options.LargeMessage,
+		filter:        options.Filter,
+		unready:       make(chan bool),
+		stopped:       make(chan struct{}),
 	}
 }
 
@@ -33,13 +108,162 @@ func (cg *ConsumerGroupHandler) MarkUnready() {
 	cg.unready = make(chan bool)
 }
 
-func (cg *ConsumerGroupHandler) Setup(_ sarama.ConsumerGroupSession) error {
+// MarkReady closes the ready channel unless it's already closed
+func (cg *ConsumerGroupHandler) MarkReady() {
+	select {
+	case <-cg.unready:
+	default:
+		close(cg.unready)
+	}
+}
+
+// Stopped is closed when the consumer has to stop because of the stop failure policy
+func (cg *ConsumerGroupHandler) Stopped() <-chan struct{} {
+	return cg.stopped
+}
+
+// Panics returns the number of panics recovered from the handler
+func (cg *ConsumerGroupHandler) Panics() int64 {
+	return atomic.LoadInt64(&cg.panics)
+}
+
+// Filtered returns the number of messages skipped by the filter
+func (cg *ConsumerGroupHandler) Filtered() int64 {
+	return atomic.LoadInt64(&cg.filtered)
+}
+
+// SeekTo resets offsets of the claimed partitions to the position at the next setup
+func (cg *ConsumerGroupHandler) SeekTo(position core.SeekPosition) {
+	cg.seekMu.Lock()
+	defer cg.seekMu.Unlock()
+	cg.seek = &position
+}
+
+// SeekOnceTo resets offsets of the claimed partitions to the position if it's not applied yet,
+// the position is recorded in the metadata of committed offsets, so restarts and rebalances don't apply it again.
+// It must be called before the consumer starts.
+func (cg *ConsumerGroupHandler) SeekOnceTo(position core.SeekPosition) {
+	cg.seekMu.Lock()
+	defer cg.seekMu.Unlock()
+	cg.seekOnce = &position
+	cg.offsetMetadata = seekMetadata(position)
+}
+
+func (cg *ConsumerGroupHandler) Setup(sess sarama.ConsumerGroupSession) error {
 	log.Debugf("Setup consumer group handler [%s]", cg.handlerName)
+	if err := cg.resetOffsets(sess); err != nil {
+		return err
+	}
 	// Mark the consumer as ready
-	close(cg.unready)
+	cg.MarkReady()
+	return nil
+}
+
+// resetOffsets applies the pending seek position, it's kept pending when it fails
+// so that it's applied again at the next setup.
+func (cg *ConsumerGroupHandler) resetOffsets(sess sarama.ConsumerGroupSession) error {
+	cg.seekMu.Lock()
+	defer cg.seekMu.Unlock()
+	if cg.seek != nil {
+		if err := cg.resetClaimedOffsets(sess, sess.Claims(), *cg.seek); err != nil {
+			return err
+		}
+		cg.seek = nil
+		return nil
+	}
+	if cg.seekOnce == nil {
+		return nil
+	}
+	claims, err := cg.unseekedClaims(sess.Claims())
+	if err != nil {
+		return err
+	}
+	return cg.resetClaimedOffsets(sess, claims, *cg.seekOnce)
+}
+
+func (cg *ConsumerGroupHandler) resetClaimedOffsets(sess sarama.ConsumerGroupSession,
+	claims map[string][]int32, position core.SeekPosition) error {
+	if len(claims) == 0 {
+		return nil
+	}
+	for topic, partitions := range claims {
+		for _, partition := range partitions {
+			offset, err := cg.seekOffset(topic, partition, position)
+			if err != nil {
+				return errors.WithMessagef(err, "cannot find [%s] offset of topic [%s], partition [%d]",
+					position.Type, topic, partition)
+			}
+			log.Infof("Consumer [%s] resets offset of topic [%s], partition [%d] to [%d]",
+				cg.handlerName, topic, partition, offset)
+			// ResetOffset only moves the offset backward, MarkOffset only moves it forward
+			sess.ResetOffset(topic, partition, offset, cg.offsetMetadata)
+			sess.MarkOffset(topic, partition, offset, cg.offsetMetadata)
+		}
+	}
+	sess.Commit()
 	return nil
 }
 
+// unseekedClaims returns the claimed partitions whose committed offset doesn't record the seek position
+func (cg *ConsumerGroupHandler) unseekedClaims(claims map[string][]int32) (map[string][]int32, error) {
+	groupId := strings.TrimSpace(cg.topicConsumer.GroupId)
+	coordinator, err := cg.client.Coordinator(groupId)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "cannot find coordinator of group [%s]", groupId)
+	}
+	request := &sarama.OffsetFetchRequest{Version: 1, ConsumerGroup: groupId}
+	for topic, partitions := range claims {
+		for _, partition := range partitions {
+			request.AddPartition(topic, partition)
+		}
+	}
+	response, err := coordinator.FetchOffset(request)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "cannot fetch committed offsets of group [%s]", groupId)
+	}
+	unseeked := make(map[string][]int32)
+	for topic, partitions := range claims {
+		for _, partition := range partitions {
+			block := response.GetBlock(topic, partition)
+			if block != nil && block.Err == sarama.ErrNoError && block.Metadata == cg.offsetMetadata {
+				continue
+			}
+			unseeked[topic] = append(unseeked[topic], partition)
+		}
+	}
+	return unseeked, nil
+}
+
+// seekMetadata identifies the seek position in the metadata of committed offsets
+func seekMetadata(position core.SeekPosition) string {
+	switch position.Type {
+	case constant.SeekOffset:
+		return fmt.Sprintf("seek:%s:%d", position.Type, position.Offset)
+	case constant.SeekTimestamp:
+		return fmt.Sprintf("seek:%s:%d", position.Type, position.Timestamp.UnixMilli())
+	default:
+		return "seek:" + position.Type
+	}
+}
+
+func (cg *ConsumerGroupHandler) seekOffset(topic string, partition int32, position core.SeekPosition) (int64, error) {
+	switch position.Type {
+	case constant.SeekEarliest:
+		return cg.client.GetOffset(topic, partition, sarama.OffsetOldest)
+	case constant.SeekLatest:
+		return cg.client.GetOffset(topic, partition, sarama.OffsetNewest)
+	case constant.SeekTimestamp:
+		offset, err := cg.client.GetOffset(topic, partition, position.Timestamp.UnixMilli())
+		if err == nil && offset < 0 {
+			// No message is produced after the timestamp
+			return cg.client.GetOffset(topic, partition, sarama.OffsetNewest)
+		}
+		return offset, err
+	default:
+		return position.Offset, nil
+	}
+}
+
 func (cg *ConsumerGroupHandler) Cleanup(sess sarama.ConsumerGroupSession) error {
 	if sess.Context().Err() != nil {
 		log.WithErrors(sess.Context().Err()).Debugf("Cleanup consumer group handler [%s]", cg.handlerName)
@@ -48,13 +272,35 @@ func (cg *ConsumerGroupHandler) Cleanup(sess sarama.ConsumerGroupSession) error
 }
 
 func (cg *ConsumerGroupHandler) ConsumeClaim(sess sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	var assembler *largemessage.Assembler
+	if cg.largeMessage != nil {
+		assembler = cg.largeMessage.NewAssembler()
+	}
 	for {
 		select {
 		case msg := <-claim.Messages():
-			cg.handler.HandlerFunc(cg.mapper.ToCoreConsumerMessage(msg))
+			for _, coreMsg := range cg.assemble(assembler, cg.mapper.ToCoreConsumerMessage(msg)) {
+				cg.applyContentType(coreMsg)
+				if cg.resolve(coreMsg) && cg.decrypt(coreMsg) && cg.match(coreMsg) && cg.validate(coreMsg) {
+					switch cg.process(sess.Context(), coreMsg) {
+					case processInterrupted:
+						// The message is not marked, so it's consumed again by the next session
+						return nil
+					case processStopped:
+						cg.stopOnce.Do(func() { close(cg.stopped) })
+						return nil
+					}
+				}
+			}
+
+			if assembler != nil && assembler.Pending() {
+				// Offsets are marked once all chunks are reassembled and the held messages are handled,
+				// so the chunks and the held messages are consumed again after a restart
+				break
+			}
 
 			// Mark this message as consumed
-			sess.MarkMessage(msg, "")
+			sess.MarkMessage(msg, cg.offsetMetadata)
 
 			if !cg.client.Config().Consumer.Offsets.AutoCommit.Enable {
 				// Manual commit if auto commit is disabled
@@ -64,6 +310,187 @@ func (cg *ConsumerGroupHandler) ConsumeClaim(sess sarama.ConsumerGroupSession, c
 		case <-sess.Context().Done():
 			log.Infof("Consumer session closed, [%s] stops taking new messages", cg.handlerName)
 			return nil
+		case <-cg.stopped:
+			return nil
+		}
+	}
+}
+
+type processResult int
+
+const (
+	processDone processResult = iota
+	processInterrupted
+	processStopped
+)
+
+// process invokes the handler and applies the failure policy when it panics
+func (cg *ConsumerGroupHandler) process(ctx context.Context, msg *core.ConsumerMessage) processResult {
+	err := cg.handleSafely(msg)
+	if err == nil {
+		return processDone
+	}
+	switch cg.topicConsumer.FailurePolicy {
+	case constant.FailurePolicyRetry:
+		for attempt := 1; err != nil && attempt <= cg.topicConsumer.MaxRetries; attempt++ {
+			select {
+			case <-ctx.Done():
+				return processInterrupted
+			case <-time.After(cg.topicConsumer.RetryBackoff):
+			}
+			log.Infof("Consumer [%s] retries message at topic [%s], partition [%d], offset [%d], attempt [%d]",
+				cg.handlerName, msg.Topic, msg.Partition, msg.Offset, attempt)
+			err = cg.handleSafely(msg)
+		}
+		if err != nil && cg.topicConsumer.DeadLetterTopic != "" {
+			cg.forward(cg.topicConsumer.DeadLetterTopic, msg, constant.HeaderException, err)
+		}
+	case constant.FailurePolicyDeadLetter:
+		cg.forward(cg.topicConsumer.DeadLetterTopic, msg, constant.HeaderException, err)
+	case constant.FailurePolicyStop:
+		log.Errorf("Consumer [%s] is stopped by its failure policy at topic [%s], partition [%d], offset [%d]",
+			cg.handlerName, msg.Topic, msg.Partition, msg.Offset)
+		return processStopped
+	}
+	return processDone
+}
+
+// handleSafely invokes the handler, a panic is recovered and returned as an error
+func (cg *ConsumerGroupHandler) handleSafely(msg *core.ConsumerMessage) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			atomic.AddInt64(&cg.panics, 1)
+			err = fmt.Errorf("handler panics: %v", r)
+			log.WithErrors(err).Errorf("Consumer [%s] panics when handling message at topic [%s], "+
+				"partition [%d], offset [%d]\n%s", cg.handlerName, msg.Topic, msg.Partition, msg.Offset, debug.Stack())
+		}
+	}()
+	cg.handle(msg)
+	return nil
+}
+
+// assemble returns the messages to handle, nothing is returned while some chunks are still missing.
+func (cg *ConsumerGroupHandler) assemble(assembler *largemessage.Assembler, msg *core.ConsumerMessage) []*core.ConsumerMessage {
+	if assembler == nil {
+		return []*core.ConsumerMessage{msg}
+	}
+	messages, err := assembler.Add(msg)
+	if err != nil {
+		log.WithErrors(err).Errorf("Consumer [%s] cannot reassemble message at topic [%s], partition [%d], offset [%d]",
+			cg.handlerName, msg.Topic, msg.Partition, msg.Offset)
+	}
+	return messages
+}
+
+// resolve returns false when the value of a claim-check message cannot be loaded, in this case
+// the message is routed to the invalid message topic if it is configured.
+func (cg *ConsumerGroupHandler) resolve(msg *core.ConsumerMessage) bool {
+	if cg.largeMessage == nil {
+		return true
+	}
+	err := cg.largeMessage.Resolve(context.Background(), msg)
+	if err == nil {
+		return true
+	}
+	log.WithErrors(err).Errorf("Consumer [%s] cannot load large message at topic [%s], partition [%d], offset [%d]",
+		cg.handlerName, msg.Topic, msg.Partition, msg.Offset)
+	cg.routeInvalidMessage(msg, err)
+	return false
+}
+
+// applyContentType sets the content type of the configured codec
+// when the message doesn't advertise its content type.
+func (cg *ConsumerGroupHandler) applyContentType(msg *core.ConsumerMessage) {
+	if cg.codec == nil {
+		return
+	}
+	for _, header := range msg.Headers {
+		if strings.EqualFold(string(header.Key), constant.HeaderContentType) {
+			return
 		}
 	}
+	msg.Headers = append(msg.Headers, core.MessageHeader{
+		Key:   []byte(constant.HeaderContentType),
+		Value: []byte(cg.codec.ContentType()),
+	})
+}
+
+// decrypt returns false when the message cannot be decrypted, in this case
+// the message is routed to the invalid message topic if it is configured.
+func (cg *ConsumerGroupHandler) decrypt(msg *core.ConsumerMessage) bool {
+	if cg.decrypter == nil || !encryption.IsEncrypted(msg) {
+		return true
+	}
+	err := cg.decrypter.Decrypt(context.Background(), msg)
+	if err == nil {
+		return true
+	}
+	log.WithErrors(err).Errorf("Consumer [%s] cannot decrypt message at topic [%s], partition [%d], offset [%d]",
+		cg.handlerName, msg.Topic, msg.Partition, msg.Offset)
+	cg.routeInvalidMessage(msg, err)
+	return false
+}
+
+// match returns false when the message is filtered out
+func (cg *ConsumerGroupHandler) match(msg *core.ConsumerMessage) bool {
+	if cg.filter == nil || cg.filter.Match(msg) {
+		return true
+	}
+	atomic.AddInt64(&cg.filtered, 1)
+	log.Debugf("Consumer [%s] filters out message at topic [%s], partition [%d], offset [%d]",
+		cg.handlerName, msg.Topic, msg.Partition, msg.Offset)
+	return false
+}
+
+// validate returns false when the message is invalid, in this case
+// the message is routed to the invalid message topic if it is configured.
+func (cg *ConsumerGroupHandler) validate(msg *core.ConsumerMessage) bool {
+	if cg.validator == nil {
+		return true
+	}
+	document, err := validator.PayloadDocument(msg)
+	if err == nil && document == nil {
+		// Wire format messages are checked against their schema by the registry serde
+		return true
+	}
+	if err == nil {
+		err = cg.validator.Validate(document)
+	}
+	if err == nil {
+		return true
+	}
+	log.WithErrors(err).Warnf("Consumer [%s] receives invalid message at topic [%s], partition [%d], offset [%d]",
+		cg.handlerName, msg.Topic, msg.Partition, msg.Offset)
+	cg.routeInvalidMessage(msg, err)
+	return false
+}
+
+func (cg *ConsumerGroupHandler) routeInvalidMessage(msg *core.ConsumerMessage, err error) {
+	if cg.topicConsumer.InvalidMessageTopic == "" {
+		return
+	}
+	cg.forward(cg.topicConsumer.InvalidMessageTopic, msg, constant.HeaderValidationError, err)
+}
+
+// forward sends the message to the topic with its original coordinates and the error in headers
+func (cg *ConsumerGroupHandler) forward(topic string, msg *core.ConsumerMessage, errorHeader string, err error) {
+	if cg.producer == nil {
+		return
+	}
+	headers := append(make([]core.MessageHeader, 0, len(msg.Headers)+4), msg.Headers...)
+	headers = append(headers,
+		core.MessageHeader{Key: []byte(constant.HeaderOriginalTopic), Value: []byte(msg.Topic)},
+		core.MessageHeader{Key: []byte(constant.HeaderOriginalPartition), Value: []byte(strconv.Itoa(int(msg.Partition)))},
+		core.MessageHeader{Key: []byte(constant.HeaderOriginalOffset), Value: []byte(strconv.FormatInt(msg.Offset, 10))},
+		core.MessageHeader{Key: []byte(errorHeader), Value: []byte(err.Error())},
+	)
+	if _, _, err := cg.producer.Send(&core.Message{
+		Topic:   topic,
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: headers,
+	}); err != nil {
+		log.WithErrors(err).Errorf("Consumer [%s] cannot forward message to topic [%s]",
+			cg.handlerName, topic)
+	}
 }
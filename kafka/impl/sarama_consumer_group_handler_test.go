@@ -0,0 +1,267 @@
+package impl
+
+import (
+	"context"
+	"github.com/Shopify/sarama"
+	"github.com/golibs-starter/golib-message-bus/kafka/constant"
+	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"github.com/golibs-starter/golib-message-bus/kafka/filter"
+	"github.com/golibs-starter/golib-message-bus/kafka/properties"
+	"github.com/golibs-starter/golib-message-bus/kafka/relayer"
+	"github.com/golibs-starter/golib-message-bus/kafka/validator"
+	"github.com/golibs-starter/golib/config"
+	"github.com/golibs-starter/golib/event"
+	assert "github.com/stretchr/testify/require"
+	"testing"
+	"testing/fstest"
+)
+
+type testPanicHandler struct {
+	calls int
+}
+
+func (t *testPanicHandler) HandlerFunc(_ *core.ConsumerMessage) {
+	t.calls++
+	panic("poison pill")
+}
+
+func (t *testPanicHandler) Close() {
+}
+
+type testSyncProducer struct {
+	messages []*core.Message
+}
+
+func (t *testSyncProducer) Send(m *core.Message) (partition int32, offset int64, err error) {
+	t.messages = append(t.messages, m)
+	return 0, 0, nil
+}
+
+func (t *testSyncProducer) Close() error {
+	return nil
+}
+
+func newTestConsumerGroupHandler(handler core.ConsumerHandler, topicConsumer *properties.TopicConsumer,
+	producer core.SyncProducer) *ConsumerGroupHandler {
+	return NewConsumerGroupHandler(nil, handler, NewSaramaMapper(), topicConsumer,
+		ConsumerGroupHandlerOptions{Producer: producer})
+}
+
+func TestConsumerGroupHandler_WhenHandlerPanicsWithSkipPolicy_ShouldRecover(t *testing.T) {
+	handler := &testPanicHandler{}
+	cg := newTestConsumerGroupHandler(handler, &properties.TopicConsumer{FailurePolicy: constant.FailurePolicySkip}, nil)
+	result := cg.process(context.Background(), &core.ConsumerMessage{Topic: "test.topic"})
+	assert.Equal(t, processDone, result)
+	assert.Equal(t, 1, handler.calls)
+	assert.Equal(t, int64(1), cg.Panics())
+}
+
+func TestConsumerGroupHandler_WhenRetriesAreExhausted_ShouldRouteToDeadLetterTopic(t *testing.T) {
+	handler := &testPanicHandler{}
+	producer := &testSyncProducer{}
+	cg := newTestConsumerGroupHandler(handler, &properties.TopicConsumer{
+		FailurePolicy:   constant.FailurePolicyRetry,
+		MaxRetries:      2,
+		DeadLetterTopic: "test.topic.dlt",
+	}, producer)
+	result := cg.process(context.Background(), &core.ConsumerMessage{Topic: "test.topic", Value: []byte("v1")})
+	assert.Equal(t, processDone, result)
+	assert.Equal(t, 3, handler.calls)
+	assert.Len(t, producer.messages, 1)
+	assert.Equal(t, "test.topic.dlt", producer.messages[0].Topic)
+	assert.Equal(t, []byte("v1"), producer.messages[0].Value)
+	var exception string
+	for _, header := range producer.messages[0].Headers {
+		if string(header.Key) == constant.HeaderException {
+			exception = string(header.Value)
+		}
+	}
+	assert.Equal(t, "handler panics: poison pill", exception)
+}
+
+func TestConsumerGroupHandler_WhenHandlerPanicsWithStopPolicy_ShouldStop(t *testing.T) {
+	cg := newTestConsumerGroupHandler(&testPanicHandler{},
+		&properties.TopicConsumer{FailurePolicy: constant.FailurePolicyStop}, nil)
+	result := cg.process(context.Background(), &core.ConsumerMessage{Topic: "test.topic"})
+	assert.Equal(t, processStopped, result)
+}
+
+type testConsumerGroupSession struct {
+	sarama.ConsumerGroupSession
+	ctx      context.Context
+	claims   map[string][]int32
+	offsets  map[int32]int64
+	metadata map[int32]string
+	marked   []int64
+	commits  int
+}
+
+func (t *testConsumerGroupSession) Context() context.Context {
+	return t.ctx
+}
+
+func (t *testConsumerGroupSession) MarkMessage(msg *sarama.ConsumerMessage, _ string) {
+	t.marked = append(t.marked, msg.Offset)
+}
+
+func (t *testConsumerGroupSession) Claims() map[string][]int32 {
+	return t.claims
+}
+
+func (t *testConsumerGroupSession) ResetOffset(_ string, partition int32, offset int64, _ string) {
+	if offset <= t.offsets[partition] {
+		t.offsets[partition] = offset
+	}
+}
+
+func (t *testConsumerGroupSession) MarkOffset(_ string, partition int32, offset int64, metadata string) {
+	if offset > t.offsets[partition] {
+		t.offsets[partition] = offset
+	}
+	if t.metadata != nil {
+		t.metadata[partition] = metadata
+	}
+}
+
+func (t *testConsumerGroupSession) Commit() {
+	t.commits++
+}
+
+func TestConsumerGroupHandler_WhenSeekToOffset_ShouldResetClaimedPartitionsOnce(t *testing.T) {
+	cg := newTestConsumerGroupHandler(&testPanicHandler{}, &properties.TopicConsumer{}, nil)
+	sess := &testConsumerGroupSession{
+		claims:  map[string][]int32{"test.topic": {0, 1}},
+		offsets: map[int32]int64{0: 20, 1: 5},
+	}
+	cg.SeekTo(core.SeekPosition{Type: constant.SeekOffset, Offset: 10})
+	assert.NoError(t, cg.Setup(sess))
+	assert.Equal(t, map[int32]int64{0: 10, 1: 10}, sess.offsets)
+	assert.Equal(t, 1, sess.commits)
+
+	cg.MarkUnready()
+	assert.NoError(t, cg.Setup(sess))
+	assert.Equal(t, 1, sess.commits)
+}
+
+func TestConsumerGroupHandler_WhenSeekOnceToOffset_ShouldOnlyResetPartitionsNotRecordingIt(t *testing.T) {
+	metadata := seekMetadata(core.SeekPosition{Type: constant.SeekOffset, Offset: 10})
+	coordinator := sarama.NewMockBroker(t, 1)
+	defer coordinator.Close()
+	coordinator.SetHandlerByMap(map[string]sarama.MockResponse{
+		"OffsetFetchRequest": sarama.NewMockOffsetFetchResponse(t).
+			SetOffset("test.group", "test.topic", 0, 20, metadata, sarama.ErrNoError).
+			SetOffset("test.group", "test.topic", 1, 5, "", sarama.ErrNoError),
+	})
+	broker := sarama.NewBroker(coordinator.Addr())
+	assert.NoError(t, broker.Open(sarama.NewConfig()))
+	defer func() { _ = broker.Close() }()
+
+	cg := NewConsumerGroupHandler(&testClient{config: sarama.NewConfig(), coordinator: broker}, &testPanicHandler{},
+		NewSaramaMapper(), &properties.TopicConsumer{GroupId: "test.group"}, ConsumerGroupHandlerOptions{})
+	sess := &testConsumerGroupSession{
+		claims:   map[string][]int32{"test.topic": {0, 1}},
+		offsets:  map[int32]int64{0: 20, 1: 5},
+		metadata: map[int32]string{},
+	}
+	cg.SeekOnceTo(core.SeekPosition{Type: constant.SeekOffset, Offset: 10})
+	assert.NoError(t, cg.Setup(sess))
+	assert.Equal(t, map[int32]int64{0: 20, 1: 10}, sess.offsets)
+	assert.Equal(t, map[int32]string{1: metadata}, sess.metadata)
+	assert.Equal(t, 1, sess.commits)
+}
+
+type testConsumerGroupClaim struct {
+	sarama.ConsumerGroupClaim
+	messages chan *sarama.ConsumerMessage
+}
+
+func (t *testConsumerGroupClaim) Messages() <-chan *sarama.ConsumerMessage {
+	return t.messages
+}
+
+type testClient struct {
+	sarama.Client
+	config      *sarama.Config
+	coordinator *sarama.Broker
+}
+
+func (t *testClient) Config() *sarama.Config {
+	return t.config
+}
+
+func (t *testClient) Coordinator(string) (*sarama.Broker, error) {
+	return t.coordinator, nil
+}
+
+type testCountingHandler struct {
+	offsets []int64
+}
+
+func (t *testCountingHandler) HandlerFunc(msg *core.ConsumerMessage) {
+	t.offsets = append(t.offsets, msg.Offset)
+}
+
+func (t *testCountingHandler) Close() {
+}
+
+func TestConsumerGroupHandler_WhenMessagesAreFilteredOut_ShouldMarkAndCountThem(t *testing.T) {
+	msgFilter, err := filter.New([]properties.MessageFilter{{EventName: "OrderCreatedEvent"}})
+	assert.NoError(t, err)
+	handler := &testCountingHandler{}
+	cg := NewConsumerGroupHandler(&testClient{config: sarama.NewConfig()}, handler, NewSaramaMapper(),
+		&properties.TopicConsumer{}, ConsumerGroupHandlerOptions{Filter: msgFilter})
+	ctx, cancel := context.WithCancel(context.Background())
+	sess := &testConsumerGroupSession{ctx: ctx}
+	claim := &testConsumerGroupClaim{messages: make(chan *sarama.ConsumerMessage)}
+	done := make(chan error)
+	go func() {
+		done <- cg.ConsumeClaim(sess, claim)
+	}()
+
+	claim.messages <- &sarama.ConsumerMessage{Topic: "test.topic", Offset: 1, Value: []byte("{}"),
+		Headers: []*sarama.RecordHeader{{Key: []byte(constant.HeaderEventName), Value: []byte("OrderCreatedEvent")}}}
+	claim.messages <- &sarama.ConsumerMessage{Topic: "test.topic", Offset: 2, Value: []byte("{}"),
+		Headers: []*sarama.RecordHeader{{Key: []byte(constant.HeaderEventName), Value: []byte("OrderDeletedEvent")}}}
+	claim.messages <- &sarama.ConsumerMessage{Topic: "test.topic", Offset: 3,
+		Value: []byte(`{"event":"OrderCreatedEvent"}`)}
+	cancel()
+	assert.NoError(t, <-done)
+
+	assert.Equal(t, []int64{1}, handler.offsets)
+	assert.Equal(t, []int64{1, 2, 3}, sess.marked)
+	assert.Equal(t, int64(2), cg.Filtered())
+}
+
+func TestConsumerGroupHandler_WhenEventIsProducedWithSchema_ShouldValidateTheSamePayload(t *testing.T) {
+	fsys := fstest.MapFS{"schemas/order.json": &fstest.MapFile{
+		Data: []byte(`{"type": "object", "required": ["order_id"], "properties": {"order_id": {"type": "string"}}}`),
+	}}
+	loader := validator.NewSchemaLoader(fsys)
+	payloadValidator, err := loader.Load("embed://schemas/order.json")
+	assert.NoError(t, err)
+	cg := NewConsumerGroupHandler(nil, &testPanicHandler{}, NewSaramaMapper(), &properties.TopicConsumer{},
+		ConsumerGroupHandlerOptions{Validator: payloadValidator})
+
+	appProps := &config.AppProperties{Name: "TestApp"}
+	for _, mode := range []string{"", constant.CloudEventsModeBinary, constant.CloudEventsModeStructured} {
+		eventProducerProps := &properties.EventProducer{EventMappings: map[string]properties.EventTopic{
+			"testevent": {TopicName: "test.topic", JsonSchema: "embed://schemas/order.json", CloudEventsMode: mode},
+		}}
+		envelopeConverter, err := relayer.NewCloudEventsConverter(
+			relayer.NewDefaultEventConverter(appProps, eventProducerProps), appProps, eventProducerProps)
+		assert.NoError(t, err)
+		converter, err := validator.NewEventConverter(envelopeConverter, eventProducerProps, loader)
+		assert.NoError(t, err)
+
+		valid, err := converter.Convert(event.NewApplicationEvent(context.Background(), "TestEvent",
+			event.WithPayload(map[string]interface{}{"order_id": "1"})))
+		assert.NoError(t, err)
+		assert.True(t, cg.validate(&core.ConsumerMessage{Value: valid.Value, Headers: valid.Headers}), mode)
+
+		// Produced without the validating converter
+		invalid, err := envelopeConverter.Convert(event.NewApplicationEvent(context.Background(), "TestEvent",
+			event.WithPayload(map[string]interface{}{"order_id": 1})))
+		assert.NoError(t, err)
+		assert.False(t, cg.validate(&core.ConsumerMessage{Value: invalid.Value, Headers: invalid.Headers}), mode)
+	}
+}
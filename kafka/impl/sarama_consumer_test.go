@@ -0,0 +1,72 @@
+package impl
+
+import (
+	"context"
+	"github.com/Shopify/sarama"
+	"github.com/golibs-starter/golib-message-bus/kafka/properties"
+	assert "github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestMatchTopics_ShouldReturnSortedTopicsMatchingWholeName(t *testing.T) {
+	pattern, err := compileTopicPattern(`tenant-.*\.events`)
+	assert.NoError(t, err)
+	topics := matchTopics(pattern, []string{"tenant-b.events", "tenant-a.events", "tenant-a.events.dlt", "orders"})
+	assert.Equal(t, []string{"tenant-a.events", "tenant-b.events"}, topics)
+}
+
+func TestNewSaramaConsumer_WhenJsonSchemaWithoutSchemaLoader_ShouldReturnError(t *testing.T) {
+	_, err := NewSaramaConsumer(NewSaramaMapper(), &properties.Client{},
+		&properties.TopicConsumer{Topic: "test.topic", JsonSchema: "embed://test.json"},
+		&testPanicHandler{}, ConsumerOptions{})
+	assert.ErrorContains(t, err, "a schema loader is required")
+}
+
+func TestNewSaramaConsumer_WhenJsonPathFilterWithNonJsonCodec_ShouldReturnError(t *testing.T) {
+	_, err := NewSaramaConsumer(NewSaramaMapper(), &properties.Client{},
+		&properties.TopicConsumer{Topic: "test.topic", Codec: "msgpack",
+			Filters: []properties.MessageFilter{{JsonPath: "payload.status", Equals: "PAID"}}},
+		&testPanicHandler{}, ConsumerOptions{})
+	assert.ErrorContains(t, err, "JSON path filter of handler [testPanicHandler] cannot be used with codec [msgpack]")
+}
+
+type testTopicsClient struct {
+	testClient
+	topics []string
+}
+
+func (t *testTopicsClient) RefreshMetadata(...string) error {
+	return nil
+}
+
+func (t *testTopicsClient) Topics() ([]string, error) {
+	return t.topics, nil
+}
+
+func TestSaramaConsumer_WhenPatternMatchesNoTopic_ShouldBeReady(t *testing.T) {
+	pattern, err := compileTopicPattern(`tenant-.*\.events`)
+	assert.NoError(t, err)
+	client := &testTopicsClient{testClient: testClient{config: sarama.NewConfig()}, topics: []string{"orders"}}
+	consumer := &SaramaConsumer{
+		client:               client,
+		consumerGroupHandler: NewConsumerGroupHandler(client, &testPanicHandler{}, NewSaramaMapper(), &properties.TopicConsumer{}, ConsumerGroupHandlerOptions{}),
+		name:                 "testPanicHandler",
+		topicPattern:         pattern,
+		topicRefreshInterval: time.Millisecond,
+	}
+
+	assert.False(t, consumer.subscribe(context.Background()))
+	select {
+	case <-consumer.WaitForReady():
+	default:
+		t.Fatal("consumer is not ready")
+	}
+
+	// The consumer is ready again when a session is set up after a matching topic is created
+	client.topics = []string{"tenant-a.events"}
+	assert.True(t, consumer.subscribe(context.Background()))
+	assert.NotPanics(t, func() {
+		assert.NoError(t, consumer.consumerGroupHandler.Setup(&testConsumerGroupSession{ctx: context.Background()}))
+	})
+}
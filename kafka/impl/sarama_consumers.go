@@ -2,8 +2,13 @@ package impl
 
 import (
 	"context"
+	"fmt"
+	"github.com/golibs-starter/golib-message-bus/kafka/codec"
 	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"github.com/golibs-starter/golib-message-bus/kafka/encryption"
+	"github.com/golibs-starter/golib-message-bus/kafka/largemessage"
 	"github.com/golibs-starter/golib-message-bus/kafka/properties"
+	"github.com/golibs-starter/golib-message-bus/kafka/validator"
 	"github.com/golibs-starter/golib/log"
 	coreUtils "github.com/golibs-starter/golib/utils"
 	"github.com/pkg/errors"
@@ -11,11 +16,34 @@ import (
 	"sync"
 )
 
+// ConsumerOptions are the optional dependencies of consumers
+type ConsumerOptions struct {
+	// SchemaLoader loads the JSON schemas of handler mappings, it's required when a mapping has a JSON schema.
+	SchemaLoader *validator.SchemaLoader
+
+	// Producer routes invalid and failed messages, it's required when a mapping has
+	// an invalid message topic or a dead letter topic.
+	Producer core.SyncProducer
+
+	// Codecs resolves the codecs of handler mappings, default: the builtin codecs.
+	Codecs *codec.Registry
+
+	// Decrypter decrypts encrypted messages before invoking the handler.
+	Decrypter *encryption.Decrypter
+
+	// LargeMessage restores chunked and claim-check messages.
+	LargeMessage *largemessage.Decoder
+
+	// Middlewares are called around the handler in their order.
+	Middlewares []core.ConsumerMiddleware
+}
+
 type SaramaConsumers struct {
 	clientProps        *properties.Client
 	consumerProps      *properties.Consumer
 	kafkaConsumerProps *properties.KafkaConsumer
 	mapper             *SaramaMapper
+	options            ConsumerOptions
 	consumers          map[string]*SaramaConsumer
 	unready            chan bool
 }
@@ -25,6 +53,7 @@ func NewSaramaConsumers(
 	consumerProps *properties.KafkaConsumer,
 	mapper *SaramaMapper,
 	handlers []core.ConsumerHandler,
+	options ConsumerOptions,
 ) (*SaramaConsumers, error) {
 	if len(consumerProps.HandlerMappings) < 1 {
 		return nil, errors.New("[SaramaConsumers] Missing handler mapping")
@@ -40,6 +69,7 @@ func NewSaramaConsumers(
 		consumerProps:      &clientProps.Consumer,
 		kafkaConsumerProps: consumerProps,
 		mapper:             mapper,
+		options:            options,
 		consumers:          make(map[string]*SaramaConsumer),
 		unready:            make(chan bool),
 	}
@@ -63,7 +93,8 @@ func (s *SaramaConsumers) init(handlerMap map[string]core.ConsumerHandler) error
 			log.Debugf("Kafka consumer key [%s] is not exists in handler list", key)
 			continue
 		}
-		saramaConsumer, err := NewSaramaConsumer(s.mapper, s.clientProps, &config, handler)
+		config := config
+		saramaConsumer, err := NewSaramaConsumer(s.mapper, s.clientProps, &config, handler, s.options)
 		if err != nil {
 			return err
 		}
@@ -101,3 +132,20 @@ func (s *SaramaConsumers) Stop() {
 	}
 	wg.Wait()
 }
+
+func (s *SaramaConsumers) Seek(handlerName string, position core.SeekPosition) error {
+	consumer, exists := s.consumers[strings.ToLower(strings.TrimSpace(handlerName))]
+	if !exists {
+		return fmt.Errorf("[SaramaConsumers] Consumer [%s] is not found", handlerName)
+	}
+	return consumer.Seek(position)
+}
+
+// Stats returns the statistics of consumers by their handler name
+func (s *SaramaConsumers) Stats() map[string]ConsumerStats {
+	stats := make(map[string]ConsumerStats, len(s.consumers))
+	for _, consumer := range s.consumers {
+		stats[consumer.name] = consumer.Stats()
+	}
+	return stats
+}
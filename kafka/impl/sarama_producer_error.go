@@ -0,0 +1,38 @@
+package impl
+
+import (
+	"github.com/Shopify/sarama"
+	"github.com/pkg/errors"
+	"io"
+	"net"
+	"syscall"
+)
+
+// IsRetriableProducerError reports whether a produce error is caused by
+// an unavailable cluster, so producing the message again later may succeed.
+func IsRetriableProducerError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var kErr sarama.KError
+	if errors.As(err, &kErr) {
+		switch kErr {
+		case sarama.ErrLeaderNotAvailable,
+			sarama.ErrNotLeaderForPartition,
+			sarama.ErrRequestTimedOut,
+			sarama.ErrBrokerNotAvailable,
+			sarama.ErrNetworkException,
+			sarama.ErrNotEnoughReplicas,
+			sarama.ErrNotEnoughReplicasAfterAppend,
+			sarama.ErrNotController:
+			return true
+		}
+		return false
+	}
+	if errors.Is(err, sarama.ErrOutOfBrokers) || errors.Is(err, sarama.ErrNotConnected) ||
+		errors.Is(err, io.EOF) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
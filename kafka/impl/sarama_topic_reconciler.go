@@ -0,0 +1,171 @@
+package impl
+
+import (
+	"fmt"
+	"github.com/Shopify/sarama"
+	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"github.com/golibs-starter/golib/log"
+	"github.com/pkg/errors"
+)
+
+func (s SaramaAdmin) PlanTopics(configurations []core.TopicConfiguration) (*core.TopicPlan, error) {
+	plan := &core.TopicPlan{}
+	if len(configurations) == 0 {
+		return plan, nil
+	}
+	topicDetails, err := s.buildTopicDetails(configurations)
+	if err != nil {
+		return nil, errors.WithMessage(err, "plan topics failed")
+	}
+	err = s.withClusterAdmin(func(admin sarama.ClusterAdmin) error {
+		topics := make([]string, 0, len(configurations))
+		for _, configuration := range configurations {
+			topics = append(topics, configuration.Name)
+		}
+		metadata, err := admin.DescribeTopics(topics)
+		if err != nil {
+			return errors.WithMessage(err, "describe topics failed")
+		}
+		existingTopics := make(map[string]*sarama.TopicMetadata, len(metadata))
+		for _, topicMetadata := range metadata {
+			if errors.Is(topicMetadata.Err, sarama.ErrUnknownTopicOrPartition) {
+				continue
+			}
+			if !errors.Is(topicMetadata.Err, sarama.ErrNoError) {
+				return errors.WithMessagef(topicMetadata.Err, "describe topic [%s] failed", topicMetadata.Name)
+			}
+			existingTopics[topicMetadata.Name] = topicMetadata
+		}
+		for _, configuration := range configurations {
+			existingTopic, exists := existingTopics[configuration.Name]
+			if !exists {
+				plan.Create = append(plan.Create, configuration)
+				continue
+			}
+			s.planPartitions(plan, configuration, existingTopic)
+			if err := s.planConfigs(plan, admin, configuration.Name, topicDetails[configuration.Name]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.WithMessage(err, "plan topics failed")
+	}
+	return plan, nil
+}
+
+// planPartitions compares the partitions and the replication factor when they are set in the configuration
+func (s SaramaAdmin) planPartitions(plan *core.TopicPlan, configuration core.TopicConfiguration,
+	existingTopic *sarama.TopicMetadata) {
+	partitions := int32(len(existingTopic.Partitions))
+	if configuration.Partitions > partitions {
+		plan.IncreasePartitions = append(plan.IncreasePartitions, core.TopicPartitionsChange{
+			Topic: configuration.Name,
+			From:  partitions,
+			To:    configuration.Partitions,
+		})
+	} else if configuration.Partitions > 0 && configuration.Partitions < partitions {
+		plan.Irreconcilable = append(plan.Irreconcilable, core.TopicDifference{
+			Topic:  configuration.Name,
+			Reason: fmt.Sprintf("partitions cannot be reduced from [%d] to [%d]", partitions, configuration.Partitions),
+		})
+	}
+	if len(existingTopic.Partitions) > 0 && configuration.ReplicaFactor > 0 {
+		replicaFactor := int16(len(existingTopic.Partitions[0].Replicas))
+		if configuration.ReplicaFactor != replicaFactor {
+			plan.Irreconcilable = append(plan.Irreconcilable, core.TopicDifference{
+				Topic: configuration.Name,
+				Reason: fmt.Sprintf("replication factor cannot be changed from [%d] to [%d]",
+					replicaFactor, configuration.ReplicaFactor),
+			})
+		}
+	}
+}
+
+func (s SaramaAdmin) planConfigs(plan *core.TopicPlan, admin sarama.ClusterAdmin, topic string,
+	detail *sarama.TopicDetail) error {
+	if len(detail.ConfigEntries) == 0 {
+		return nil
+	}
+	entries, err := admin.DescribeConfig(sarama.ConfigResource{Type: sarama.TopicResource, Name: topic})
+	if err != nil {
+		return errors.WithMessagef(err, "describe configs of topic [%s] failed", topic)
+	}
+	actualConfigs := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		actualConfigs[entry.Name] = entry.Value
+	}
+	changes := make(map[string]core.TopicConfigChange)
+	for name, value := range detail.ConfigEntries {
+		if value != nil && actualConfigs[name] != *value {
+			changes[name] = core.TopicConfigChange{From: actualConfigs[name], To: *value}
+		}
+	}
+	if len(changes) > 0 {
+		plan.AlterConfigs = append(plan.AlterConfigs, core.TopicConfigsChange{Topic: topic, Configs: changes})
+	}
+	return nil
+}
+
+func (s SaramaAdmin) ApplyTopicPlan(plan *core.TopicPlan, forcePartitions bool) error {
+	if len(plan.Create) > 0 {
+		if err := s.CreateTopics(plan.Create); err != nil {
+			return err
+		}
+	}
+	topicErrors := make(map[string]error)
+	for _, change := range plan.IncreasePartitions {
+		if err := s.CreatePartitions(change.Topic, change.To, forcePartitions); err != nil {
+			topicErrors[change.Topic] = errors.WithMessage(err, "increase partitions failed")
+		}
+	}
+	if len(plan.AlterConfigs) == 0 {
+		return newAdminError("apply topic plan failed", topicErrors)
+	}
+	return s.withClusterAdmin(func(admin sarama.ClusterAdmin) error {
+		for _, change := range plan.AlterConfigs {
+			configs := make(map[string]string, len(change.Configs))
+			for name, config := range change.Configs {
+				configs[name] = config.To
+			}
+			if err := s.alterTopicConfigs(admin, change.Topic, configs); err != nil {
+				topicErrors[change.Topic] = err
+				continue
+			}
+			log.Infof("Configs of Kafka topic [%s] have been altered", change.Topic)
+		}
+		return newAdminError("apply topic plan failed", topicErrors)
+	})
+}
+
+// alterTopicConfigs sets the changed configs, other dynamic configs of the topic are kept
+// because AlterConfigs resets the configs that are not provided.
+func (s SaramaAdmin) alterTopicConfigs(admin sarama.ClusterAdmin, topic string, changedConfigs map[string]string) error {
+	entries, err := admin.DescribeConfig(sarama.ConfigResource{Type: sarama.TopicResource, Name: topic})
+	if err != nil {
+		return errors.WithMessage(err, "describe configs failed")
+	}
+	configs := make(map[string]*string)
+	for _, entry := range entries {
+		if isDynamicTopicConfig(entry) {
+			value := entry.Value
+			configs[entry.Name] = &value
+		}
+	}
+	for name, value := range changedConfigs {
+		value := value
+		configs[name] = &value
+	}
+	if err := admin.AlterConfig(sarama.TopicResource, topic, configs, false); err != nil {
+		return errors.WithMessage(err, "alter configs failed")
+	}
+	return nil
+}
+
+func isDynamicTopicConfig(entry sarama.ConfigEntry) bool {
+	if entry.Default || entry.ReadOnly || entry.Sensitive {
+		return false
+	}
+	return entry.Source == sarama.SourceTopic || entry.Source == sarama.SourceUnknown
+}
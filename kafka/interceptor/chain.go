@@ -0,0 +1,44 @@
+package interceptor
+
+import (
+	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"sort"
+)
+
+// SortProducerInterceptors returns the interceptors sorted by their order
+func SortProducerInterceptors(interceptors []core.ProducerInterceptor) []core.ProducerInterceptor {
+	sorted := append(make([]core.ProducerInterceptor, 0, len(interceptors)), interceptors...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Order() < sorted[j].Order()
+	})
+	return sorted
+}
+
+// ChainProducerInterceptors returns a send function calling the interceptors in order before send
+func ChainProducerInterceptors(interceptors []core.ProducerInterceptor, send func(m *core.Message) error) func(m *core.Message) error {
+	sorted := SortProducerInterceptors(interceptors)
+	chain := send
+	for i := len(sorted) - 1; i >= 0; i-- {
+		current, next := sorted[i], chain
+		chain = func(m *core.Message) error {
+			return current.Intercept(m, next)
+		}
+	}
+	return chain
+}
+
+// ChainConsumerMiddlewares returns a handler function calling the middlewares in order before the handler
+func ChainConsumerMiddlewares(handler core.ConsumerHandler, middlewares []core.ConsumerMiddleware) func(msg *core.ConsumerMessage) {
+	sorted := append(make([]core.ConsumerMiddleware, 0, len(middlewares)), middlewares...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Order() < sorted[j].Order()
+	})
+	chain := handler.HandlerFunc
+	for i := len(sorted) - 1; i >= 0; i-- {
+		current, next := sorted[i], chain
+		chain = func(msg *core.ConsumerMessage) {
+			current.Handle(handler, msg, next)
+		}
+	}
+	return chain
+}
@@ -0,0 +1,157 @@
+package interceptor
+
+import (
+	"errors"
+	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	assert "github.com/stretchr/testify/require"
+	"testing"
+)
+
+type testProducer struct {
+	messages []*core.Message
+}
+
+func (t *testProducer) Send(m *core.Message) (partition int32, offset int64, err error) {
+	t.messages = append(t.messages, m)
+	return 1, int64(len(t.messages)), nil
+}
+
+func (t *testProducer) Close() error {
+	return nil
+}
+
+type testInterceptor struct {
+	order int
+	calls *[]int
+	err   error
+}
+
+func (t testInterceptor) Order() int {
+	return t.order
+}
+
+func (t testInterceptor) Intercept(m *core.Message, next func(m *core.Message) error) error {
+	*t.calls = append(*t.calls, t.order)
+	if t.err != nil {
+		return t.err
+	}
+	m.Headers = append(m.Headers, core.MessageHeader{Key: []byte("x-intercepted"), Value: []byte("true")})
+	return next(m)
+}
+
+type testHandler struct {
+	messages []*core.ConsumerMessage
+}
+
+func (t *testHandler) HandlerFunc(msg *core.ConsumerMessage) {
+	t.messages = append(t.messages, msg)
+}
+
+func (t *testHandler) Close() {
+}
+
+type testMiddleware struct {
+	order int
+	calls *[]int
+	skip  bool
+}
+
+func (t testMiddleware) Order() int {
+	return t.order
+}
+
+func (t testMiddleware) Handle(_ core.ConsumerHandler, msg *core.ConsumerMessage, next func(msg *core.ConsumerMessage)) {
+	*t.calls = append(*t.calls, t.order)
+	if !t.skip {
+		next(msg)
+	}
+}
+
+func TestSyncProducer_WhenInterceptorsAreProvided_ShouldCallThemInOrder(t *testing.T) {
+	var calls []int
+	delegate := &testProducer{}
+	producer := NewSyncProducer(delegate, []core.ProducerInterceptor{
+		testInterceptor{order: 2, calls: &calls},
+		testInterceptor{order: 1, calls: &calls},
+	})
+	partition, offset, err := producer.Send(&core.Message{Topic: "test.topic"})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), partition)
+	assert.Equal(t, int64(1), offset)
+	assert.Equal(t, []int{1, 2}, calls)
+	assert.Len(t, delegate.messages, 1)
+	assert.Len(t, delegate.messages[0].Headers, 2)
+}
+
+func TestSyncProducer_WhenInterceptorRejects_ShouldNotSend(t *testing.T) {
+	var calls []int
+	delegate := &testProducer{}
+	producer := NewSyncProducer(delegate, []core.ProducerInterceptor{
+		testInterceptor{order: 1, calls: &calls, err: errors.New("rejected")},
+		testInterceptor{order: 2, calls: &calls},
+	})
+	_, _, err := producer.Send(&core.Message{Topic: "test.topic"})
+	assert.EqualError(t, err, "rejected")
+	assert.Equal(t, []int{1}, calls)
+	assert.Empty(t, delegate.messages)
+}
+
+type testAsyncProducer struct {
+	errorsCh chan *core.ProducerError
+	messages []*core.Message
+}
+
+func (t *testAsyncProducer) Send(m *core.Message) {
+	t.messages = append(t.messages, m)
+}
+
+func (t *testAsyncProducer) Successes() <-chan *core.Message {
+	return nil
+}
+
+func (t *testAsyncProducer) Errors() <-chan *core.ProducerError {
+	return t.errorsCh
+}
+
+func (t *testAsyncProducer) Close() error {
+	close(t.errorsCh)
+	return nil
+}
+
+func TestAsyncProducer_WhenInterceptorRejectsAfterClose_ShouldNotReport(t *testing.T) {
+	var calls []int
+	delegate := &testAsyncProducer{errorsCh: make(chan *core.ProducerError)}
+	producer := NewAsyncProducer(delegate, []core.ProducerInterceptor{
+		testInterceptor{order: 1, calls: &calls, err: errors.New("rejected")},
+	})
+	producer.Send(&core.Message{Topic: "test.topic"})
+	producerErr := <-producer.Errors()
+	assert.EqualError(t, producerErr.Err, "rejected")
+
+	assert.NoError(t, producer.Close())
+	producer.Send(&core.Message{Topic: "test.topic"})
+	_, open := <-producer.Errors()
+	assert.False(t, open)
+	assert.Empty(t, delegate.messages)
+}
+
+func TestChainConsumerMiddlewares_WhenMiddlewareSkips_ShouldNotCallHandler(t *testing.T) {
+	var calls []int
+	handler := &testHandler{}
+	handle := ChainConsumerMiddlewares(handler, []core.ConsumerMiddleware{
+		testMiddleware{order: 2, calls: &calls},
+		testMiddleware{order: 1, calls: &calls},
+	})
+	handle(&core.ConsumerMessage{})
+	assert.Equal(t, []int{1, 2}, calls)
+	assert.Len(t, handler.messages, 1)
+
+	calls = nil
+	handle = ChainConsumerMiddlewares(handler, []core.ConsumerMiddleware{
+		testMiddleware{order: 1, calls: &calls, skip: true},
+		testMiddleware{order: 2, calls: &calls},
+	})
+	handle(&core.ConsumerMessage{})
+	assert.Equal(t, []int{1}, calls)
+	assert.Len(t, handler.messages, 1)
+}
@@ -0,0 +1,96 @@
+package interceptor
+
+import (
+	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"github.com/golibs-starter/golib-message-bus/kafka/internal/producererrors"
+	"github.com/golibs-starter/golib/log"
+)
+
+// SyncProducer is a core.SyncProducer calling the interceptors around each send
+type SyncProducer struct {
+	producer core.SyncProducer
+	send     syncSend
+}
+
+// syncSend sends the message, the partition and offset it's written to are recorded in the result
+type syncSend func(m *core.Message, result *syncResult) error
+
+type syncResult struct {
+	partition int32
+	offset    int64
+}
+
+func NewSyncProducer(producer core.SyncProducer, interceptors []core.ProducerInterceptor) *SyncProducer {
+	return &SyncProducer{
+		producer: producer,
+		send: chainSyncSend(SortProducerInterceptors(interceptors), func(m *core.Message, result *syncResult) error {
+			var err error
+			result.partition, result.offset, err = producer.Send(m)
+			return err
+		}),
+	}
+}
+
+// chainSyncSend is ChainProducerInterceptors for sync sends, the result is passed along the chain
+func chainSyncSend(sorted []core.ProducerInterceptor, send syncSend) syncSend {
+	chain := send
+	for i := len(sorted) - 1; i >= 0; i-- {
+		current, next := sorted[i], chain
+		chain = func(m *core.Message, result *syncResult) error {
+			return current.Intercept(m, func(m *core.Message) error {
+				return next(m, result)
+			})
+		}
+	}
+	return chain
+}
+
+func (s *SyncProducer) Send(m *core.Message) (partition int32, offset int64, err error) {
+	result := &syncResult{partition: -1, offset: -1}
+	err = s.send(m, result)
+	return result.partition, result.offset, err
+}
+
+func (s *SyncProducer) Close() error {
+	return s.producer.Close()
+}
+
+// AsyncProducer is a core.AsyncProducer calling the interceptors around each send,
+// rejected messages are reported to the Errors channel.
+type AsyncProducer struct {
+	producer core.AsyncProducer
+	send     func(m *core.Message) error
+	errors   *producererrors.Reporter
+}
+
+func NewAsyncProducer(producer core.AsyncProducer, interceptors []core.ProducerInterceptor) *AsyncProducer {
+	return &AsyncProducer{
+		producer: producer,
+		send: ChainProducerInterceptors(interceptors, func(m *core.Message) error {
+			producer.Send(m)
+			return nil
+		}),
+		errors: producererrors.NewReporter(producer.Errors()),
+	}
+}
+
+func (a *AsyncProducer) Send(m *core.Message) {
+	if err := a.send(m); err != nil {
+		if !a.errors.Report(&core.ProducerError{Msg: m, Err: err}) {
+			log.WithErrors(err).Errorf("Kafka message is rejected by an interceptor after the producer is closed")
+		}
+	}
+}
+
+func (a *AsyncProducer) Successes() <-chan *core.Message {
+	return a.producer.Successes()
+}
+
+func (a *AsyncProducer) Errors() <-chan *core.ProducerError {
+	return a.errors.Errors()
+}
+
+func (a *AsyncProducer) Close() error {
+	a.errors.Close()
+	return a.producer.Close()
+}
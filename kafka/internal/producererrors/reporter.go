@@ -0,0 +1,57 @@
+// Package producererrors merges the errors of a decorated async producer with the errors of its decorator.
+package producererrors
+
+import (
+	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"sync"
+)
+
+// Reporter forwards the errors of the delegate producer and the errors reported by the decorator
+// to a single channel, it's closed once the delegate errors are drained and the reports are delivered.
+type Reporter struct {
+	errorsCh chan *core.ProducerError
+	mu       sync.RWMutex
+	closed   bool
+	reports  sync.WaitGroup
+}
+
+func NewReporter(delegateErrors <-chan *core.ProducerError) *Reporter {
+	r := &Reporter{errorsCh: make(chan *core.ProducerError)}
+	go func() {
+		defer close(r.errorsCh)
+		for e := range delegateErrors {
+			r.errorsCh <- e
+		}
+		// The delegate errors are closed after Close, so no report is added anymore
+		r.reports.Wait()
+	}()
+	return r
+}
+
+// Report delivers the error in background, so the caller doesn't block until Errors is read.
+// Returns false when the reporter is closed, the error is not delivered then.
+func (r *Reporter) Report(e *core.ProducerError) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.closed {
+		return false
+	}
+	r.reports.Add(1)
+	go func() {
+		defer r.reports.Done()
+		r.errorsCh <- e
+	}()
+	return true
+}
+
+// Errors returns the merged errors
+func (r *Reporter) Errors() <-chan *core.ProducerError {
+	return r.errorsCh
+}
+
+// Close stops accepting reports, it must be called before closing the delegate producer
+func (r *Reporter) Close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+}
@@ -0,0 +1,39 @@
+package producererrors
+
+import (
+	"errors"
+	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	assert "github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestReporter_WhenReportRacesWithClose_ShouldDeliverOrDropWithoutPanic(t *testing.T) {
+	delegateErrors := make(chan *core.ProducerError)
+	reporter := NewReporter(delegateErrors)
+	delegateErr := &core.ProducerError{Err: errors.New("delegate")}
+	go func() {
+		delegateErrors <- delegateErr
+	}()
+	assert.Equal(t, delegateErr, <-reporter.Errors())
+
+	reported := make(chan bool)
+	for i := 0; i < 10; i++ {
+		go func() {
+			reported <- reporter.Report(&core.ProducerError{Err: errors.New("rejected")})
+		}()
+	}
+	reporter.Close()
+	close(delegateErrors)
+	delivered := 0
+	for range reporter.Errors() {
+		delivered++
+	}
+	accepted := 0
+	for i := 0; i < 10; i++ {
+		if <-reported {
+			accepted++
+		}
+	}
+	assert.Equal(t, accepted, delivered)
+	assert.False(t, reporter.Report(&core.ProducerError{Err: errors.New("rejected")}))
+}
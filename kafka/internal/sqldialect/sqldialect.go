@@ -0,0 +1,33 @@
+// Package sqldialect holds the SQL dialects supported by the database/sql backed stores.
+package sqldialect
+
+import (
+	"strconv"
+	"strings"
+)
+
+const Mysql = "mysql"
+const Postgres = "postgres"
+
+// Supported reports whether the dialect is supported
+func Supported(dialect string) bool {
+	return dialect == Mysql || dialect == Postgres
+}
+
+// Bind replaces the ? placeholders of the query by the dialect specific ones
+func Bind(dialect string, query string) string {
+	if dialect != Postgres {
+		return query
+	}
+	var builder strings.Builder
+	n := 0
+	for _, c := range query {
+		if c == '?' {
+			n++
+			builder.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		builder.WriteRune(c)
+	}
+	return builder.String()
+}
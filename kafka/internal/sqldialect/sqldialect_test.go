@@ -0,0 +1,12 @@
+package sqldialect
+
+import (
+	assert "github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestBind_WhenPostgres_ShouldNumberPlaceholders(t *testing.T) {
+	query := "SELECT id FROM t WHERE a = ? AND b IN (?, ?)"
+	assert.Equal(t, "SELECT id FROM t WHERE a = $1 AND b IN ($2, $3)", Bind(Postgres, query))
+	assert.Equal(t, query, Bind(Mysql, query))
+}
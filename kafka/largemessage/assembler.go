@@ -0,0 +1,177 @@
+package largemessage
+
+import (
+	"fmt"
+	"github.com/golibs-starter/golib-message-bus/kafka/constant"
+	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"github.com/golibs-starter/golib-message-bus/kafka/properties"
+	"github.com/golibs-starter/golib/log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Assembler reassembles the chunks of messages consumed from a single partition,
+// it's not safe for concurrent use.
+// Messages consumed while chunks are missing are held until the chunks are reassembled or dropped,
+// so the offsets marked after handling them never skip a missing chunk
+// and no message is handled again after a restart.
+type Assembler struct {
+	props    *properties.LargeMessage
+	groups   map[string]*chunkGroup
+	rejected map[string]time.Time
+	held     []*core.ConsumerMessage
+}
+
+type chunkGroup struct {
+	chunks    [][]byte
+	received  int
+	size      int
+	startedAt time.Time
+}
+
+func NewAssembler(props *properties.LargeMessage) *Assembler {
+	return &Assembler{
+		props:    props,
+		groups:   make(map[string]*chunkGroup),
+		rejected: make(map[string]time.Time),
+	}
+}
+
+// Add returns the messages ready to be handled in their offset order, or nothing while some chunks are missing.
+// A reassembled message has the offset of its last chunk. When the message is rejected, an error is returned
+// together with the messages which became ready.
+func (a *Assembler) Add(msg *core.ConsumerMessage) ([]*core.ConsumerMessage, error) {
+	a.expire(msg.Timestamp)
+	ready, err := a.add(msg)
+	if ready != nil {
+		a.held = append(a.held, ready)
+	}
+	if len(a.groups) > 0 && len(a.held) > a.props.MaxHeldMessages {
+		a.drop(fmt.Sprintf("more than %d messages are held", a.props.MaxHeldMessages))
+	}
+	if len(a.groups) > 0 {
+		return nil, err
+	}
+	messages := a.held
+	a.held = nil
+	return messages, err
+}
+
+func (a *Assembler) add(msg *core.ConsumerMessage) (*core.ConsumerMessage, error) {
+	chunkId, isChunk := headerValue(msg.Headers, constant.HeaderChunkId)
+	if !isChunk {
+		return msg, nil
+	}
+	if _, rejected := a.rejected[chunkId]; rejected {
+		return nil, fmt.Errorf("chunk of rejected message [%s] is dropped", chunkId)
+	}
+	index, count, err := chunkPosition(msg)
+	if err != nil {
+		return nil, err
+	}
+	if count > a.props.MaxChunkCount {
+		a.rejected[chunkId] = msg.Timestamp
+		return nil, fmt.Errorf("chunk count [%d] of message [%s] exceeds the max chunk count [%d]",
+			count, chunkId, a.props.MaxChunkCount)
+	}
+	group, exists := a.groups[chunkId]
+	if !exists {
+		group = &chunkGroup{chunks: make([][]byte, count), startedAt: msg.Timestamp}
+		a.groups[chunkId] = group
+	}
+	if index >= len(group.chunks) {
+		return nil, fmt.Errorf("chunk index [%d] of message [%s] is out of range", index, chunkId)
+	}
+	if group.chunks[index] == nil {
+		// Redelivered chunks are ignored
+		if group.size+len(msg.Value) > a.props.MaxAssembledBytes {
+			delete(a.groups, chunkId)
+			a.rejected[chunkId] = group.startedAt
+			return nil, fmt.Errorf("message [%s] exceeds the max assembled bytes [%d]",
+				chunkId, a.props.MaxAssembledBytes)
+		}
+		group.chunks[index] = msg.Value
+		group.received++
+		group.size += len(msg.Value)
+	}
+	if group.received < len(group.chunks) {
+		return nil, nil
+	}
+	delete(a.groups, chunkId)
+	value := make([]byte, 0, group.size)
+	for _, chunk := range group.chunks {
+		value = append(value, chunk...)
+	}
+	assembled := *msg
+	assembled.Value = value
+	assembled.Headers = make([]core.MessageHeader, 0, len(msg.Headers))
+	for _, header := range msg.Headers {
+		switch strings.ToLower(string(header.Key)) {
+		case constant.HeaderChunkId, constant.HeaderChunkIndex, constant.HeaderChunkCount:
+		case constant.HeaderChunkKeyless:
+			assembled.Key = nil
+		default:
+			assembled.Headers = append(assembled.Headers, header)
+		}
+	}
+	return &assembled, nil
+}
+
+// Pending reports whether some messages are not completely reassembled
+func (a *Assembler) Pending() bool {
+	return len(a.groups) > 0
+}
+
+// expire drops messages whose chunks are still missing after the timeout,
+// eg: when the producer crashed while sending them.
+func (a *Assembler) expire(now time.Time) {
+	for chunkId, group := range a.groups {
+		if now.Sub(group.startedAt) > a.props.ChunkTimeout {
+			log.Warnf("Large message [%s] is dropped, only %d/%d chunks are received after %s",
+				chunkId, group.received, len(group.chunks), a.props.ChunkTimeout)
+			delete(a.groups, chunkId)
+		}
+	}
+	for chunkId, rejectedAt := range a.rejected {
+		if now.Sub(rejectedAt) > a.props.ChunkTimeout {
+			delete(a.rejected, chunkId)
+		}
+	}
+}
+
+// drop gives up the messages whose chunks are still missing
+func (a *Assembler) drop(reason string) {
+	for chunkId, group := range a.groups {
+		log.Warnf("Large message [%s] is dropped, only %d/%d chunks are received and %s",
+			chunkId, group.received, len(group.chunks), reason)
+		delete(a.groups, chunkId)
+		a.rejected[chunkId] = group.startedAt
+	}
+}
+
+func chunkPosition(msg *core.ConsumerMessage) (int, int, error) {
+	indexValue, _ := headerValue(msg.Headers, constant.HeaderChunkIndex)
+	countValue, _ := headerValue(msg.Headers, constant.HeaderChunkCount)
+	index, err := strconv.Atoi(indexValue)
+	if err != nil {
+		return 0, 0, fmt.Errorf("chunk index [%s] is invalid", indexValue)
+	}
+	count, err := strconv.Atoi(countValue)
+	if err != nil || count <= 0 {
+		return 0, 0, fmt.Errorf("chunk count [%s] is invalid", countValue)
+	}
+	if index < 0 {
+		return 0, 0, fmt.Errorf("chunk index [%d] is invalid", index)
+	}
+	return index, count, nil
+}
+
+func headerValue(headers []core.MessageHeader, key string) (string, bool) {
+	for _, header := range headers {
+		if strings.EqualFold(string(header.Key), key) {
+			return string(header.Value), true
+		}
+	}
+	return "", false
+}
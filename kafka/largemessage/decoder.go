@@ -0,0 +1,52 @@
+package largemessage
+
+import (
+	"context"
+	"errors"
+	"github.com/golibs-starter/golib-message-bus/kafka/constant"
+	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"github.com/golibs-starter/golib-message-bus/kafka/properties"
+	pkgErrors "github.com/pkg/errors"
+	"strings"
+)
+
+// Decoder restores the large messages produced by the Encoder
+type Decoder struct {
+	props *properties.LargeMessage
+	store Store
+}
+
+// NewDecoder creates the decoder, the store is only required to consume claim-check messages.
+func NewDecoder(props *properties.LargeMessage, store Store) *Decoder {
+	return &Decoder{props: props, store: store}
+}
+
+// NewAssembler creates the chunk assembler of a partition
+func (d Decoder) NewAssembler() *Assembler {
+	return NewAssembler(d.props)
+}
+
+// Resolve replaces the value of a claim-check message by the value stored in the store,
+// other messages are left untouched.
+func (d Decoder) Resolve(ctx context.Context, msg *core.ConsumerMessage) error {
+	ref, exists := headerValue(msg.Headers, constant.HeaderClaimCheckRef)
+	if !exists {
+		return nil
+	}
+	if d.store == nil {
+		return errors.New("a large message store is required to consume claim-check messages")
+	}
+	value, err := d.store.Get(ctx, ref)
+	if err != nil {
+		return pkgErrors.WithMessagef(err, "get large message [%s] from the store failed", ref)
+	}
+	headers := make([]core.MessageHeader, 0, len(msg.Headers))
+	for _, header := range msg.Headers {
+		if !strings.EqualFold(string(header.Key), constant.HeaderClaimCheckRef) {
+			headers = append(headers, header)
+		}
+	}
+	msg.Value = value
+	msg.Headers = headers
+	return nil
+}
@@ -0,0 +1,90 @@
+package largemessage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/golibs-starter/golib-message-bus/kafka/constant"
+	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"github.com/golibs-starter/golib-message-bus/kafka/properties"
+	"github.com/google/uuid"
+	pkgErrors "github.com/pkg/errors"
+	"strconv"
+)
+
+// Encoder transforms messages larger than MaxMessageBytes into chunks or claim-check messages,
+// smaller messages are left untouched.
+type Encoder struct {
+	props *properties.LargeMessage
+	store Store
+}
+
+// NewEncoder creates the encoder, the store is only required by the claim-check mode.
+func NewEncoder(props *properties.LargeMessage, store Store) (*Encoder, error) {
+	if props.MaxMessageBytes <= 0 {
+		return nil, errors.New("max message bytes of large messages must be positive")
+	}
+	if props.Mode == constant.LargeMessageModeClaimCheck && store == nil {
+		return nil, errors.New("a large message store is required by the claim-check mode")
+	}
+	return &Encoder{props: props, store: store}, nil
+}
+
+func (e Encoder) Encode(m *core.Message) ([]*core.Message, error) {
+	if len(m.Value) <= e.props.MaxMessageBytes {
+		return []*core.Message{m}, nil
+	}
+	if e.props.Mode == constant.LargeMessageModeClaimCheck {
+		message, err := e.claimCheck(m)
+		if err != nil {
+			return nil, err
+		}
+		return []*core.Message{message}, nil
+	}
+	return e.chunk(m), nil
+}
+
+func (e Encoder) claimCheck(m *core.Message) (*core.Message, error) {
+	ref := fmt.Sprintf("%s/%s", m.Topic, uuid.New().String())
+	if err := e.store.Put(context.Background(), ref, m.Value); err != nil {
+		return nil, pkgErrors.WithMessagef(err, "put large message [%s] to the store failed", ref)
+	}
+	message := *m
+	message.Value = nil
+	message.Headers = append(copyHeaders(m.Headers),
+		core.MessageHeader{Key: []byte(constant.HeaderClaimCheckRef), Value: []byte(ref)})
+	return &message, nil
+}
+
+// chunk splits the value, all chunks have the same key so they land on the same partition
+func (e Encoder) chunk(m *core.Message) []*core.Message {
+	chunkId := uuid.New().String()
+	count := (len(m.Value) + e.props.MaxMessageBytes - 1) / e.props.MaxMessageBytes
+	key := m.Key
+	headers := copyHeaders(m.Headers)
+	if key == nil {
+		key = []byte(chunkId)
+		headers = append(headers, core.MessageHeader{Key: []byte(constant.HeaderChunkKeyless), Value: []byte("true")})
+	}
+	chunks := make([]*core.Message, 0, count)
+	for i := 0; i < count; i++ {
+		end := (i + 1) * e.props.MaxMessageBytes
+		if end > len(m.Value) {
+			end = len(m.Value)
+		}
+		chunk := *m
+		chunk.Key = key
+		chunk.Value = m.Value[i*e.props.MaxMessageBytes : end]
+		chunk.Headers = append(copyHeaders(headers),
+			core.MessageHeader{Key: []byte(constant.HeaderChunkId), Value: []byte(chunkId)},
+			core.MessageHeader{Key: []byte(constant.HeaderChunkIndex), Value: []byte(strconv.Itoa(i))},
+			core.MessageHeader{Key: []byte(constant.HeaderChunkCount), Value: []byte(strconv.Itoa(count))},
+		)
+		chunks = append(chunks, &chunk)
+	}
+	return chunks
+}
+
+func copyHeaders(headers []core.MessageHeader) []core.MessageHeader {
+	return append(make([]core.MessageHeader, 0, len(headers)+4), headers...)
+}
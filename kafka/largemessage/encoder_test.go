@@ -0,0 +1,237 @@
+package largemessage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/golibs-starter/golib-message-bus/kafka/constant"
+	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"github.com/golibs-starter/golib-message-bus/kafka/properties"
+	assert "github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func toConsumerMessage(m *core.Message, offset int64) *core.ConsumerMessage {
+	return &core.ConsumerMessage{Topic: m.Topic, Key: m.Key, Value: m.Value, Headers: m.Headers,
+		Offset: offset, Timestamp: time.Now()}
+}
+
+func newTestChunkProps() *properties.LargeMessage {
+	return &properties.LargeMessage{Mode: constant.LargeMessageModeChunk, MaxMessageBytes: 4, ChunkTimeout: time.Minute,
+		MaxChunkCount: 10, MaxAssembledBytes: 100, MaxHeldMessages: 10}
+}
+
+func encodeTestChunks(t *testing.T, props *properties.LargeMessage, value []byte) []*core.Message {
+	encoder, err := NewEncoder(props, nil)
+	assert.NoError(t, err)
+	chunks, err := encoder.Encode(&core.Message{Topic: "test.topic", Value: value})
+	assert.NoError(t, err)
+	return chunks
+}
+
+func TestEncoder_WhenChunkMode_ShouldReassembleChunks(t *testing.T) {
+	props := newTestChunkProps()
+	encoder, err := NewEncoder(props, nil)
+	assert.NoError(t, err)
+	value := []byte("0123456789")
+	chunks, err := encoder.Encode(&core.Message{Topic: "test.topic", Value: value,
+		Headers: []core.MessageHeader{{Key: []byte("h1"), Value: []byte("hv1")}}})
+	assert.NoError(t, err)
+	assert.Len(t, chunks, 3)
+	for _, chunk := range chunks {
+		assert.Equal(t, chunks[0].Key, chunk.Key)
+	}
+
+	assembler := NewDecoder(props, nil).NewAssembler()
+	messages, err := assembler.Add(&core.ConsumerMessage{Value: []byte("other"), Timestamp: time.Now()})
+	assert.NoError(t, err)
+	assert.Len(t, messages, 1)
+	assert.Equal(t, []byte("other"), messages[0].Value)
+
+	messages, err = assembler.Add(toConsumerMessage(chunks[0], 1))
+	assert.NoError(t, err)
+	assert.Empty(t, messages)
+	messages, err = assembler.Add(toConsumerMessage(chunks[2], 2))
+	assert.NoError(t, err)
+	assert.Empty(t, messages)
+	assert.True(t, assembler.Pending())
+
+	messages, err = assembler.Add(toConsumerMessage(chunks[1], 3))
+	assert.NoError(t, err)
+	assert.Len(t, messages, 1)
+	assert.False(t, assembler.Pending())
+	msg := messages[0]
+	assert.Equal(t, value, msg.Value)
+	assert.Nil(t, msg.Key)
+	assert.Equal(t, int64(3), msg.Offset)
+	assert.Equal(t, []core.MessageHeader{{Key: []byte("h1"), Value: []byte("hv1")}}, msg.Headers)
+}
+
+func TestAssembler_WhenMessagesAreInterleavedWithChunks_ShouldHoldThemUntilChunksAreReassembled(t *testing.T) {
+	props := newTestChunkProps()
+	chunks := encodeTestChunks(t, props, []byte("01234567"))
+	assembler := NewAssembler(props)
+
+	messages, err := assembler.Add(toConsumerMessage(chunks[0], 1))
+	assert.NoError(t, err)
+	assert.Empty(t, messages)
+	messages, err = assembler.Add(&core.ConsumerMessage{Value: []byte("other"), Offset: 2, Timestamp: time.Now()})
+	assert.NoError(t, err)
+	assert.Empty(t, messages)
+
+	messages, err = assembler.Add(toConsumerMessage(chunks[1], 3))
+	assert.NoError(t, err)
+	assert.Len(t, messages, 2)
+	assert.Equal(t, []byte("other"), messages[0].Value)
+	assert.Equal(t, []byte("01234567"), messages[1].Value)
+	assert.Equal(t, int64(3), messages[1].Offset)
+}
+
+func TestAssembler_WhenTooManyMessagesAreHeld_ShouldDropPendingChunks(t *testing.T) {
+	props := newTestChunkProps()
+	props.MaxHeldMessages = 1
+	chunks := encodeTestChunks(t, props, []byte("01234567"))
+	assembler := NewAssembler(props)
+
+	_, err := assembler.Add(toConsumerMessage(chunks[0], 1))
+	assert.NoError(t, err)
+	messages, err := assembler.Add(&core.ConsumerMessage{Value: []byte("other1"), Offset: 2, Timestamp: time.Now()})
+	assert.NoError(t, err)
+	assert.Empty(t, messages)
+	messages, err = assembler.Add(&core.ConsumerMessage{Value: []byte("other2"), Offset: 3, Timestamp: time.Now()})
+	assert.NoError(t, err)
+	assert.Len(t, messages, 2)
+	assert.False(t, assembler.Pending())
+
+	messages, err = assembler.Add(toConsumerMessage(chunks[1], 4))
+	assert.Error(t, err)
+	assert.Empty(t, messages)
+	assert.False(t, assembler.Pending())
+}
+
+func TestAssembler_WhenChunksExceedTheLimits_ShouldRejectMessage(t *testing.T) {
+	props := newTestChunkProps()
+	props.MaxChunkCount = 2
+	chunks := encodeTestChunks(t, props, []byte("0123456789"))
+	assembler := NewAssembler(props)
+	_, err := assembler.Add(toConsumerMessage(chunks[0], 1))
+	assert.Error(t, err)
+	assert.False(t, assembler.Pending())
+
+	props = newTestChunkProps()
+	props.MaxAssembledBytes = 6
+	chunks = encodeTestChunks(t, props, []byte("0123456789"))
+	assembler = NewAssembler(props)
+	_, err = assembler.Add(toConsumerMessage(chunks[0], 1))
+	assert.NoError(t, err)
+	_, err = assembler.Add(toConsumerMessage(chunks[1], 2))
+	assert.Error(t, err)
+	assert.False(t, assembler.Pending())
+	_, err = assembler.Add(toConsumerMessage(chunks[2], 3))
+	assert.Error(t, err)
+	assert.False(t, assembler.Pending())
+}
+
+func TestEncoder_WhenClaimCheckMode_ShouldResolveValueFromStore(t *testing.T) {
+	props := &properties.LargeMessage{Mode: constant.LargeMessageModeClaimCheck, MaxMessageBytes: 4,
+		StoreDir: t.TempDir()}
+	store, err := NewFileStore(props)
+	assert.NoError(t, err)
+	encoder, err := NewEncoder(props, store)
+	assert.NoError(t, err)
+
+	value := bytes.Repeat([]byte("a"), 10)
+	messages, err := encoder.Encode(&core.Message{Topic: "test.topic", Key: []byte("k1"), Value: value})
+	assert.NoError(t, err)
+	assert.Len(t, messages, 1)
+	assert.Empty(t, messages[0].Value)
+
+	msg := toConsumerMessage(messages[0], 1)
+	assert.NoError(t, NewDecoder(props, store).Resolve(context.Background(), msg))
+	assert.Equal(t, value, msg.Value)
+	assert.Equal(t, []byte("k1"), msg.Key)
+	assert.Empty(t, msg.Headers)
+}
+
+func TestEncoder_WhenMessageIsSmall_ShouldNotEncode(t *testing.T) {
+	props := &properties.LargeMessage{Mode: constant.LargeMessageModeChunk, MaxMessageBytes: 10}
+	encoder, err := NewEncoder(props, nil)
+	assert.NoError(t, err)
+	m := &core.Message{Value: []byte("small")}
+	messages, err := encoder.Encode(m)
+	assert.NoError(t, err)
+	assert.Equal(t, []*core.Message{m}, messages)
+}
+
+func TestFileStore_WhenReferenceEscapesDir_ShouldReturnError(t *testing.T) {
+	store, err := NewFileStore(&properties.LargeMessage{StoreDir: t.TempDir()})
+	assert.NoError(t, err)
+	_, err = store.Get(context.Background(), "../secret")
+	assert.Error(t, err)
+}
+
+func TestFileStore_WhenPurge_ShouldDeleteValuesPutBeforeTime(t *testing.T) {
+	store, err := NewFileStore(&properties.LargeMessage{StoreDir: t.TempDir()})
+	assert.NoError(t, err)
+	assert.NoError(t, store.Put(context.Background(), "test.topic/old", []byte("old")))
+	assert.NoError(t, store.Put(context.Background(), "test.topic/new", []byte("new")))
+	oldTime := time.Now().Add(-time.Hour)
+	assert.NoError(t, os.Chtimes(filepath.Join(store.dir, "test.topic", "old"), oldTime, oldTime))
+
+	deleted, err := store.Purge(context.Background(), time.Now().Add(-time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+	_, err = store.Get(context.Background(), "test.topic/old")
+	assert.True(t, os.IsNotExist(err))
+	value, err := store.Get(context.Background(), "test.topic/new")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("new"), value)
+}
+
+type testFailingStore struct{}
+
+func (testFailingStore) Put(context.Context, string, []byte) error {
+	return errors.New("store is down")
+}
+
+func (testFailingStore) Get(context.Context, string) ([]byte, error) {
+	return nil, errors.New("store is down")
+}
+
+type testAsyncProducer struct {
+	errorsCh chan *core.ProducerError
+}
+
+func (p *testAsyncProducer) Send(*core.Message) {}
+
+func (p *testAsyncProducer) Successes() <-chan *core.Message {
+	return nil
+}
+
+func (p *testAsyncProducer) Errors() <-chan *core.ProducerError {
+	return p.errorsCh
+}
+
+func (p *testAsyncProducer) Close() error {
+	close(p.errorsCh)
+	return nil
+}
+
+func TestAsyncProducer_WhenEncodingFailsAfterClose_ShouldNotReport(t *testing.T) {
+	props := &properties.LargeMessage{Mode: constant.LargeMessageModeClaimCheck, MaxMessageBytes: 4}
+	encoder, err := NewEncoder(props, testFailingStore{})
+	assert.NoError(t, err)
+	producer := NewAsyncProducer(&testAsyncProducer{errorsCh: make(chan *core.ProducerError)}, encoder)
+
+	producer.Send(&core.Message{Topic: "test.topic", Value: []byte("0123456789")})
+	producerErr := <-producer.Errors()
+	assert.ErrorContains(t, producerErr.Err, "store is down")
+
+	assert.NoError(t, producer.Close())
+	producer.Send(&core.Message{Topic: "test.topic", Value: []byte("0123456789")})
+	_, open := <-producer.Errors()
+	assert.False(t, open)
+}
@@ -0,0 +1,95 @@
+package largemessage
+
+import (
+	"context"
+	"fmt"
+	"github.com/golibs-starter/golib-message-bus/kafka/properties"
+	"github.com/pkg/errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FileStore is a Store keeping values as files in a directory,
+// which has to be shared between producers and consumers.
+// Values are never deleted by consumers since other consumer groups may still need them,
+// they are purged by the StoreCleaner after the store retention.
+type FileStore struct {
+	dir string
+}
+
+func NewFileStore(props *properties.LargeMessage) (*FileStore, error) {
+	if err := os.MkdirAll(props.StoreDir, 0755); err != nil {
+		return nil, errors.WithMessagef(err, "create large message store dir [%s] failed", props.StoreDir)
+	}
+	return &FileStore{dir: props.StoreDir}, nil
+}
+
+func (f FileStore) Put(_ context.Context, ref string, value []byte) error {
+	path, err := f.path(ref)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	// Write to a temporary file first, so readers never see a partial value
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, value, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+func (f FileStore) Get(_ context.Context, ref string) ([]byte, error) {
+	path, err := f.path(ref)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(path)
+}
+
+func (f FileStore) Purge(_ context.Context, before time.Time) (int64, error) {
+	var deleted int64
+	err := filepath.WalkDir(f.dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				// Removed by another purger
+				return nil
+			}
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !info.ModTime().Before(before) {
+			return nil
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		deleted++
+		return nil
+	})
+	if err != nil {
+		return deleted, errors.WithMessagef(err, "purge large message store dir [%s] failed", f.dir)
+	}
+	return deleted, nil
+}
+
+func (f FileStore) path(ref string) (string, error) {
+	cleaned := filepath.Clean(filepath.FromSlash(ref))
+	if filepath.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("large message reference [%s] is invalid", ref)
+	}
+	return filepath.Join(f.dir, cleaned), nil
+}
@@ -0,0 +1,78 @@
+package largemessage
+
+import (
+	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"github.com/golibs-starter/golib-message-bus/kafka/internal/producererrors"
+	"github.com/golibs-starter/golib/log"
+)
+
+// SyncProducer is a core.SyncProducer which encodes large messages before sending them.
+// When a message is chunked, the partition and offset of the last chunk are returned.
+type SyncProducer struct {
+	producer core.SyncProducer
+	encoder  *Encoder
+}
+
+func NewSyncProducer(producer core.SyncProducer, encoder *Encoder) *SyncProducer {
+	return &SyncProducer{producer: producer, encoder: encoder}
+}
+
+func (s *SyncProducer) Send(m *core.Message) (partition int32, offset int64, err error) {
+	messages, err := s.encoder.Encode(m)
+	if err != nil {
+		return -1, -1, err
+	}
+	for _, message := range messages {
+		if partition, offset, err = s.producer.Send(message); err != nil {
+			return partition, offset, err
+		}
+	}
+	return partition, offset, nil
+}
+
+func (s *SyncProducer) Close() error {
+	return s.producer.Close()
+}
+
+// AsyncProducer is a core.AsyncProducer which encodes large messages before sending them.
+// Chunks are reported individually to the Successes and Errors channels,
+// the producer should be idempotent or limited to one in-flight request to keep chunks in order.
+type AsyncProducer struct {
+	producer core.AsyncProducer
+	encoder  *Encoder
+	errors   *producererrors.Reporter
+}
+
+func NewAsyncProducer(producer core.AsyncProducer, encoder *Encoder) *AsyncProducer {
+	return &AsyncProducer{
+		producer: producer,
+		encoder:  encoder,
+		errors:   producererrors.NewReporter(producer.Errors()),
+	}
+}
+
+func (a *AsyncProducer) Send(m *core.Message) {
+	messages, err := a.encoder.Encode(m)
+	if err != nil {
+		if !a.errors.Report(&core.ProducerError{Msg: m, Err: err}) {
+			log.WithErrors(err).Errorf("Large message cannot be encoded after the producer is closed")
+		}
+		return
+	}
+	for _, message := range messages {
+		a.producer.Send(message)
+	}
+}
+
+func (a *AsyncProducer) Successes() <-chan *core.Message {
+	return a.producer.Successes()
+}
+
+func (a *AsyncProducer) Errors() <-chan *core.ProducerError {
+	return a.errors.Errors()
+}
+
+func (a *AsyncProducer) Close() error {
+	a.errors.Close()
+	return a.producer.Close()
+}
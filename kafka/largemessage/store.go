@@ -0,0 +1,13 @@
+package largemessage
+
+import "context"
+
+// Store keeps the values of claim-check messages
+type Store interface {
+
+	// Put the value under the reference
+	Put(ctx context.Context, ref string, value []byte) error
+
+	// Get the value stored under the reference
+	Get(ctx context.Context, ref string) ([]byte, error)
+}
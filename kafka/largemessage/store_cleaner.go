@@ -0,0 +1,71 @@
+package largemessage
+
+import (
+	"context"
+	"github.com/golibs-starter/golib-message-bus/kafka/properties"
+	"github.com/golibs-starter/golib/log"
+	"sync"
+	"time"
+)
+
+// Purger is implemented by the stores which can delete the values put before a time
+type Purger interface {
+
+	// Purge deletes the values put before the time and returns the number of deleted values
+	Purge(ctx context.Context, before time.Time) (int64, error)
+}
+
+// StoreCleaner purges the values older than the store retention,
+// it does nothing when the store doesn't implement Purger.
+type StoreCleaner struct {
+	store    Store
+	props    *properties.LargeMessage
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+func NewStoreCleaner(store Store, props *properties.LargeMessage) *StoreCleaner {
+	return &StoreCleaner{store: store, props: props, stopCh: make(chan struct{})}
+}
+
+// Start purges the store in background until ctx is done or Stop is called.
+func (c *StoreCleaner) Start(ctx context.Context) {
+	purger, ok := c.store.(Purger)
+	if !ok {
+		return
+	}
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		c.run(ctx, purger)
+	}()
+}
+
+func (c *StoreCleaner) run(ctx context.Context, purger Purger) {
+	ticker := time.NewTicker(c.props.StorePurgeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			deleted, err := purger.Purge(ctx, time.Now().Add(-c.props.StoreRetention))
+			if err != nil {
+				log.WithErrors(err).Warnf("Large message store cannot be purged")
+			} else if deleted > 0 {
+				log.Infof("Large message store purged [%d] values", deleted)
+			}
+		case <-ctx.Done():
+			return
+		case <-c.stopCh:
+			return
+		}
+	}
+}
+
+// Stop the cleaner and wait for the in-flight purge to finish
+func (c *StoreCleaner) Stop() {
+	c.stopOnce.Do(func() {
+		close(c.stopCh)
+	})
+	c.wg.Wait()
+}
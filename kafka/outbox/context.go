@@ -0,0 +1,20 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+)
+
+type txContextKey struct{}
+
+// WithTx returns a copy of ctx that carries the caller's transaction.
+// Outbox implementations which support transactions will write into it.
+func WithTx(ctx context.Context, tx *sql.Tx) context.Context {
+	return context.WithValue(ctx, txContextKey{}, tx)
+}
+
+// TxFromContext returns the transaction attached by WithTx, or nil.
+func TxFromContext(ctx context.Context) *sql.Tx {
+	tx, _ := ctx.Value(txContextKey{}).(*sql.Tx)
+	return tx
+}
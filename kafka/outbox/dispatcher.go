@@ -0,0 +1,184 @@
+package outbox
+
+import (
+	"context"
+	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"github.com/golibs-starter/golib-message-bus/kafka/log"
+	"github.com/golibs-starter/golib-message-bus/kafka/properties"
+	"github.com/golibs-starter/golib/event"
+	coreLog "github.com/golibs-starter/golib/log"
+	"github.com/pkg/errors"
+	"sync"
+	"time"
+)
+
+// Dispatcher publishes pending outbox messages with the sync producer.
+// Delivery is at least once: a message may be published again
+// if it cannot be marked as sent after publishing.
+type Dispatcher struct {
+	outbox     Outbox
+	producer   core.SyncProducer
+	props      *properties.Outbox
+	eventProps *event.Properties
+	stopCh     chan struct{}
+	stopOnce   sync.Once
+	wg         sync.WaitGroup
+}
+
+func NewDispatcher(
+	outbox Outbox,
+	producer core.SyncProducer,
+	props *properties.Outbox,
+	eventProps *event.Properties,
+) *Dispatcher {
+	return &Dispatcher{
+		outbox:     outbox,
+		producer:   producer,
+		props:      props,
+		eventProps: eventProps,
+		stopCh:     make(chan struct{}),
+	}
+}
+
+// Start runs the dispatcher in background until ctx is done or Stop is called.
+func (d *Dispatcher) Start(ctx context.Context) {
+	coreLog.Infof("Outbox dispatcher is starting")
+	// Stop waits for the batch being dispatched, so no row is claimed once it returns
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		d.run(ctx)
+	}()
+}
+
+func (d *Dispatcher) run(ctx context.Context) {
+	pollTicker := time.NewTicker(d.props.PollInterval)
+	defer pollTicker.Stop()
+	purgeTicker := time.NewTicker(d.props.PurgeInterval)
+	defer purgeTicker.Stop()
+	for {
+		select {
+		case <-pollTicker.C:
+			d.dispatchPending(ctx)
+		case <-purgeTicker.C:
+			if _, err := d.Purge(ctx); err != nil {
+				coreLog.WithErrors(err).Errorf("Outbox dispatcher cannot purge sent messages")
+			}
+		case <-ctx.Done():
+			coreLog.Infof("Outbox dispatcher is stopped by context")
+			return
+		case <-d.stopCh:
+			coreLog.Infof("Outbox dispatcher is stopped")
+			return
+		}
+	}
+}
+
+// dispatchPending publishes batches until no message can be published
+func (d *Dispatcher) dispatchPending(ctx context.Context) {
+	for {
+		sent, err := d.Dispatch(ctx)
+		if err != nil {
+			coreLog.WithErrors(err).Errorf("Outbox dispatcher cannot dispatch pending messages")
+			return
+		}
+		if sent == 0 {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-d.stopCh:
+			return
+		default:
+		}
+	}
+}
+
+// Stop the dispatcher and wait for the in-flight batch to finish.
+func (d *Dispatcher) Stop() {
+	d.stopOnce.Do(func() {
+		close(d.stopCh)
+	})
+	d.wg.Wait()
+}
+
+// Dispatch publishes one batch of pending messages.
+// Returns number of published messages.
+func (d *Dispatcher) Dispatch(ctx context.Context) (int, error) {
+	entries, err := d.outbox.FetchPending(ctx, d.props.BatchSize)
+	if err != nil {
+		return 0, errors.WithMessage(err, "fetch pending outbox messages failed")
+	}
+	sent := 0
+	failedKeys := make(map[string]bool)
+	for _, entry := range entries {
+		// Once a message fails, the next messages of its key wait for it to keep their order
+		key, keyed := orderingKey(entry.Message)
+		if keyed && failedKeys[key] {
+			continue
+		}
+		descMessage := log.DescMessage(entry.Message, d.eventProps.Log.NotLogPayloadForEvents)
+		partition, offset, err := d.producer.Send(entry.Message)
+		if err != nil {
+			if keyed {
+				failedKeys[key] = true
+			}
+			nextAttemptAt := time.Now().Add(d.backoff(entry.Attempts + 1))
+			coreLog.WithErrors(err).Errorf("Error while producing outbox message [%d] %s, next attempt at [%s]",
+				entry.Id, descMessage, nextAttemptAt)
+			if err := d.outbox.MarkFailed(ctx, entry.Id, err, nextAttemptAt); err != nil {
+				return sent, errors.WithMessagef(err, "mark outbox message [%d] as failed failed", entry.Id)
+			}
+			continue
+		}
+		if err := d.outbox.MarkSent(ctx, entry.Id); err != nil {
+			return sent, errors.WithMessagef(err, "mark outbox message [%d] as sent failed", entry.Id)
+		}
+		sent++
+		if partition == core.PartitionSpooled {
+			coreLog.Warnf("Outbox message [%d] is spooled, it will be produced once the cluster is reachable, "+
+				"message %s", entry.Id, descMessage)
+			continue
+		}
+		coreLog.Infof("Success to produce outbox message [%d] to kafka partition [%d], offset [%d], message %s",
+			entry.Id, partition, offset, descMessage)
+	}
+	return sent, nil
+}
+
+// Purge deletes messages which were sent before the retention period.
+func (d *Dispatcher) Purge(ctx context.Context) (int64, error) {
+	deleted, err := d.outbox.Purge(ctx, time.Now().Add(-d.props.Retention))
+	if err != nil {
+		return 0, err
+	}
+	if deleted > 0 {
+		coreLog.Infof("Outbox dispatcher purged [%d] sent messages", deleted)
+	}
+	return deleted, nil
+}
+
+// backoff returns the delay before the given attempt,
+// doubled after each attempt and capped by MaxBackoff.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.props.InitialBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= d.props.MaxBackoff {
+			return d.props.MaxBackoff
+		}
+	}
+	if delay > d.props.MaxBackoff {
+		return d.props.MaxBackoff
+	}
+	return delay
+}
+
+// orderingKey returns the topic and key of a message, messages without key are not ordered.
+func orderingKey(message *core.Message) (string, bool) {
+	if len(message.Key) == 0 {
+		return "", false
+	}
+	return message.Topic + "/" + string(message.Key), true
+}
@@ -0,0 +1,141 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"github.com/golibs-starter/golib-message-bus/kafka/properties"
+	"github.com/golibs-starter/golib/event"
+	assert "github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+type TestProducer struct {
+	messages []*core.Message
+	err      error
+}
+
+func (t *TestProducer) Send(m *core.Message) (partition int32, offset int64, err error) {
+	if t.err != nil {
+		return 0, 0, t.err
+	}
+	t.messages = append(t.messages, m)
+	return 0, int64(len(t.messages)), nil
+}
+
+func (t *TestProducer) Close() error {
+	return nil
+}
+
+func newTestDispatcher(outbox Outbox, producer core.SyncProducer) *Dispatcher {
+	return NewDispatcher(outbox, producer, &properties.Outbox{
+		BatchSize:      10,
+		InitialBackoff: time.Minute,
+		MaxBackoff:     5 * time.Minute,
+		Retention:      time.Hour,
+	}, &event.Properties{})
+}
+
+func TestDispatcher_WhenSendSuccess_ShouldMarkMessagesAsSent(t *testing.T) {
+	outbox := NewInMemoryOutbox()
+	producer := &TestProducer{}
+	dispatcher := newTestDispatcher(outbox, producer)
+	assert.NoError(t, outbox.Save(context.Background(),
+		&core.Message{Topic: "topic1", Value: []byte("1")},
+		&core.Message{Topic: "topic1", Value: []byte("2")},
+	))
+
+	sent, err := dispatcher.Dispatch(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, sent)
+	assert.Len(t, producer.messages, 2)
+	assert.Equal(t, "1", string(producer.messages[0].Value))
+	assert.Equal(t, "2", string(producer.messages[1].Value))
+	for _, entry := range outbox.Entries() {
+		assert.NotNil(t, entry.SentAt)
+	}
+
+	sent, err = dispatcher.Dispatch(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, sent)
+}
+
+func TestDispatcher_WhenSendFailed_ShouldScheduleNextAttempt(t *testing.T) {
+	outbox := NewInMemoryOutbox()
+	producer := &TestProducer{err: errors.New("broker not available")}
+	dispatcher := newTestDispatcher(outbox, producer)
+	assert.NoError(t, outbox.Save(context.Background(), &core.Message{Topic: "topic1"}))
+
+	sent, err := dispatcher.Dispatch(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, sent)
+	entries := outbox.Entries()
+	assert.Len(t, entries, 1)
+	assert.Nil(t, entries[0].SentAt)
+	assert.Equal(t, 1, entries[0].Attempts)
+	assert.Equal(t, "broker not available", entries[0].LastError)
+	assert.True(t, entries[0].NextAttemptAt.After(time.Now().Add(50*time.Second)))
+
+	// The message is not due yet
+	pending, err := outbox.FetchPending(context.Background(), 10)
+	assert.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestDispatcher_Backoff_ShouldDoubleAndCapByMaxBackoff(t *testing.T) {
+	dispatcher := newTestDispatcher(NewInMemoryOutbox(), &TestProducer{})
+	assert.Equal(t, time.Minute, dispatcher.backoff(1))
+	assert.Equal(t, 2*time.Minute, dispatcher.backoff(2))
+	assert.Equal(t, 4*time.Minute, dispatcher.backoff(3))
+	assert.Equal(t, 5*time.Minute, dispatcher.backoff(4))
+	assert.Equal(t, 5*time.Minute, dispatcher.backoff(100))
+}
+
+func TestDispatcher_Purge_ShouldDeleteOnlySentMessagesOutOfRetention(t *testing.T) {
+	outbox := NewInMemoryOutbox()
+	dispatcher := newTestDispatcher(outbox, &TestProducer{})
+	assert.NoError(t, outbox.Save(context.Background(), &core.Message{Topic: "topic1"}, &core.Message{Topic: "topic1"}))
+	assert.NoError(t, outbox.MarkSent(context.Background(), 1))
+	sentAt := time.Now().Add(-2 * time.Hour)
+	outbox.entries[1].SentAt = &sentAt
+
+	deleted, err := dispatcher.Purge(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+	assert.Len(t, outbox.Entries(), 1)
+	assert.Equal(t, int64(2), outbox.Entries()[0].Id)
+}
+
+func TestDispatcher_WhenSendFailed_ShouldHoldNextMessagesOfTheSameKey(t *testing.T) {
+	outbox := NewInMemoryOutbox()
+	producer := &TestProducer{err: errors.New("broker not available")}
+	dispatcher := newTestDispatcher(outbox, producer)
+	assert.NoError(t, outbox.Save(context.Background(),
+		&core.Message{Topic: "topic1", Key: []byte("key1"), Value: []byte("1")},
+		&core.Message{Topic: "topic1", Key: []byte("key1"), Value: []byte("2")},
+	))
+
+	_, err := dispatcher.Dispatch(context.Background())
+	assert.NoError(t, err)
+	entries := outbox.Entries()
+	assert.Equal(t, 1, entries[0].Attempts)
+	assert.Equal(t, 0, entries[1].Attempts)
+
+	// The second message is due, but waits for the first one
+	producer.err = nil
+	sent, err := dispatcher.Dispatch(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, sent)
+	assert.Empty(t, producer.messages)
+}
+
+func TestDispatcher_WhenStopRightAfterStart_ShouldWaitForTheDispatcher(t *testing.T) {
+	dispatcher := NewDispatcher(NewInMemoryOutbox(), &TestProducer{}, &properties.Outbox{
+		PollInterval:  time.Millisecond,
+		PurgeInterval: time.Hour,
+		BatchSize:     10,
+	}, &event.Properties{})
+	dispatcher.Start(context.Background())
+	dispatcher.Stop()
+}
@@ -0,0 +1,44 @@
+package outbox
+
+import (
+	"context"
+	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"github.com/golibs-starter/golib-message-bus/kafka/relayer"
+	"github.com/golibs-starter/golib/pubsub"
+	"github.com/pkg/errors"
+)
+
+// EventWriter converts events to kafka messages and writes them into the outbox
+// inside the caller's transaction, so they are stored atomically with the business data:
+//
+//	err := writer.Write(outbox.WithTx(ctx, tx), event)
+//
+// It's the only way to store events into the outbox, publishing them to the event bus
+// cannot join the publisher's transaction.
+type EventWriter struct {
+	outbox         Outbox
+	eventConverter relayer.EventConverter
+}
+
+func NewEventWriter(outbox Outbox, eventConverter relayer.EventConverter) *EventWriter {
+	return &EventWriter{outbox: outbox, eventConverter: eventConverter}
+}
+
+// Write stores the events in the transaction carried by ctx, it fails when ctx carries no transaction.
+func (w EventWriter) Write(ctx context.Context, events ...pubsub.Event) error {
+	if TxFromContext(ctx) == nil {
+		return errors.New("write events to outbox requires a transaction, see WithTx")
+	}
+	messages := make([]*core.Message, 0, len(events))
+	for _, event := range events {
+		message, err := w.eventConverter.Convert(event)
+		if err != nil {
+			return errors.WithMessagef(err, "convert event [%s] to kafka message failed", event.Name())
+		}
+		messages = append(messages, message)
+	}
+	if err := w.outbox.Save(ctx, messages...); err != nil {
+		return errors.WithMessage(err, "save messages to outbox failed")
+	}
+	return nil
+}
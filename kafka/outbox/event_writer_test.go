@@ -0,0 +1,46 @@
+package outbox
+
+import (
+	"context"
+	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"github.com/golibs-starter/golib/pubsub"
+	webEvent "github.com/golibs-starter/golib/web/event"
+	assert "github.com/stretchr/testify/require"
+	"testing"
+)
+
+type testEventConverter struct {
+}
+
+func (t testEventConverter) Convert(event pubsub.Event) (*core.Message, error) {
+	return &core.Message{Topic: "topic1", Value: []byte(event.Name())}, nil
+}
+
+func (t testEventConverter) Restore(_ *core.ConsumerMessage, _ pubsub.Event) error {
+	return nil
+}
+
+func TestEventWriter_WhenContextHasNoTx_ShouldReturnError(t *testing.T) {
+	outbox := NewInMemoryOutbox()
+	writer := NewEventWriter(outbox, testEventConverter{})
+	err := writer.Write(context.Background(), webEvent.NewAbstractEvent(context.Background(), "OrderCreated"))
+	assert.EqualError(t, err, "write events to outbox requires a transaction, see WithTx")
+	assert.Empty(t, outbox.Entries())
+}
+
+func TestEventWriter_WhenContextHasTx_ShouldSaveConvertedEvents(t *testing.T) {
+	_, db := newTestDatabase()
+	tx, err := db.Begin()
+	assert.NoError(t, err)
+	outbox := NewInMemoryOutbox()
+	writer := NewEventWriter(outbox, testEventConverter{})
+	err = writer.Write(WithTx(context.Background(), tx),
+		webEvent.NewAbstractEvent(context.Background(), "OrderCreated"),
+		webEvent.NewAbstractEvent(context.Background(), "OrderPaid"))
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Commit())
+	entries := outbox.Entries()
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "OrderCreated", string(entries[0].Message.Value))
+	assert.Equal(t, "OrderPaid", string(entries[1].Message.Value))
+}
@@ -0,0 +1,129 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"sort"
+	"sync"
+	"time"
+)
+
+// InMemoryOutbox is an Outbox that keeps messages in memory.
+// It does not support transactions and is intended to be used in tests.
+type InMemoryOutbox struct {
+	mu      sync.Mutex
+	entries map[int64]*Entry
+	nextId  int64
+}
+
+func NewInMemoryOutbox() *InMemoryOutbox {
+	return &InMemoryOutbox{entries: make(map[int64]*Entry)}
+}
+
+func (o *InMemoryOutbox) Save(_ context.Context, messages ...*core.Message) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	now := time.Now()
+	for _, message := range messages {
+		o.nextId++
+		o.entries[o.nextId] = &Entry{
+			Id:            o.nextId,
+			Message:       message,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		}
+	}
+	return nil
+}
+
+func (o *InMemoryOutbox) FetchPending(_ context.Context, limit int) ([]*Entry, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	now := time.Now()
+	pendingKeys := make(map[string]bool)
+	entries := make([]*Entry, 0)
+	for _, entry := range o.sortedEntries() {
+		if entry.SentAt != nil {
+			continue
+		}
+		key, keyed := orderingKey(entry.Message)
+		if keyed && pendingKeys[key] {
+			continue
+		}
+		if keyed {
+			pendingKeys[key] = true
+		}
+		if entry.NextAttemptAt.After(now) {
+			continue
+		}
+		e := *entry
+		entries = append(entries, &e)
+		if limit > 0 && len(entries) == limit {
+			break
+		}
+	}
+	return entries, nil
+}
+
+func (o *InMemoryOutbox) MarkSent(_ context.Context, id int64) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	entry, exists := o.entries[id]
+	if !exists {
+		return fmt.Errorf("outbox entry [%d] not found", id)
+	}
+	now := time.Now()
+	entry.SentAt = &now
+	return nil
+}
+
+func (o *InMemoryOutbox) MarkFailed(_ context.Context, id int64, cause error, nextAttemptAt time.Time) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	entry, exists := o.entries[id]
+	if !exists {
+		return fmt.Errorf("outbox entry [%d] not found", id)
+	}
+	entry.Attempts++
+	entry.NextAttemptAt = nextAttemptAt
+	if cause != nil {
+		entry.LastError = cause.Error()
+	}
+	return nil
+}
+
+func (o *InMemoryOutbox) Purge(_ context.Context, sentBefore time.Time) (int64, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	var deleted int64
+	for id, entry := range o.entries {
+		if entry.SentAt != nil && entry.SentAt.Before(sentBefore) {
+			delete(o.entries, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// Entries returns a snapshot of all stored entries, ordered by id.
+func (o *InMemoryOutbox) Entries() []Entry {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	entries := make([]Entry, 0, len(o.entries))
+	for _, entry := range o.sortedEntries() {
+		entries = append(entries, *entry)
+	}
+	return entries
+}
+
+func (o *InMemoryOutbox) sortedEntries() []*Entry {
+	entries := make([]*Entry, 0, len(o.entries))
+	for _, entry := range o.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Id < entries[j].Id
+	})
+	return entries
+}
@@ -0,0 +1,46 @@
+package outbox
+
+import (
+	"context"
+	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"time"
+)
+
+// Outbox stores messages that have to be published to Kafka,
+// so they are not lost when the brokers are unavailable.
+type Outbox interface {
+
+	// Save stores messages as pending.
+	// When the context carries a transaction (see WithTx),
+	// the messages are stored inside that transaction.
+	Save(ctx context.Context, messages ...*core.Message) error
+
+	// FetchPending returns at most limit pending messages
+	// which are due to be published, ordered by insertion order.
+	// A message having a key is not returned while an earlier message
+	// with the same topic and key is pending, so the order of each key is kept.
+	// Implementations shared by several dispatchers have to claim the returned messages,
+	// so they are not returned to another dispatcher until they are marked or their claim expires.
+	FetchPending(ctx context.Context, limit int) ([]*Entry, error)
+
+	// MarkSent marks a message as published.
+	MarkSent(ctx context.Context, id int64) error
+
+	// MarkFailed records a failed attempt and schedules the next one.
+	MarkFailed(ctx context.Context, id int64, cause error, nextAttemptAt time.Time) error
+
+	// Purge deletes messages which were sent before the given time.
+	// Returns number of deleted messages.
+	Purge(ctx context.Context, sentBefore time.Time) (int64, error)
+}
+
+// Entry is a message stored in the outbox
+type Entry struct {
+	Id            int64
+	Message       *core.Message
+	Attempts      int
+	LastError     string
+	NextAttemptAt time.Time
+	CreatedAt     time.Time
+	SentAt        *time.Time
+}
@@ -0,0 +1,189 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	kafkaConstant "github.com/golibs-starter/golib-message-bus/kafka/constant"
+	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"github.com/golibs-starter/golib-message-bus/kafka/internal/sqldialect"
+	"github.com/golibs-starter/golib-message-bus/kafka/properties"
+	"github.com/pkg/errors"
+	"strings"
+	"time"
+)
+
+const DialectMysql = sqldialect.Mysql
+const DialectPostgres = sqldialect.Postgres
+
+type sqlExecutor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// SqlOutbox is an Outbox backed by a database/sql table.
+// Times are stored as unix milliseconds to stay independent of the driver.
+type SqlOutbox struct {
+	db    *sql.DB
+	props *properties.Outbox
+}
+
+func NewSqlOutbox(db *sql.DB, props *properties.Outbox) (*SqlOutbox, error) {
+	if !sqldialect.Supported(props.Dialect) {
+		return nil, fmt.Errorf("outbox dialect [%s] is not supported", props.Dialect)
+	}
+	return &SqlOutbox{db: db, props: props}, nil
+}
+
+func (o SqlOutbox) Save(ctx context.Context, messages ...*core.Message) error {
+	executor := o.executor(ctx)
+	query := o.bind(fmt.Sprintf("INSERT INTO %s (topic, msg_key, msg_value, headers, msg_partition, event_id, "+
+		"event_name, attempts, next_attempt_at, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, 0, ?, ?)", o.props.TableName))
+	now := time.Now().UnixMilli()
+	for _, message := range messages {
+		headers, err := json.Marshal(message.Headers)
+		if err != nil {
+			return errors.WithMessage(err, "marshalling outbox message headers failed")
+		}
+		eventId, eventName := o.eventInfo(message)
+		if _, err := executor.ExecContext(ctx, query, message.Topic, message.Key, message.Value, string(headers),
+			message.Partition, eventId, eventName, now, now); err != nil {
+			return errors.WithMessagef(err, "insert outbox message for topic [%s] failed", message.Topic)
+		}
+	}
+	return nil
+}
+
+// FetchPending claims the pending messages with SELECT ... FOR UPDATE SKIP LOCKED
+// (requires MySQL 8.0 or PostgreSQL 9.5), their next attempt is postponed by the claim timeout
+// so that other instances skip them until they are marked.
+func (o SqlOutbox) FetchPending(ctx context.Context, limit int) ([]*Entry, error) {
+	tx, err := o.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, errors.WithMessage(err, "begin outbox claim transaction failed")
+	}
+	entries, err := o.claimPending(ctx, tx, limit)
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, errors.WithMessage(err, "commit outbox claim transaction failed")
+	}
+	return entries, nil
+}
+
+func (o SqlOutbox) claimPending(ctx context.Context, tx *sql.Tx, limit int) ([]*Entry, error) {
+	// A keyed message waits for the earlier messages of its key, even when they are claimed by another instance
+	query := o.bind(fmt.Sprintf("SELECT id, topic, msg_key, msg_value, headers, msg_partition, event_id, event_name, "+
+		"attempts, last_error, next_attempt_at, created_at FROM %[1]s o WHERE sent_at IS NULL AND next_attempt_at <= ? "+
+		"AND NOT EXISTS (SELECT 1 FROM %[1]s p WHERE p.topic = o.topic AND p.msg_key = o.msg_key "+
+		"AND p.sent_at IS NULL AND p.id < o.id) ORDER BY id LIMIT ? FOR UPDATE SKIP LOCKED", o.props.TableName))
+	now := time.Now()
+	entries, err := o.queryEntries(ctx, tx, query, now.UnixMilli(), limit)
+	if err != nil || len(entries) == 0 {
+		return entries, err
+	}
+	claimedUntil := now.Add(o.props.ClaimTimeout)
+	args := make([]interface{}, 0, len(entries)+1)
+	args = append(args, claimedUntil.UnixMilli())
+	for _, entry := range entries {
+		args = append(args, entry.Id)
+		entry.NextAttemptAt = claimedUntil
+	}
+	query = o.bind(fmt.Sprintf("UPDATE %s SET next_attempt_at = ? WHERE id IN (?%s)",
+		o.props.TableName, strings.Repeat(", ?", len(entries)-1)))
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return nil, errors.WithMessage(err, "claim pending outbox messages failed")
+	}
+	return entries, nil
+}
+
+func (o SqlOutbox) queryEntries(ctx context.Context, tx *sql.Tx, query string, args ...interface{}) ([]*Entry, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.WithMessage(err, "query pending outbox messages failed")
+	}
+	defer rows.Close()
+	entries := make([]*Entry, 0)
+	for rows.Next() {
+		var entry Entry
+		var message core.Message
+		var headers, eventId, eventName, lastError sql.NullString
+		var nextAttemptAt, createdAt int64
+		if err := rows.Scan(&entry.Id, &message.Topic, &message.Key, &message.Value, &headers, &message.Partition,
+			&eventId, &eventName, &entry.Attempts, &lastError, &nextAttemptAt, &createdAt); err != nil {
+			return nil, errors.WithMessage(err, "scan outbox message failed")
+		}
+		if headers.Valid && headers.String != "" {
+			if err := json.Unmarshal([]byte(headers.String), &message.Headers); err != nil {
+				return nil, errors.WithMessagef(err, "unmarshal headers of outbox message [%d] failed", entry.Id)
+			}
+		}
+		message.Metadata = map[string]interface{}{
+			kafkaConstant.EventId:   eventId.String,
+			kafkaConstant.EventName: eventName.String,
+		}
+		entry.Message = &message
+		entry.LastError = lastError.String
+		entry.NextAttemptAt = time.UnixMilli(nextAttemptAt)
+		entry.CreatedAt = time.UnixMilli(createdAt)
+		entries = append(entries, &entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.WithMessage(err, "iterate pending outbox messages failed")
+	}
+	return entries, nil
+}
+
+func (o SqlOutbox) MarkSent(ctx context.Context, id int64) error {
+	query := o.bind(fmt.Sprintf("UPDATE %s SET sent_at = ? WHERE id = ?", o.props.TableName))
+	if _, err := o.executor(ctx).ExecContext(ctx, query, time.Now().UnixMilli(), id); err != nil {
+		return errors.WithMessagef(err, "mark outbox message [%d] as sent failed", id)
+	}
+	return nil
+}
+
+func (o SqlOutbox) MarkFailed(ctx context.Context, id int64, cause error, nextAttemptAt time.Time) error {
+	var lastError string
+	if cause != nil {
+		lastError = cause.Error()
+	}
+	query := o.bind(fmt.Sprintf("UPDATE %s SET attempts = attempts + 1, last_error = ?, next_attempt_at = ? "+
+		"WHERE id = ?", o.props.TableName))
+	if _, err := o.executor(ctx).ExecContext(ctx, query, lastError, nextAttemptAt.UnixMilli(), id); err != nil {
+		return errors.WithMessagef(err, "mark outbox message [%d] as failed failed", id)
+	}
+	return nil
+}
+
+func (o SqlOutbox) Purge(ctx context.Context, sentBefore time.Time) (int64, error) {
+	query := o.bind(fmt.Sprintf("DELETE FROM %s WHERE sent_at IS NOT NULL AND sent_at < ?", o.props.TableName))
+	result, err := o.executor(ctx).ExecContext(ctx, query, sentBefore.UnixMilli())
+	if err != nil {
+		return 0, errors.WithMessage(err, "purge outbox messages failed")
+	}
+	return result.RowsAffected()
+}
+
+func (o SqlOutbox) executor(ctx context.Context) sqlExecutor {
+	if tx := TxFromContext(ctx); tx != nil {
+		return tx
+	}
+	return o.db
+}
+
+func (o SqlOutbox) bind(query string) string {
+	return sqldialect.Bind(o.props.Dialect, query)
+}
+
+func (o SqlOutbox) eventInfo(message *core.Message) (string, string) {
+	metadata, ok := message.Metadata.(map[string]interface{})
+	if !ok {
+		return "", ""
+	}
+	eventId, _ := metadata[kafkaConstant.EventId].(string)
+	eventName, _ := metadata[kafkaConstant.EventName].(string)
+	return eventId, eventName
+}
@@ -0,0 +1,207 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	kafkaConstant "github.com/golibs-starter/golib-message-bus/kafka/constant"
+	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"github.com/golibs-starter/golib-message-bus/kafka/properties"
+	assert "github.com/stretchr/testify/require"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testStatement struct {
+	query string
+	args  []driver.Value
+}
+
+// testDatabase is a database/sql driver recording the executed statements,
+// queries return the rows of the first registered prefix they start with.
+type testDatabase struct {
+	mu         sync.Mutex
+	statements []testStatement
+	rows       map[string][][]driver.Value
+}
+
+func newTestDatabase() (*testDatabase, *sql.DB) {
+	database := &testDatabase{rows: make(map[string][][]driver.Value)}
+	return database, sql.OpenDB(database)
+}
+
+func (d *testDatabase) Connect(context.Context) (driver.Conn, error) {
+	return &testConn{database: d}, nil
+}
+
+func (d *testDatabase) Driver() driver.Driver {
+	return nil
+}
+
+func (d *testDatabase) record(query string, args []driver.Value) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.statements = append(d.statements, testStatement{query: query, args: args})
+}
+
+func (d *testDatabase) queries() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	queries := make([]string, 0, len(d.statements))
+	for _, statement := range d.statements {
+		queries = append(queries, strings.SplitN(statement.query, " ", 2)[0])
+	}
+	return queries
+}
+
+type testConn struct {
+	database *testDatabase
+}
+
+func (c *testConn) Prepare(query string) (driver.Stmt, error) {
+	return &testStmt{database: c.database, query: query}, nil
+}
+
+func (c *testConn) Close() error {
+	return nil
+}
+
+func (c *testConn) Begin() (driver.Tx, error) {
+	c.database.record("BEGIN", nil)
+	return c, nil
+}
+
+func (c *testConn) Commit() error {
+	c.database.record("COMMIT", nil)
+	return nil
+}
+
+func (c *testConn) Rollback() error {
+	c.database.record("ROLLBACK", nil)
+	return nil
+}
+
+type testStmt struct {
+	database *testDatabase
+	query    string
+}
+
+func (s *testStmt) Close() error {
+	return nil
+}
+
+func (s *testStmt) NumInput() int {
+	return -1
+}
+
+func (s *testStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.database.record(s.query, args)
+	return driver.RowsAffected(1), nil
+}
+
+func (s *testStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.database.record(s.query, args)
+	for prefix, rows := range s.database.rows {
+		if strings.HasPrefix(s.query, prefix) {
+			return &testRows{rows: rows}, nil
+		}
+	}
+	return &testRows{}, nil
+}
+
+type testRows struct {
+	rows [][]driver.Value
+}
+
+func (r *testRows) Columns() []string {
+	return []string{"id", "topic", "msg_key", "msg_value", "headers", "msg_partition", "event_id", "event_name",
+		"attempts", "last_error", "next_attempt_at", "created_at"}
+}
+
+func (r *testRows) Close() error {
+	return nil
+}
+
+func (r *testRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func newTestSqlOutbox(t *testing.T, dialect string) (*testDatabase, *SqlOutbox) {
+	database, db := newTestDatabase()
+	outbox, err := NewSqlOutbox(db, &properties.Outbox{TableName: "kafka_outbox", Dialect: dialect,
+		ClaimTimeout: time.Minute})
+	assert.NoError(t, err)
+	return database, outbox
+}
+
+func TestSqlOutbox_WhenSaveWithTx_ShouldInsertInsideTheTransaction(t *testing.T) {
+	database, outbox := newTestSqlOutbox(t, DialectPostgres)
+	tx, err := outbox.db.Begin()
+	assert.NoError(t, err)
+	err = outbox.Save(WithTx(context.Background(), tx), &core.Message{
+		Topic:    "topic1",
+		Key:      []byte("key1"),
+		Value:    []byte("value1"),
+		Headers:  []core.MessageHeader{{Key: []byte("h1"), Value: []byte("v1")}},
+		Metadata: map[string]interface{}{kafkaConstant.EventId: "id1", kafkaConstant.EventName: "OrderCreated"},
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Rollback())
+
+	assert.Equal(t, []string{"BEGIN", "INSERT", "ROLLBACK"}, database.queries())
+	insert := database.statements[1]
+	assert.Contains(t, insert.query, "VALUES ($1, $2, $3, $4, $5, $6, $7, 0, $8, $9)")
+	assert.Equal(t, "topic1", insert.args[0])
+	assert.Equal(t, []byte("key1"), insert.args[1])
+	assert.Equal(t, "id1", insert.args[5])
+	assert.Equal(t, "OrderCreated", insert.args[6])
+}
+
+func TestSqlOutbox_WhenFetchPending_ShouldClaimMessagesWithSkipLocked(t *testing.T) {
+	database, outbox := newTestSqlOutbox(t, DialectMysql)
+	headers, err := json.Marshal([]core.MessageHeader{{Key: []byte("h1"), Value: []byte("v1")}})
+	assert.NoError(t, err)
+	now := time.Now().UnixMilli()
+	database.rows["SELECT"] = [][]driver.Value{
+		{int64(1), "topic1", []byte("key1"), []byte("value1"), string(headers), int64(2), "id1", "OrderCreated",
+			int64(0), nil, now, now},
+		{int64(2), "topic1", nil, []byte("value2"), nil, int64(0), nil, nil, int64(1), "broker down", now, now},
+	}
+
+	entries, err := outbox.FetchPending(context.Background(), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"BEGIN", "SELECT", "UPDATE", "COMMIT"}, database.queries())
+	assert.True(t, strings.HasSuffix(database.statements[1].query, "ORDER BY id LIMIT ? FOR UPDATE SKIP LOCKED"))
+	claim := database.statements[2]
+	assert.Equal(t, "UPDATE kafka_outbox SET next_attempt_at = ? WHERE id IN (?, ?)", claim.query)
+	assert.Equal(t, []driver.Value{int64(1), int64(2)}, claim.args[1:])
+	assert.GreaterOrEqual(t, claim.args[0].(int64), now+time.Minute.Milliseconds())
+
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "topic1", entries[0].Message.Topic)
+	assert.Equal(t, []byte("key1"), entries[0].Message.Key)
+	assert.Equal(t, int32(2), entries[0].Message.Partition)
+	assert.Equal(t, "h1", string(entries[0].Message.Headers[0].Key))
+	assert.Equal(t, map[string]interface{}{kafkaConstant.EventId: "id1", kafkaConstant.EventName: "OrderCreated"},
+		entries[0].Message.Metadata)
+	assert.Nil(t, entries[1].Message.Key)
+	assert.Equal(t, 1, entries[1].Attempts)
+	assert.Equal(t, "broker down", entries[1].LastError)
+}
+
+func TestSqlOutbox_WhenNothingIsPending_ShouldNotClaim(t *testing.T) {
+	database, outbox := newTestSqlOutbox(t, DialectMysql)
+	entries, err := outbox.FetchPending(context.Background(), 10)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+	assert.Equal(t, []string{"BEGIN", "SELECT", "COMMIT"}, database.queries())
+}
@@ -0,0 +1,37 @@
+package properties
+
+import (
+	"github.com/golibs-starter/golib/config"
+	"time"
+)
+
+func NewDedup(loader config.Loader) (*Dedup, error) {
+	props := Dedup{}
+	err := loader.Bind(&props)
+	return &props, err
+}
+
+type Dedup struct {
+	// Ttl is how long processed messages are remembered.
+	Ttl time.Duration `default:"24h"`
+
+	// PurgeInterval is the interval the expired keys are deleted from the SQL store.
+	PurgeInterval time.Duration `default:"1h"`
+
+	// Capacity is the maximum number of keys kept by the in-memory store,
+	// the least recently used keys are evicted first.
+	Capacity int `default:"100000"`
+
+	// TableName is the table that stores processed keys when using the SQL store.
+	TableName string `default:"kafka_consumer_dedup"`
+
+	// Dialect is used to build SQL statements. Supported: mysql, postgres.
+	Dialect string `default:"mysql" validate:"required=false,oneof=mysql postgres"`
+
+	// Order of the deduplication middleware in the consumer middleware chain.
+	Order int `default:"100"`
+}
+
+func (d Dedup) Prefix() string {
+	return "app.kafka.consumer.dedup"
+}
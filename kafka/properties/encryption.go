@@ -0,0 +1,20 @@
+package properties
+
+import (
+	"github.com/golibs-starter/golib/config"
+)
+
+func NewEncryption(loader config.Loader) (*Encryption, error) {
+	props := Encryption{}
+	err := loader.Bind(&props)
+	return &props, err
+}
+
+type Encryption struct {
+	// KeyFile is the location of the master keys used by encryption.LocalKms
+	KeyFile string
+}
+
+func (e Encryption) Prefix() string {
+	return "app.kafka.encryption"
+}
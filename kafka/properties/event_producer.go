@@ -20,4 +20,38 @@ type EventTopic struct {
 	TopicName     string
 	Transactional bool `default:"true"`
 	Disable       bool
+
+	// DeliveryMode defines how the event is relayed to Kafka.
+	// sync: wait for the broker acknowledgement before returning (default).
+	// async: send through the async producer, results are logged by the async producer handlers.
+	// fire-and-forget: send through the async producer, only failures are logged.
+	// outbox: the event is not produced when it is published, it has to be written with outbox.EventWriter
+	// inside the caller's transaction, then it's published by the outbox dispatcher (requires KafkaOutboxOpt).
+	DeliveryMode string `default:"sync" validate:"required=false,oneof=sync async fire-and-forget outbox"`
+
+	// JsonSchema is the location of the JSON schema the event payload must conform to.
+	// Use a file path or embed://<path> to load it from the embedded file system.
+	JsonSchema string
+
+	// CloudEventsMode encodes the event as a CloudEvent when it is provided.
+	// binary: attributes in ce_* headers and data in the value,
+	// structured: a JSON envelope in the value.
+	CloudEventsMode string `validate:"required=false,oneof=binary structured"`
+
+	// Codec is the name of the codec serializing the event, eg: json, gzip-json, msgpack, protobuf, raw.
+	// When it is provided, the codec content type is advertised in the content-type header.
+	// Default: json without content-type header.
+	Codec string
+
+	// EventNameHeader writes the event name in the Event-Name header,
+	// so that consumers can filter the event by name without decoding the value.
+	EventNameHeader bool
+
+	// EncryptionKeyId is the id of the KMS master key wrapping the data key the message is encrypted with.
+	// Encryption is disabled when it is empty. Requires KafkaEncryptionOpt().
+	EncryptionKeyId string
+
+	// EncryptedFields are the dot separated paths of the JSON fields to encrypt, eg: payload.email.
+	// The whole message value is encrypted when it is empty.
+	EncryptedFields []string
 }
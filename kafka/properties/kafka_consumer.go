@@ -1,6 +1,9 @@
 package properties
 
-import "github.com/golibs-starter/golib/config"
+import (
+	"github.com/golibs-starter/golib/config"
+	"time"
+)
 
 func NewKafkaConsumer(loader config.Loader) (*KafkaConsumer, error) {
 	props := KafkaConsumer{}
@@ -28,9 +31,74 @@ type TopicConsumer struct {
 	// When Topic is provided, Topics will be ignored.
 	Topics []string
 
+	// TopicPattern is used when neither Topic nor Topics is provided.
+	// The consumer subscribes to all topics whose whole name matches this regular expression,
+	// and re-subscribes when matching topics are created or deleted.
+	TopicPattern string
+
+	// TopicRefreshInterval is the interval cluster metadata is refreshed to find the topics matching TopicPattern
+	TopicRefreshInterval time.Duration `default:"1m"`
+
 	// GroupId of consumer
 	GroupId string
 
+	// JsonSchema is the location of the JSON schema the event payload of consumed messages must conform to,
+	// the same document is validated by the producer whatever the envelope is.
+	// Use a file path or embed://<path> to load it from the embedded file system.
+	JsonSchema string
+
+	// InvalidMessageTopic is the topic invalid messages are routed to instead of calling the handler.
+	// When it is empty, invalid messages are only logged.
+	InvalidMessageTopic string
+
+	// Codec is the name of the codec of consumed messages without content-type header.
+	// When it is provided, its content type is set to these messages so the converter can pick it.
+	Codec string
+
+	// FailurePolicy is applied when the handler panics.
+	// skip: the message is skipped,
+	// retry: the message is handled again up to MaxRetries times, then routed to DeadLetterTopic if it is provided,
+	// dead-letter: the message is routed to DeadLetterTopic,
+	// stop: the consumer stops without committing the message.
+	FailurePolicy string        `default:"skip" validate:"required=false,oneof=skip retry dead-letter stop"`
+	MaxRetries    int           `default:"3"`
+	RetryBackoff  time.Duration `default:"1s"`
+
+	// DeadLetterTopic is the topic failed messages are routed to. Requires a producer.
+	DeadLetterTopic string
+
+	// Seek resets the group to this position once, it's used to replay messages.
+	// The position is recorded in the metadata of committed offsets, changing it applies the new position.
+	// One of earliest, latest, offset, timestamp.
+	Seek       string `validate:"required=false,oneof=earliest latest offset timestamp"`
+	SeekOffset int64
+
+	// SeekTimestamp is used when Seek=timestamp, in RFC3339 format, eg: 2006-01-02T15:04:05Z
+	SeekTimestamp string
+
+	// Filters select the messages passed to the handler, a message has to match all of them.
+	// Filtered out messages are marked as consumed.
+	Filters []MessageFilter
+
 	// TODO implement it
 	Concurrency int
 }
+
+// MessageFilter matches messages by exactly one of Header, EventName, KeyPrefix or JsonPath
+type MessageFilter struct {
+	// Header matches messages having this header, whose value equals Equals when it is provided
+	Header string
+
+	// EventName matches messages of this event, read from the Event-Name header
+	// written by the producers enabling EventTopic.EventNameHeader
+	EventName string
+
+	// KeyPrefix matches messages whose key starts with it
+	KeyPrefix string
+
+	// JsonPath is a dot separated path, eg: payload.status. It matches messages whose JSON value
+	// contains this path, whose value equals Equals when it is provided. It requires the json codec.
+	JsonPath string
+
+	Equals string
+}
@@ -0,0 +1,53 @@
+package properties
+
+import (
+	"github.com/golibs-starter/golib/config"
+	"time"
+)
+
+func NewLargeMessage(loader config.Loader) (*LargeMessage, error) {
+	props := LargeMessage{}
+	err := loader.Bind(&props)
+	return &props, err
+}
+
+type LargeMessage struct {
+	// Mode defines how messages larger than MaxMessageBytes are produced.
+	// chunk: the value is split into ordered chunks reassembled by consumers,
+	// claim-check: the value is put in the large message store and a reference message is sent.
+	Mode string `default:"chunk" validate:"required=false,oneof=chunk claim-check"`
+
+	// MaxMessageBytes is the maximum size of a message value, also the size of chunks.
+	// It should be lower than the max.message.bytes of topics to leave room for headers.
+	MaxMessageBytes int `default:"921600"`
+
+	// ChunkTimeout is the time consumers wait for the missing chunks of a message,
+	// incomplete messages are dropped after it.
+	ChunkTimeout time.Duration `default:"5m"`
+
+	// MaxChunkCount is the maximum number of chunks of a consumed message,
+	// messages announcing more chunks are dropped.
+	MaxChunkCount int `default:"128"`
+
+	// MaxAssembledBytes is the maximum size of a reassembled message,
+	// messages whose chunks exceed it are dropped.
+	MaxAssembledBytes int `default:"104857600"`
+
+	// MaxHeldMessages is the maximum number of messages held by consumers while chunks are missing,
+	// the incomplete chunked messages are dropped when more messages are consumed.
+	MaxHeldMessages int `default:"1000"`
+
+	// StoreDir is the directory used by largemessage.FileStore
+	StoreDir string `default:"./data/kafka-large-message"`
+
+	// StoreRetention is how long values are kept in the store before being purged,
+	// it should be longer than the retention of the topics.
+	StoreRetention time.Duration `default:"168h"`
+
+	// StorePurgeInterval is the interval the store is purged
+	StorePurgeInterval time.Duration `default:"1h"`
+}
+
+func (l LargeMessage) Prefix() string {
+	return "app.kafka.largeMessage"
+}
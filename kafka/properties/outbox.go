@@ -0,0 +1,46 @@
+package properties
+
+import (
+	"github.com/golibs-starter/golib/config"
+	"time"
+)
+
+func NewOutbox(loader config.Loader) (*Outbox, error) {
+	props := Outbox{}
+	err := loader.Bind(&props)
+	return &props, err
+}
+
+type Outbox struct {
+	// TableName is the table that stores outbox messages when using the SQL outbox.
+	TableName string `default:"kafka_outbox"`
+
+	// Dialect is used to build SQL placeholders. Supported: mysql, postgres.
+	Dialect string `default:"mysql" validate:"required=false,oneof=mysql postgres"`
+
+	// PollInterval is the interval the dispatcher looks for pending messages.
+	PollInterval time.Duration `default:"1s"`
+
+	// BatchSize is the maximum number of messages published in one batch.
+	// Batches are published until no message is pending on each poll.
+	BatchSize int `default:"100"`
+
+	// ClaimTimeout is how long fetched messages are reserved to the dispatcher which fetched them.
+	// They are fetched again when they are neither marked as sent nor failed in time, eg: the instance crashed.
+	ClaimTimeout time.Duration `default:"1m"`
+
+	// InitialBackoff is the delay before the first retry of a failed message.
+	// The delay is doubled after each failed attempt until it reaches MaxBackoff.
+	InitialBackoff time.Duration `default:"1s"`
+	MaxBackoff     time.Duration `default:"5m"`
+
+	// Retention is how long sent messages are kept before being purged.
+	Retention time.Duration `default:"72h"`
+
+	// PurgeInterval is the interval the dispatcher purges sent messages.
+	PurgeInterval time.Duration `default:"1h"`
+}
+
+func (o Outbox) Prefix() string {
+	return "app.kafka.outbox"
+}
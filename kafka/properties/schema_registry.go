@@ -0,0 +1,36 @@
+package properties
+
+import (
+	"github.com/golibs-starter/golib/config"
+	"time"
+)
+
+func NewSchemaRegistry(loader config.Loader) (*SchemaRegistry, error) {
+	props := SchemaRegistry{}
+	err := loader.Bind(&props)
+	return &props, err
+}
+
+type SchemaRegistry struct {
+	// Url of the schema registry, eg: http://localhost:8081
+	Url string
+
+	// Username and Password are used for basic authentication when provided.
+	Username string
+	Password string
+
+	Timeout time.Duration `default:"10s"`
+
+	// AutoRegisterSchemas registers the event schema when it doesn't exist in the subject.
+	// When disabled, the schema has to be registered before producing.
+	AutoRegisterSchemas bool `default:"true"`
+
+	// SubjectNameStrategy defines how the subject is derived. Supported:
+	// topic: <topic>-value, record: <fully qualified record name>,
+	// topic-record: <topic>-<fully qualified record name>.
+	SubjectNameStrategy string `default:"topic" validate:"required=false,oneof=topic record topic-record"`
+}
+
+func (s SchemaRegistry) Prefix() string {
+	return "app.kafka.schemaRegistry"
+}
@@ -0,0 +1,33 @@
+package properties
+
+import (
+	"github.com/golibs-starter/golib/config"
+	"time"
+)
+
+func NewSpool(loader config.Loader) (*Spool, error) {
+	props := Spool{}
+	err := loader.Bind(&props)
+	return &props, err
+}
+
+type Spool struct {
+	// Dir is the directory that contains the spool journal.
+	Dir string `default:"./data/kafka-spool"`
+
+	// MaxBytes is the maximum size of the spool journal.
+	// Messages are rejected when the journal is full.
+	MaxBytes int64 `default:"104857600"`
+
+	// FsyncPolicy defines when the journal is flushed to the disk.
+	// always: after each write, interval: every FsyncInterval, never: let the OS decide.
+	FsyncPolicy   string        `default:"interval" validate:"required=false,oneof=always interval never"`
+	FsyncInterval time.Duration `default:"1s"`
+
+	// ReplayInterval is the interval the replayer tries to drain the spool.
+	ReplayInterval time.Duration `default:"5s"`
+}
+
+func (s Spool) Prefix() string {
+	return "app.kafka.producer.spool"
+}
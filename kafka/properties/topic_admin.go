@@ -13,6 +13,49 @@ func NewTopicAdmin(loader config.Loader) (*TopicAdmin, error) {
 
 type TopicAdmin struct {
 	Topics []core.TopicConfiguration
+
+	// DeriveTopics adds the topics referenced by consumer and producer mappings to Topics.
+	// Derived topics are configured by TopicDefaults, unless they are already configured in Topics.
+	DeriveTopics bool
+
+	// TopicDefaults is the template of derived topics, its Name is ignored
+	TopicDefaults core.TopicConfiguration
+
+	// Reconcile compares Topics with the existing topics on startup: missing topics are created,
+	// partitions are increased and drifted configs are altered.
+	// When it is disabled, only missing topics are created.
+	Reconcile bool
+
+	// DryRun logs the reconciliation plan without applying it
+	DryRun bool
+
+	// FailOnIrreconcilable fails the startup when some differences cannot be reconciled,
+	// such as a reduced partition count or a changed replication factor.
+	// Otherwise they are only logged.
+	FailOnIrreconcilable bool
+
+	// ForcePartitionIncrease lets reconciliation increase partitions of topics having records,
+	// it breaks the key-to-partition affinity of keyed messages. Otherwise reconciliation fails for them.
+	ForcePartitionIncrease bool
+
+	// Acls are ensured on startup, they are not created when Principals is empty
+	Acls Acls
+}
+
+// Acls grants the principals of the service access to its topics and groups
+type Acls struct {
+	// Principals of the service, eg: User:order-service
+	Principals []string
+
+	// Host the principals connect from
+	Host string `default:"*"`
+
+	// ConsumedTopics and ConsumerGroups are granted READ
+	ConsumedTopics []string
+	ConsumerGroups []string
+
+	// ProducedTopics are granted WRITE
+	ProducedTopics []string
 }
 
 func (h TopicAdmin) Prefix() string {
@@ -0,0 +1,24 @@
+package properties
+
+import (
+	"github.com/golibs-starter/golib/config"
+	"time"
+)
+
+func NewTopicReadiness(loader config.Loader) (*TopicReadiness, error) {
+	props := TopicReadiness{}
+	err := loader.Bind(&props)
+	return &props, err
+}
+
+type TopicReadiness struct {
+	// Timeout is how long the startup waits for the cluster and the topics to be ready.
+	Timeout time.Duration `default:"60s"`
+
+	// Interval between two checks.
+	Interval time.Duration `default:"2s"`
+}
+
+func (r TopicReadiness) Prefix() string {
+	return "app.kafka.readiness"
+}
@@ -0,0 +1,268 @@
+package relayer
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/golibs-starter/golib-message-bus/kafka/codec"
+	kafkaConstant "github.com/golibs-starter/golib-message-bus/kafka/constant"
+	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"github.com/golibs-starter/golib-message-bus/kafka/properties"
+	"github.com/golibs-starter/golib/config"
+	"github.com/golibs-starter/golib/event"
+	"github.com/golibs-starter/golib/pubsub"
+	webEvent "github.com/golibs-starter/golib/web/event"
+	"github.com/pkg/errors"
+	"strings"
+	"time"
+)
+
+const CloudEventsSpecVersion = "1.0"
+const CloudEventsContentType = "application/cloudevents+json"
+const CloudEventsDataContentType = "application/json"
+
+const HeaderCeSpecVersion = "ce_specversion"
+const HeaderCeId = "ce_id"
+const HeaderCeSource = "ce_source"
+const HeaderCeType = "ce_type"
+const HeaderCeTime = "ce_time"
+const HeaderCeServiceCode = "ce_servicecode"
+const HeaderCeRequestId = "ce_requestid"
+const HeaderCeUserId = "ce_userid"
+const HeaderCeTechnicalUsername = "ce_technicalusername"
+const HeaderCeAdditionalData = "ce_additionaldata"
+
+// CloudEvent is the JSON envelope of a CloudEvent in structured mode.
+// The golib event fields which have no CloudEvents attribute are carried as extensions,
+// the additional data is encoded as a JSON string.
+type CloudEvent struct {
+	SpecVersion       string          `json:"specversion"`
+	Id                string          `json:"id"`
+	Source            string          `json:"source"`
+	Type              string          `json:"type"`
+	Time              string          `json:"time,omitempty"`
+	DataContentType   string          `json:"datacontenttype,omitempty"`
+	ServiceCode       string          `json:"servicecode,omitempty"`
+	RequestId         string          `json:"requestid,omitempty"`
+	UserId            string          `json:"userid,omitempty"`
+	TechnicalUsername string          `json:"technicalusername,omitempty"`
+	AdditionalData    string          `json:"additionaldata,omitempty"`
+	Data              json.RawMessage `json:"data,omitempty"`
+}
+
+// CloudEventsConverter encodes events as CloudEvents when it is enabled in their EventTopic.
+// The event id, name, the application name, the event time and the event payload
+// are mapped to the CloudEvents id, type, source, time and data attributes,
+// the other golib event fields are mapped to extensions so they survive a round trip.
+// The data is always JSON, so a CloudEvents mapping cannot select another codec.
+// Other events are handled by the delegate converter.
+type CloudEventsConverter struct {
+	delegate           EventConverter
+	appProps           *config.AppProperties
+	eventProducerProps *properties.EventProducer
+}
+
+func NewCloudEventsConverter(
+	delegate EventConverter,
+	appProps *config.AppProperties,
+	eventProducerProps *properties.EventProducer,
+) (*CloudEventsConverter, error) {
+	for event, eventTopic := range eventProducerProps.EventMappings {
+		if eventTopic.CloudEventsMode != "" && eventTopic.Codec != "" && eventTopic.Codec != codec.Json {
+			return nil, fmt.Errorf("cloud events mode of event [%s] cannot be used with codec [%s]",
+				event, eventTopic.Codec)
+		}
+	}
+	return &CloudEventsConverter{
+		delegate:           delegate,
+		appProps:           appProps,
+		eventProducerProps: eventProducerProps,
+	}, nil
+}
+
+func (c CloudEventsConverter) Convert(evt pubsub.Event) (*core.Message, error) {
+	message, err := c.delegate.Convert(evt)
+	if err != nil {
+		return nil, err
+	}
+	mode := c.eventProducerProps.EventMappings[strings.ToLower(evt.Name())].CloudEventsMode
+	if mode == "" {
+		return message, nil
+	}
+	data, err := json.Marshal(evt.Payload())
+	if err != nil {
+		return nil, errors.WithMessage(err, "marshalling event payload failed")
+	}
+	ce := CloudEvent{
+		SpecVersion:     CloudEventsSpecVersion,
+		Id:              evt.Identifier(),
+		Source:          c.appProps.Name,
+		Type:            evt.Name(),
+		Time:            time.Now().Format(time.RFC3339Nano),
+		DataContentType: CloudEventsDataContentType,
+		Data:            data,
+	}
+	if err := c.setExtensions(evt, &ce); err != nil {
+		return nil, err
+	}
+	// The cloud event content type replaces the one advertised by the codec
+	message.Headers = removeHeader(message.Headers, kafkaConstant.HeaderContentType)
+	switch mode {
+	case kafkaConstant.CloudEventsModeBinary:
+		message.Value = data
+		message.Headers = append(message.Headers,
+			core.MessageHeader{Key: []byte(kafkaConstant.HeaderContentType), Value: []byte(ce.DataContentType)},
+			core.MessageHeader{Key: []byte(HeaderCeSpecVersion), Value: []byte(ce.SpecVersion)},
+			core.MessageHeader{Key: []byte(HeaderCeId), Value: []byte(ce.Id)},
+			core.MessageHeader{Key: []byte(HeaderCeSource), Value: []byte(ce.Source)},
+			core.MessageHeader{Key: []byte(HeaderCeType), Value: []byte(ce.Type)},
+			core.MessageHeader{Key: []byte(HeaderCeTime), Value: []byte(ce.Time)},
+		)
+		for key, value := range map[string]string{
+			HeaderCeServiceCode:       ce.ServiceCode,
+			HeaderCeRequestId:         ce.RequestId,
+			HeaderCeUserId:            ce.UserId,
+			HeaderCeTechnicalUsername: ce.TechnicalUsername,
+			HeaderCeAdditionalData:    ce.AdditionalData,
+		} {
+			if value != "" {
+				message.Headers = append(message.Headers, core.MessageHeader{Key: []byte(key), Value: []byte(value)})
+			}
+		}
+	case kafkaConstant.CloudEventsModeStructured:
+		value, err := json.Marshal(ce)
+		if err != nil {
+			return nil, errors.WithMessage(err, "marshalling cloud event failed")
+		}
+		message.Value = value
+		message.Headers = append(message.Headers,
+			core.MessageHeader{Key: []byte(kafkaConstant.HeaderContentType), Value: []byte(CloudEventsContentType)})
+	default:
+		return nil, fmt.Errorf("cloud events mode [%s] is not supported", mode)
+	}
+	return message, nil
+}
+
+func (c CloudEventsConverter) Restore(msg *core.ConsumerMessage, dest pubsub.Event) error {
+	ce, err := c.decode(msg)
+	if err != nil {
+		return err
+	}
+	if ce == nil {
+		return c.delegate.Restore(msg, dest)
+	}
+	var timestamp int64
+	if ce.Time != "" {
+		t, err := time.Parse(time.RFC3339Nano, ce.Time)
+		if err != nil {
+			return errors.WithMessage(err, "parse cloud event time failed")
+		}
+		timestamp = t.UnixMilli()
+	}
+	var additionalData map[string]interface{}
+	if ce.AdditionalData != "" {
+		if err := json.Unmarshal([]byte(ce.AdditionalData), &additionalData); err != nil {
+			return errors.WithMessage(err, "unmarshal cloud event additional data failed")
+		}
+	}
+	// Rebuild the event in the shape of a golib application event, then deserialize it into the destination
+	value, err := json.Marshal(map[string]interface{}{
+		"id":                 ce.Id,
+		"event":              ce.Type,
+		"source":             ce.Source,
+		"service_code":       ce.ServiceCode,
+		"request_id":         ce.RequestId,
+		"user_id":            ce.UserId,
+		"technical_username": ce.TechnicalUsername,
+		"additional_data":    additionalData,
+		"timestamp":          timestamp,
+		"payload":            ce.Data,
+	})
+	if err != nil {
+		return errors.WithMessage(err, "marshalling restored event failed")
+	}
+	if err := json.Unmarshal(value, dest); err != nil {
+		return errors.WithMessage(err, "unmarshal cloud event failed")
+	}
+	if restorer, ok := c.delegate.(EventAttributesRestorer); ok {
+		restorer.RestoreAttributes(msg, dest)
+	}
+	return nil
+}
+
+func (c CloudEventsConverter) RestoreAttributes(msg *core.ConsumerMessage, dest pubsub.Event) {
+	if restorer, ok := c.delegate.(EventAttributesRestorer); ok {
+		restorer.RestoreAttributes(msg, dest)
+	}
+}
+
+// decode returns the cloud event carried by the message,
+// or nil when the message is not a cloud event.
+func (c CloudEventsConverter) decode(msg *core.ConsumerMessage) (*CloudEvent, error) {
+	headers := make(map[string]string)
+	for _, header := range msg.Headers {
+		headers[strings.ToLower(string(header.Key))] = string(header.Value)
+	}
+	if specVersion, exists := headers[HeaderCeSpecVersion]; exists {
+		return &CloudEvent{
+			SpecVersion:       specVersion,
+			Id:                headers[HeaderCeId],
+			Source:            headers[HeaderCeSource],
+			Type:              headers[HeaderCeType],
+			Time:              headers[HeaderCeTime],
+			DataContentType:   headers[kafkaConstant.HeaderContentType],
+			ServiceCode:       headers[HeaderCeServiceCode],
+			RequestId:         headers[HeaderCeRequestId],
+			UserId:            headers[HeaderCeUserId],
+			TechnicalUsername: headers[HeaderCeTechnicalUsername],
+			AdditionalData:    headers[HeaderCeAdditionalData],
+			Data:              msg.Value,
+		}, nil
+	}
+	if strings.HasPrefix(headers[kafkaConstant.HeaderContentType], CloudEventsContentType) {
+		var ce CloudEvent
+		if err := json.Unmarshal(msg.Value, &ce); err != nil {
+			return nil, errors.WithMessage(err, "unmarshal structured cloud event failed")
+		}
+		return &ce, nil
+	}
+	return nil, nil
+}
+
+// setExtensions maps the time and the golib event fields to the cloud event
+func (c CloudEventsConverter) setExtensions(evt pubsub.Event, ce *CloudEvent) error {
+	var applicationEvent *event.ApplicationEvent
+	if we, ok := evt.(webEvent.AbstractEventWrapper); ok && we.GetAbstractEvent() != nil {
+		abstractEvent := we.GetAbstractEvent()
+		applicationEvent = abstractEvent.ApplicationEvent
+		ce.RequestId = abstractEvent.RequestId
+		ce.UserId = abstractEvent.UserId
+		ce.TechnicalUsername = abstractEvent.TechnicalUsername
+	} else if ae, ok := evt.(*event.ApplicationEvent); ok {
+		applicationEvent = ae
+	}
+	if applicationEvent == nil {
+		return nil
+	}
+	if applicationEvent.Timestamp != 0 {
+		ce.Time = time.UnixMilli(applicationEvent.Timestamp).Format(time.RFC3339Nano)
+	}
+	ce.ServiceCode = applicationEvent.ServiceCode
+	if len(applicationEvent.AdditionalData) > 0 {
+		additionalData, err := json.Marshal(applicationEvent.AdditionalData)
+		if err != nil {
+			return errors.WithMessage(err, "marshalling event additional data failed")
+		}
+		ce.AdditionalData = string(additionalData)
+	}
+	return nil
+}
+
+func removeHeader(headers []core.MessageHeader, key string) []core.MessageHeader {
+	result := make([]core.MessageHeader, 0, len(headers))
+	for _, header := range headers {
+		if !strings.EqualFold(string(header.Key), key) {
+			result = append(result, header)
+		}
+	}
+	return result
+}
@@ -0,0 +1,126 @@
+package relayer
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/golibs-starter/golib-message-bus/kafka/codec"
+	kafkaConstant "github.com/golibs-starter/golib-message-bus/kafka/constant"
+	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"github.com/golibs-starter/golib-message-bus/kafka/properties"
+	"github.com/golibs-starter/golib/config"
+	assert "github.com/stretchr/testify/require"
+	"testing"
+)
+
+func newTestCloudEventsConverter(t *testing.T, mode string) *CloudEventsConverter {
+	appProps := &config.AppProperties{Name: "TestApp"}
+	eventProducerProps := &properties.EventProducer{EventMappings: map[string]properties.EventTopic{
+		"testevent": {TopicName: "c1.test.topic", CloudEventsMode: mode},
+	}}
+	converter, err := NewCloudEventsConverter(NewDefaultEventConverter(appProps, eventProducerProps),
+		appProps, eventProducerProps)
+	assert.NoError(t, err)
+	return converter
+}
+
+func newTestCloudEventsEvent() *TestEvent {
+	evt := newTestEvent(context.Background(), map[string]interface{}{"field1": "val1"})
+	evt.ServiceCode = "service1"
+	evt.RequestId = "request1"
+	evt.UserId = "user1"
+	evt.TechnicalUsername = "technical1"
+	evt.AddAdditionData("device_id", "device1")
+	return evt
+}
+
+func assertRestoredEvent(t *testing.T, evt *TestEvent, restored *TestEvent) {
+	assert.Equal(t, evt.Identifier(), restored.Identifier())
+	assert.Equal(t, "TestEvent", restored.Name())
+	assert.Equal(t, "TestApp", restored.Source)
+	assert.Equal(t, evt.Timestamp, restored.Timestamp)
+	assert.Equal(t, "service1", restored.ServiceCode)
+	assert.Equal(t, "request1", restored.RequestId)
+	assert.Equal(t, "user1", restored.UserId)
+	assert.Equal(t, "technical1", restored.TechnicalUsername)
+	assert.Equal(t, map[string]interface{}{"device_id": "device1"}, restored.AdditionalData)
+	assert.Equal(t, map[string]interface{}{"field1": "val1"}, restored.Payload())
+}
+
+func toConsumerMessage(msg *core.Message) *core.ConsumerMessage {
+	return &core.ConsumerMessage{Topic: msg.Topic, Key: msg.Key, Value: msg.Value, Headers: msg.Headers}
+}
+
+func headerValue(headers []core.MessageHeader, key string) string {
+	for _, header := range headers {
+		if string(header.Key) == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+func TestCloudEventsConverter_WhenBinaryMode_ShouldPutAttributesInHeaders(t *testing.T) {
+	converter := newTestCloudEventsConverter(t, kafkaConstant.CloudEventsModeBinary)
+	evt := newTestCloudEventsEvent()
+
+	msg, err := converter.Convert(evt)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"field1":"val1"}`, string(msg.Value))
+	assert.Equal(t, "1.0", headerValue(msg.Headers, HeaderCeSpecVersion))
+	assert.Equal(t, evt.Identifier(), headerValue(msg.Headers, HeaderCeId))
+	assert.Equal(t, "TestApp", headerValue(msg.Headers, HeaderCeSource))
+	assert.Equal(t, "TestEvent", headerValue(msg.Headers, HeaderCeType))
+	assert.NotEmpty(t, headerValue(msg.Headers, HeaderCeTime))
+	assert.Equal(t, "application/json", headerValue(msg.Headers, kafkaConstant.HeaderContentType))
+	assert.Equal(t, "service1", headerValue(msg.Headers, HeaderCeServiceCode))
+	assert.Equal(t, "user1", headerValue(msg.Headers, HeaderCeUserId))
+	assert.JSONEq(t, `{"device_id":"device1"}`, headerValue(msg.Headers, HeaderCeAdditionalData))
+
+	restored := &TestEvent{}
+	assert.NoError(t, converter.Restore(toConsumerMessage(msg), restored))
+	assertRestoredEvent(t, evt, restored)
+}
+
+func TestCloudEventsConverter_WhenStructuredMode_ShouldEncodeEnvelope(t *testing.T) {
+	converter := newTestCloudEventsConverter(t, kafkaConstant.CloudEventsModeStructured)
+	evt := newTestCloudEventsEvent()
+
+	msg, err := converter.Convert(evt)
+	assert.NoError(t, err)
+	assert.Equal(t, CloudEventsContentType, headerValue(msg.Headers, kafkaConstant.HeaderContentType))
+	var ce CloudEvent
+	assert.NoError(t, json.Unmarshal(msg.Value, &ce))
+	assert.Equal(t, "1.0", ce.SpecVersion)
+	assert.Equal(t, evt.Identifier(), ce.Id)
+	assert.Equal(t, "TestApp", ce.Source)
+	assert.Equal(t, "TestEvent", ce.Type)
+	assert.JSONEq(t, `{"field1":"val1"}`, string(ce.Data))
+	assert.Equal(t, "service1", ce.ServiceCode)
+	assert.Equal(t, "technical1", ce.TechnicalUsername)
+
+	restored := &TestEvent{}
+	assert.NoError(t, converter.Restore(toConsumerMessage(msg), restored))
+	assertRestoredEvent(t, evt, restored)
+}
+
+func TestCloudEventsConverter_WhenModeIsNotProvided_ShouldDelegate(t *testing.T) {
+	converter := newTestCloudEventsConverter(t, "")
+	evt := newTestEvent(context.Background(), map[string]interface{}{"field1": "val1"})
+
+	msg, err := converter.Convert(evt)
+	assert.NoError(t, err)
+	assert.Empty(t, headerValue(msg.Headers, HeaderCeSpecVersion))
+
+	restored := &TestEvent{}
+	assert.NoError(t, converter.Restore(toConsumerMessage(msg), restored))
+	assert.Equal(t, evt.Identifier(), restored.Identifier())
+}
+
+func TestNewCloudEventsConverter_WhenCodecIsNotJson_ShouldReturnError(t *testing.T) {
+	eventProducerProps := &properties.EventProducer{EventMappings: map[string]properties.EventTopic{
+		"testevent": {TopicName: "c1.test.topic", CloudEventsMode: kafkaConstant.CloudEventsModeBinary,
+			Codec: codec.Msgpack},
+	}}
+	_, err := NewCloudEventsConverter(nil, &config.AppProperties{}, eventProducerProps)
+	assert.Error(t, err)
+}
@@ -2,11 +2,12 @@ package relayer
 
 import (
 	"context"
-	"encoding/json"
+	"github.com/golibs-starter/golib-message-bus/kafka/codec"
 	kafkaConstant "github.com/golibs-starter/golib-message-bus/kafka/constant"
 	"github.com/golibs-starter/golib-message-bus/kafka/core"
 	"github.com/golibs-starter/golib-message-bus/kafka/properties"
 	"github.com/golibs-starter/golib/config"
+	"github.com/golibs-starter/golib/event"
 	"github.com/golibs-starter/golib/pubsub"
 	"github.com/golibs-starter/golib/web/constant"
 	webEvent "github.com/golibs-starter/golib/web/event"
@@ -18,22 +19,38 @@ import (
 type DefaultEventConverter struct {
 	appProps           *config.AppProperties
 	eventProducerProps *properties.EventProducer
+	codecs             *codec.Registry
 }
 
 func NewDefaultEventConverter(
 	appProps *config.AppProperties,
 	eventProducerProps *properties.EventProducer,
+) *DefaultEventConverter {
+	return NewDefaultEventConverterWithCodecs(appProps, eventProducerProps, codec.NewRegistry())
+}
+
+// NewDefaultEventConverterWithCodecs creates a DefaultEventConverter
+// serializing events with the codecs selected in their EventTopic.
+func NewDefaultEventConverterWithCodecs(
+	appProps *config.AppProperties,
+	eventProducerProps *properties.EventProducer,
+	codecs *codec.Registry,
 ) *DefaultEventConverter {
 	return &DefaultEventConverter{
 		appProps:           appProps,
 		eventProducerProps: eventProducerProps,
+		codecs:             codecs,
 	}
 }
 
 func (d DefaultEventConverter) Convert(event pubsub.Event) (*core.Message, error) {
 	lcEvent := strings.ToLower(event.Name())
 	eventTopic := d.eventProducerProps.EventMappings[lcEvent]
-	msgBytes, err := json.Marshal(event)
+	msgCodec, err := d.producerCodec(eventTopic)
+	if err != nil {
+		return nil, err
+	}
+	msgBytes, err := msgCodec.Marshal(event)
 	if err != nil {
 		return nil, errors.WithMessage(err, "marshalling event failed")
 	}
@@ -56,6 +73,13 @@ func (d DefaultEventConverter) Convert(event pubsub.Event) (*core.Message, error
 		},
 	}
 
+	if eventTopic.Codec != "" {
+		message.Headers = append(message.Headers, core.MessageHeader{
+			Key:   []byte(kafkaConstant.HeaderContentType),
+			Value: []byte(msgCodec.ContentType()),
+		})
+	}
+
 	if evtOrderable, ok := event.(EventOrderable); ok {
 		message.Key = []byte(evtOrderable.OrderingKey())
 	}
@@ -69,21 +93,64 @@ func (d DefaultEventConverter) Convert(event pubsub.Event) (*core.Message, error
 		message.Headers = d.appendMsgHeaders(message.Headers, webAbsEvent)
 		message.Metadata = d.appendMsgMetadata(message.Metadata.(map[string]interface{}), webAbsEvent)
 	}
+
+	if eventTopic.EventNameHeader {
+		message.Headers = append(message.Headers, core.MessageHeader{
+			Key:   []byte(kafkaConstant.HeaderEventName),
+			Value: []byte(event.Name()),
+		})
+	}
 	return &message, nil
 }
 
 func (d DefaultEventConverter) Restore(msg *core.ConsumerMessage, dest pubsub.Event) error {
-	if err := json.Unmarshal(msg.Value, dest); err != nil {
+	msgCodec, err := d.consumerCodec(msg)
+	if err != nil {
+		return err
+	}
+	if err := msgCodec.Unmarshal(msg.Value, dest); err != nil {
 		return errors.WithMessage(err, "unmarshal consumer message failed")
 	}
+	d.RestoreAttributes(msg, dest)
+	return nil
+}
+
+// producerCodec returns the codec selected in the event topic, JSON is used by default
+func (d DefaultEventConverter) producerCodec(eventTopic properties.EventTopic) (codec.Codec, error) {
+	if eventTopic.Codec == "" {
+		return codec.NewJsonCodec(), nil
+	}
+	return d.codecs.Get(eventTopic.Codec)
+}
+
+// consumerCodec returns the codec advertised by the content-type header of the message,
+// JSON is used when the header is absent
+func (d DefaultEventConverter) consumerCodec(msg *core.ConsumerMessage) (codec.Codec, error) {
+	for _, header := range msg.Headers {
+		if strings.EqualFold(string(header.Key), kafkaConstant.HeaderContentType) {
+			return d.codecs.GetByContentType(string(header.Value))
+		}
+	}
+	return codec.NewJsonCodec(), nil
+}
+
+// RestoreAttributes restores the web event attributes from the message headers
+// and from the already deserialized destination event.
+func (d DefaultEventConverter) RestoreAttributes(msg *core.ConsumerMessage, dest pubsub.Event) {
 	if we, ok := dest.(webEvent.AbstractEventWrapper); ok {
 		abstractEvent := we.GetAbstractEvent()
+		if abstractEvent == nil {
+			return
+		}
+		if abstractEvent.ApplicationEvent == nil {
+			// The payload may be deserialized without the application event, eg: when using a schema
+			abstractEvent.ApplicationEvent = &event.ApplicationEvent{}
+		}
 		var attributes webEvent.Attributes
 		d.restoreAttributesFromHeaders(msg.Headers, &attributes)
 		d.restoreAttributesFromDeserializedEvent(abstractEvent, &attributes)
 		abstractEvent.Ctx = context.WithValue(context.Background(), constant.ContextEventAttributes, &attributes)
 	}
-	return nil
 }
 
 func (d DefaultEventConverter) restoreAttributesFromHeaders(headers []core.MessageHeader, attributes *webEvent.Attributes) {
@@ -13,3 +13,11 @@ type EventConverter interface {
 	// Restore a consumed message back to destination event
 	Restore(msg *core.ConsumerMessage, dest pubsub.Event) error
 }
+
+// EventAttributesRestorer is implemented by converters which can restore
+// the event attributes independently of the way the payload is deserialized.
+type EventAttributesRestorer interface {
+
+	// RestoreAttributes restore the event attributes from a consumed message
+	RestoreAttributes(msg *core.ConsumerMessage, dest pubsub.Event)
+}
@@ -1,6 +1,7 @@
 package relayer
 
 import (
+	kafkaConstant "github.com/golibs-starter/golib-message-bus/kafka/constant"
 	"github.com/golibs-starter/golib-message-bus/kafka/core"
 	"github.com/golibs-starter/golib-message-bus/kafka/log"
 	"github.com/golibs-starter/golib-message-bus/kafka/properties"
@@ -12,17 +13,32 @@ import (
 
 type EventMessageRelayer struct {
 	producer               core.SyncProducer
+	asyncProducer          core.AsyncProducer
 	eventProducerProps     *properties.EventProducer
 	eventProps             *event.Properties
 	notLogPayloadForEvents map[string]bool
 	eventConverter         EventConverter
 }
 
+// NewEventMessageRelayer creates a relayer producing events with the sync producer,
+// use NewEventMessageRelayerWithAsyncProducer to support the async and fire-and-forget delivery modes.
 func NewEventMessageRelayer(
 	producer core.SyncProducer,
 	eventProducerProps *properties.EventProducer,
 	eventProps *event.Properties,
 	eventConverter EventConverter,
+) pubsub.Subscriber {
+	return NewEventMessageRelayerWithAsyncProducer(producer, nil, eventProducerProps, eventProps, eventConverter)
+}
+
+// NewEventMessageRelayerWithAsyncProducer creates a relayer producing events in async
+// and fire-and-forget delivery modes with the async producer.
+func NewEventMessageRelayerWithAsyncProducer(
+	producer core.SyncProducer,
+	asyncProducer core.AsyncProducer,
+	eventProducerProps *properties.EventProducer,
+	eventProps *event.Properties,
+	eventConverter EventConverter,
 ) pubsub.Subscriber {
 	notLogPayloadForEvents := make(map[string]bool)
 	for _, e := range eventProps.Log.NotLogPayloadForEvents {
@@ -30,6 +46,7 @@ func NewEventMessageRelayer(
 	}
 	return &EventMessageRelayer{
 		producer:               producer,
+		asyncProducer:          asyncProducer,
 		eventProducerProps:     eventProducerProps,
 		eventProps:             eventProps,
 		notLogPayloadForEvents: notLogPayloadForEvents,
@@ -53,6 +70,11 @@ func (e EventMessageRelayer) Supports(event pubsub.Event) bool {
 		logger.Errorf("Cannot find topic for event [%s]", event.Name())
 		return false
 	}
+	if eventTopic.DeliveryMode == kafkaConstant.DeliveryModeOutbox {
+		logger.Warnf("Event [%s] is mapped with the outbox delivery mode, it is not produced when it is published. "+
+			"Write it with outbox.EventWriter inside your transaction", event.Name())
+		return false
+	}
 	return true
 }
 
@@ -63,12 +85,44 @@ func (e EventMessageRelayer) Handle(event pubsub.Event) {
 		logger.WithErrors(err).Error("Error while converting event to kafka message")
 		return
 	}
+	eventTopic := e.eventProducerProps.EventMappings[strings.ToLower(event.Name())]
+	switch eventTopic.DeliveryMode {
+	case "", kafkaConstant.DeliveryModeSync:
+		e.sendSync(event, message)
+	case kafkaConstant.DeliveryModeAsync, kafkaConstant.DeliveryModeFireAndForget:
+		e.sendAsync(event, message, eventTopic.DeliveryMode)
+	default:
+		logger.Errorf("Delivery mode [%s] is not supported for event [%s]", eventTopic.DeliveryMode, event.Name())
+	}
+}
+
+func (e EventMessageRelayer) sendSync(event pubsub.Event, message *core.Message) {
+	logger := coreLog.WithCtx(event.Context())
 	partition, offset, err := e.producer.Send(message)
 	if err != nil {
 		logger.WithErrors(err).Errorf("Error while producing kafka message %s",
 			log.DescMessage(message, e.eventProps.Log.NotLogPayloadForEvents))
 		return
 	}
+	if partition == core.PartitionSpooled {
+		logger.Warnf("Kafka message is spooled, it will be produced once the cluster is reachable, message %s",
+			log.DescMessage(message, e.eventProps.Log.NotLogPayloadForEvents))
+		return
+	}
 	logger.Infof("Success to produce to kafka partition [%d], offset [%d], message %s",
 		partition, offset, log.DescMessage(message, e.eventProps.Log.NotLogPayloadForEvents))
 }
+
+func (e EventMessageRelayer) sendAsync(event pubsub.Event, message *core.Message, deliveryMode string) {
+	if e.asyncProducer == nil {
+		coreLog.WithCtx(event.Context()).Errorf("Async producer is not available, cannot relay event [%s] in [%s] mode",
+			event.Name(), deliveryMode)
+		return
+	}
+	// The delivery result is reported by the async producer handlers,
+	// they rely on the metadata to decide whether the success should be logged.
+	if metadata, ok := message.Metadata.(map[string]interface{}); ok {
+		metadata[kafkaConstant.DeliveryMode] = deliveryMode
+	}
+	e.asyncProducer.Send(message)
+}
@@ -3,6 +3,7 @@ package relayer
 import (
 	"context"
 	"encoding/json"
+	"github.com/golibs-starter/golib-message-bus/kafka/codec"
 	kafkaConstant "github.com/golibs-starter/golib-message-bus/kafka/constant"
 	"github.com/golibs-starter/golib-message-bus/kafka/core"
 	"github.com/golibs-starter/golib-message-bus/kafka/properties"
@@ -29,6 +30,26 @@ func (t *TestProducer) Close() error {
 	return nil
 }
 
+type TestAsyncProducer struct {
+	messages []*core.Message
+}
+
+func (t *TestAsyncProducer) Send(m *core.Message) {
+	t.messages = append(t.messages, m)
+}
+
+func (t *TestAsyncProducer) Successes() <-chan *core.Message {
+	return nil
+}
+
+func (t *TestAsyncProducer) Errors() <-chan *core.ProducerError {
+	return nil
+}
+
+func (t *TestAsyncProducer) Close() error {
+	return nil
+}
+
 type TestEvent struct {
 	*webEvent.AbstractEvent
 }
@@ -93,6 +114,18 @@ func TestEventMessageRelayer_WhenEventTopicNameIsEmpty_ShouldNotSupport(t *testi
 	assert.False(t, listener.Supports(webEvent.NewAbstractEvent(context.Background(), "TestEvent")))
 }
 
+func TestEventMessageRelayer_WhenDeliveryModeIsOutbox_ShouldNotSupport(t *testing.T) {
+	producer := &TestProducer{}
+	appProps := &config.AppProperties{Name: "TestApp"}
+	eventProducerProps := &properties.EventProducer{EventMappings: map[string]properties.EventTopic{
+		"testevent": {TopicName: "test.topic", DeliveryMode: kafkaConstant.DeliveryModeOutbox},
+	}}
+	eventProps := &event.Properties{}
+	converter := NewDefaultEventConverter(appProps, eventProducerProps)
+	listener := NewEventMessageRelayer(producer, eventProducerProps, eventProps, converter)
+	assert.False(t, listener.Supports(webEvent.NewAbstractEvent(context.Background(), "TestEvent")))
+}
+
 func TestEventMessageRelayer_WhenEventTopicIsEnabled_ShouldSupport(t *testing.T) {
 	producer := &TestProducer{}
 	appProps := &config.AppProperties{Name: "TestApp"}
@@ -237,3 +270,94 @@ func TestEventMessageRelayer_WhenIsWebEventAndNotLogPayload_ShouldSuccess(t *tes
 	listener.Handle(testEvent)
 	assert.NotNil(t, producer.message)
 }
+
+func TestEventMessageRelayer_WhenDeliveryModeIsAsync_ShouldSendMessageByAsyncProducer(t *testing.T) {
+	producer := &TestProducer{}
+	asyncProducer := &TestAsyncProducer{}
+	appProps := &config.AppProperties{Name: "TestApp"}
+	eventProducerProps := &properties.EventProducer{EventMappings: map[string]properties.EventTopic{
+		"testevent": {TopicName: "test.topic", DeliveryMode: kafkaConstant.DeliveryModeAsync},
+	}}
+	eventProps := &event.Properties{}
+	converter := NewDefaultEventConverter(appProps, eventProducerProps)
+	listener := NewEventMessageRelayerWithAsyncProducer(producer, asyncProducer, eventProducerProps, eventProps, converter)
+	testEvent := newTestEvent(context.Background(), "TestEvent")
+	listener.Handle(testEvent)
+
+	assert.Nil(t, producer.message)
+	assert.Len(t, asyncProducer.messages, 1)
+	assert.Equal(t, "test.topic", asyncProducer.messages[0].Topic)
+	resultMetadata := asyncProducer.messages[0].Metadata.(map[string]interface{})
+	assert.Equal(t, testEvent.Identifier(), resultMetadata[kafkaConstant.EventId])
+	assert.Equal(t, kafkaConstant.DeliveryModeAsync, resultMetadata[kafkaConstant.DeliveryMode])
+}
+
+func TestEventMessageRelayer_WhenDeliveryModeIsFireAndForget_ShouldSendMessageByAsyncProducer(t *testing.T) {
+	producer := &TestProducer{}
+	asyncProducer := &TestAsyncProducer{}
+	appProps := &config.AppProperties{Name: "TestApp"}
+	eventProducerProps := &properties.EventProducer{EventMappings: map[string]properties.EventTopic{
+		"testevent": {TopicName: "test.topic", DeliveryMode: kafkaConstant.DeliveryModeFireAndForget},
+	}}
+	eventProps := &event.Properties{}
+	converter := NewDefaultEventConverter(appProps, eventProducerProps)
+	listener := NewEventMessageRelayerWithAsyncProducer(producer, asyncProducer, eventProducerProps, eventProps, converter)
+	listener.Handle(newTestEvent(context.Background(), "TestEvent"))
+
+	assert.Nil(t, producer.message)
+	assert.Len(t, asyncProducer.messages, 1)
+	resultMetadata := asyncProducer.messages[0].Metadata.(map[string]interface{})
+	assert.Equal(t, kafkaConstant.DeliveryModeFireAndForget, resultMetadata[kafkaConstant.DeliveryMode])
+}
+
+func TestEventMessageRelayer_WhenDeliveryModeIsNotSupported_ShouldNotSendMessage(t *testing.T) {
+	producer := &TestProducer{}
+	asyncProducer := &TestAsyncProducer{}
+	appProps := &config.AppProperties{Name: "TestApp"}
+	eventProducerProps := &properties.EventProducer{EventMappings: map[string]properties.EventTopic{
+		"testevent": {TopicName: "test.topic", DeliveryMode: "unknown"},
+	}}
+	eventProps := &event.Properties{}
+	converter := NewDefaultEventConverter(appProps, eventProducerProps)
+	listener := NewEventMessageRelayerWithAsyncProducer(producer, asyncProducer, eventProducerProps, eventProps, converter)
+	listener.Handle(newTestEvent(context.Background(), "TestEvent"))
+
+	assert.Nil(t, producer.message)
+	assert.Empty(t, asyncProducer.messages)
+}
+
+func TestEventMessageRelayer_WhenCodecIsProvided_ShouldSendMessageWithContentType(t *testing.T) {
+	producer := &TestProducer{}
+	appProps := &config.AppProperties{Name: "TestApp"}
+	eventProducerProps := &properties.EventProducer{EventMappings: map[string]properties.EventTopic{
+		"testevent": {TopicName: "test.topic", Codec: codec.Msgpack},
+	}}
+	eventProps := &event.Properties{}
+	converter := NewDefaultEventConverterWithCodecs(appProps, eventProducerProps, codec.NewRegistry())
+	listener := NewEventMessageRelayer(producer, eventProducerProps, eventProps, converter)
+	testEvent := newTestEvent(context.Background(), map[string]interface{}{"field1": "val1"})
+	listener.Handle(testEvent)
+
+	assert.NotNil(t, producer.message)
+	assert.Equal(t, "application/msgpack", headerValue(producer.message.Headers, kafkaConstant.HeaderContentType))
+
+	restored := &TestEvent{}
+	err := converter.Restore(&core.ConsumerMessage{Value: producer.message.Value, Headers: producer.message.Headers}, restored)
+	assert.NoError(t, err)
+	assert.Equal(t, testEvent.Identifier(), restored.Identifier())
+	assert.Equal(t, map[string]interface{}{"field1": "val1"}, restored.Payload())
+}
+
+func TestEventMessageRelayer_WhenEventNameHeaderIsEnabled_ShouldSendMessageWithEventName(t *testing.T) {
+	producer := &TestProducer{}
+	appProps := &config.AppProperties{Name: "TestApp"}
+	eventProducerProps := &properties.EventProducer{EventMappings: map[string]properties.EventTopic{
+		"testevent": {TopicName: "test.topic", EventNameHeader: true},
+	}}
+	converter := NewDefaultEventConverter(appProps, eventProducerProps)
+	listener := NewEventMessageRelayer(producer, eventProducerProps, &event.Properties{}, converter)
+	listener.Handle(newTestEvent(context.Background(), map[string]interface{}{"field1": "val1"}))
+
+	assert.NotNil(t, producer.message)
+	assert.Equal(t, "TestEvent", headerValue(producer.message.Headers, kafkaConstant.HeaderEventName))
+}
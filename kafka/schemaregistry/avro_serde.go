@@ -0,0 +1,68 @@
+package schemaregistry
+
+import (
+	"fmt"
+	"github.com/golibs-starter/golib/pubsub"
+	"github.com/hamba/avro/v2"
+	"github.com/pkg/errors"
+)
+
+// AvroEvent is an event serialized with an Avro schema.
+// Fields are mapped by the `avro` struct tag.
+type AvroEvent interface {
+
+	// AvroSchema returns the Avro schema of the event
+	AvroSchema() string
+}
+
+type AvroSerde struct {
+}
+
+func NewAvroSerde() *AvroSerde {
+	return &AvroSerde{}
+}
+
+func (a AvroSerde) SchemaType() string {
+	return SchemaTypeAvro
+}
+
+func (a AvroSerde) Supports(event pubsub.Event) bool {
+	_, ok := event.(AvroEvent)
+	return ok
+}
+
+func (a AvroSerde) Schema(event pubsub.Event) (*Schema, string, error) {
+	avroEvent, ok := event.(AvroEvent)
+	if !ok {
+		return nil, "", fmt.Errorf("event [%s] is not an AvroEvent", event.Name())
+	}
+	schema, err := avro.Parse(avroEvent.AvroSchema())
+	if err != nil {
+		return nil, "", errors.WithMessagef(err, "parse avro schema of event [%s] failed", event.Name())
+	}
+	namedSchema, ok := schema.(avro.NamedSchema)
+	if !ok {
+		return nil, "", fmt.Errorf("avro schema of event [%s] has to be a named schema", event.Name())
+	}
+	return &Schema{Schema: schema.String(), SchemaType: SchemaTypeAvro}, namedSchema.FullName(), nil
+}
+
+func (a AvroSerde) Serialize(event pubsub.Event) ([]byte, error) {
+	avroEvent, ok := event.(AvroEvent)
+	if !ok {
+		return nil, fmt.Errorf("event [%s] is not an AvroEvent", event.Name())
+	}
+	schema, err := avro.Parse(avroEvent.AvroSchema())
+	if err != nil {
+		return nil, errors.WithMessagef(err, "parse avro schema of event [%s] failed", event.Name())
+	}
+	return avro.Marshal(schema, event)
+}
+
+func (a AvroSerde) Deserialize(payload []byte, writer *Schema, dest pubsub.Event) error {
+	schema, err := avro.Parse(writer.Schema)
+	if err != nil {
+		return errors.WithMessage(err, "parse writer avro schema failed")
+	}
+	return avro.Unmarshal(schema, payload, dest)
+}
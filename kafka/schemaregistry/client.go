@@ -0,0 +1,32 @@
+package schemaregistry
+
+const SchemaTypeAvro = "AVRO"
+const SchemaTypeProtobuf = "PROTOBUF"
+
+// Client communicates with the schema registry
+type Client interface {
+
+	// GetSchema returns the schema registered with the given id
+	GetSchema(id int) (*Schema, error)
+
+	// Register registers the schema under the subject.
+	// Returns the schema id.
+	Register(subject string, schema *Schema) (int, error)
+
+	// Lookup returns the id of the schema already registered under the subject.
+	Lookup(subject string, schema *Schema) (int, error)
+}
+
+// Schema is a schema stored in the schema registry
+type Schema struct {
+	Schema     string `json:"schema"`
+	SchemaType string `json:"schemaType,omitempty"`
+}
+
+// Type returns the schema type, AVRO is the default type of the schema registry
+func (s Schema) Type() string {
+	if s.SchemaType == "" {
+		return SchemaTypeAvro
+	}
+	return s.SchemaType
+}
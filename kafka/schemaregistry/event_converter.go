@@ -0,0 +1,137 @@
+package schemaregistry
+
+import (
+	"fmt"
+	"github.com/golibs-starter/golib-message-bus/kafka/constant"
+	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"github.com/golibs-starter/golib-message-bus/kafka/properties"
+	"github.com/golibs-starter/golib-message-bus/kafka/relayer"
+	"github.com/golibs-starter/golib/pubsub"
+	"github.com/pkg/errors"
+	"strings"
+)
+
+// EventConverter is a relayer.EventConverter that serializes events supported by one of the serdes
+// in the schema registry wire format. Other events are handled by the delegate converter.
+type EventConverter struct {
+	delegate relayer.EventConverter
+	client   Client
+	props    *properties.SchemaRegistry
+	serdes   []Serde
+}
+
+func NewEventConverter(
+	delegate relayer.EventConverter,
+	client Client,
+	props *properties.SchemaRegistry,
+	serdes ...Serde,
+) *EventConverter {
+	return &EventConverter{
+		delegate: delegate,
+		client:   client,
+		props:    props,
+		serdes:   serdes,
+	}
+}
+
+func (c EventConverter) Convert(event pubsub.Event) (*core.Message, error) {
+	message, err := c.delegate.Convert(event)
+	if err != nil {
+		return nil, err
+	}
+	serde := c.serdeForEvent(event)
+	if serde == nil {
+		return message, nil
+	}
+	schema, recordName, err := serde.Schema(event)
+	if err != nil {
+		return nil, err
+	}
+	subject, err := SubjectName(c.props.SubjectNameStrategy, message.Topic, recordName)
+	if err != nil {
+		return nil, err
+	}
+	var schemaId int
+	if c.props.AutoRegisterSchemas {
+		schemaId, err = c.client.Register(subject, schema)
+	} else {
+		schemaId, err = c.client.Lookup(subject, schema)
+	}
+	if err != nil {
+		return nil, errors.WithMessagef(err, "resolve schema of event [%s] failed", event.Name())
+	}
+	payload, err := serde.Serialize(event)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "serialize event [%s] with %s failed", event.Name(), serde.SchemaType())
+	}
+	message.Value = EncodeWireFormat(schemaId, payload)
+	message.Headers = append(removeContentType(message.Headers), core.MessageHeader{
+		Key:   []byte(constant.HeaderContentType),
+		Value: []byte(wireFormatContentType(serde.SchemaType())),
+	})
+	return message, nil
+}
+
+func (c EventConverter) Restore(msg *core.ConsumerMessage, dest pubsub.Event) error {
+	if !isWireFormatMessage(msg) {
+		return c.delegate.Restore(msg, dest)
+	}
+	schemaId, payload, err := DecodeWireFormat(msg.Value)
+	if err != nil {
+		return err
+	}
+	schema, err := c.client.GetSchema(schemaId)
+	if err != nil {
+		return errors.WithMessage(err, "get writer schema failed")
+	}
+	serde := c.serdeForType(schema.Type())
+	if serde == nil {
+		return fmt.Errorf("schema type [%s] is not supported", schema.Type())
+	}
+	if err := serde.Deserialize(payload, schema, dest); err != nil {
+		return errors.WithMessagef(err, "deserialize consumer message with %s failed", serde.SchemaType())
+	}
+	if restorer, ok := c.delegate.(relayer.EventAttributesRestorer); ok {
+		restorer.RestoreAttributes(msg, dest)
+	}
+	return nil
+}
+
+// isWireFormatMessage trusts the content-type header when it's present, the magic byte is only checked
+// for messages without it, such as the ones produced by other schema registry clients.
+func isWireFormatMessage(msg *core.ConsumerMessage) bool {
+	for _, header := range msg.Headers {
+		if strings.EqualFold(string(header.Key), constant.HeaderContentType) {
+			return strings.HasPrefix(strings.ToLower(string(header.Value)), WireFormatContentType)
+		}
+	}
+	return IsWireFormat(msg.Value)
+}
+
+func removeContentType(headers []core.MessageHeader) []core.MessageHeader {
+	kept := make([]core.MessageHeader, 0, len(headers)+1)
+	for _, header := range headers {
+		if !strings.EqualFold(string(header.Key), constant.HeaderContentType) {
+			kept = append(kept, header)
+		}
+	}
+	return kept
+}
+
+func (c EventConverter) serdeForEvent(event pubsub.Event) Serde {
+	for _, serde := range c.serdes {
+		if serde.Supports(event) {
+			return serde
+		}
+	}
+	return nil
+}
+
+func (c EventConverter) serdeForType(schemaType string) Serde {
+	for _, serde := range c.serdes {
+		if serde.SchemaType() == schemaType {
+			return serde
+		}
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+package schemaregistry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	kafkaConstant "github.com/golibs-starter/golib-message-bus/kafka/constant"
+	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"github.com/golibs-starter/golib-message-bus/kafka/properties"
+	"github.com/golibs-starter/golib-message-bus/kafka/relayer"
+	"github.com/golibs-starter/golib/config"
+	"github.com/golibs-starter/golib/web/constant"
+	webEvent "github.com/golibs-starter/golib/web/event"
+	assert "github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// testRegistry is an in-process stand-in of the schema registry REST API
+type testRegistry struct {
+	mu       sync.Mutex
+	schemas  []Schema
+	subjects map[string]int
+	requests int
+}
+
+func newTestRegistry() (*testRegistry, *httptest.Server) {
+	registry := &testRegistry{subjects: map[string]int{}}
+	return registry, httptest.NewServer(http.HandlerFunc(registry.serve))
+}
+
+func (r *testRegistry) serve(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests++
+	w.Header().Set("Content-Type", contentType)
+	var id int
+	if _, err := fmt.Sscanf(req.URL.Path, "/schemas/ids/%d", &id); err == nil {
+		if id < 1 || id > len(r.schemas) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(r.schemas[id-1])
+		return
+	}
+	var schema Schema
+	_ = json.NewDecoder(req.Body).Decode(&schema)
+	path := strings.TrimPrefix(req.URL.Path, "/subjects/")
+	if subject := strings.TrimSuffix(path, "/versions"); subject != path {
+		key := subject + schema.Schema
+		if _, exists := r.subjects[key]; !exists {
+			r.schemas = append(r.schemas, schema)
+			r.subjects[key] = len(r.schemas)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]int{"id": r.subjects[key]})
+		return
+	}
+	if id, exists := r.subjects[path+schema.Schema]; exists {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"subject": path, "id": id})
+		return
+	}
+	w.WriteHeader(http.StatusNotFound)
+}
+
+type TestAvroEvent struct {
+	*webEvent.AbstractEvent `avro:"-"`
+	OrderId                 string `avro:"order_id"`
+	Amount                  int64  `avro:"amount"`
+}
+
+func (t TestAvroEvent) AvroSchema() string {
+	return `{"type":"record","name":"OrderCreated","namespace":"com.example","fields":[
+		{"name":"order_id","type":"string"},{"name":"amount","type":"long"}]}`
+}
+
+type TestProtobufEvent struct {
+	*webEvent.AbstractEvent
+	Order *timestamppb.Timestamp
+}
+
+func (t TestProtobufEvent) ProtoPayload() proto.Message {
+	return t.Order
+}
+
+func (t TestProtobufEvent) ProtoSchema() string {
+	return `syntax = "proto3"; package google.protobuf; message Timestamp { int64 seconds = 1; int32 nanos = 2; }`
+}
+
+func newTestConverter(t *testing.T, serverUrl string, autoRegister bool) *EventConverter {
+	appProps := &config.AppProperties{Name: "TestApp"}
+	eventProducerProps := &properties.EventProducer{EventMappings: map[string]properties.EventTopic{
+		"testevent": {TopicName: "test.topic"},
+	}}
+	props := &properties.SchemaRegistry{
+		Url:                 serverUrl,
+		AutoRegisterSchemas: autoRegister,
+		SubjectNameStrategy: SubjectNameStrategyTopic,
+	}
+	client, err := NewHttpClient(props)
+	assert.NoError(t, err)
+	return NewEventConverter(relayer.NewDefaultEventConverter(appProps, eventProducerProps), client, props,
+		NewAvroSerde(), NewProtobufSerde())
+}
+
+func TestEventConverter_WhenAvroEvent_ShouldConvertAndRestoreInWireFormat(t *testing.T) {
+	registry, server := newTestRegistry()
+	defer server.Close()
+	converter := newTestConverter(t, server.URL, true)
+
+	event := &TestAvroEvent{
+		AbstractEvent: webEvent.NewAbstractEvent(context.Background(), "TestEvent"),
+		OrderId:       "order-1",
+		Amount:        100,
+	}
+	message, err := converter.Convert(event)
+	assert.NoError(t, err)
+	assert.Equal(t, "test.topic", message.Topic)
+	assert.True(t, IsWireFormat(message.Value))
+	assert.Contains(t, message.Headers, core.MessageHeader{
+		Key: []byte(kafkaConstant.HeaderContentType), Value: []byte("application/vnd.schemaregistry+avro"),
+	})
+	schemaId, _, err := DecodeWireFormat(message.Value)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, schemaId)
+	assert.Equal(t, 1, registry.subjects["test.topic-value"+registry.schemas[0].Schema])
+
+	// Schema id is cached, the registry is not called again
+	_, err = converter.Convert(event)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, registry.requests)
+
+	restored := &TestAvroEvent{AbstractEvent: &webEvent.AbstractEvent{}}
+	err = converter.Restore(&core.ConsumerMessage{
+		Value: message.Value,
+		Headers: []core.MessageHeader{
+			{Key: []byte(constant.HeaderCorrelationId), Value: []byte("test-request-id")},
+		},
+	}, restored)
+	assert.NoError(t, err)
+	assert.Equal(t, "order-1", restored.OrderId)
+	assert.Equal(t, int64(100), restored.Amount)
+	assert.Equal(t, "test-request-id", webEvent.GetAttributes(restored.Context()).CorrelationId)
+}
+
+func TestEventConverter_WhenProtobufEvent_ShouldConvertAndRestoreInWireFormat(t *testing.T) {
+	registry, server := newTestRegistry()
+	defer server.Close()
+	converter := newTestConverter(t, server.URL, true)
+
+	event := &TestProtobufEvent{
+		AbstractEvent: webEvent.NewAbstractEvent(context.Background(), "TestEvent"),
+		Order:         &timestamppb.Timestamp{Seconds: 1663493407},
+	}
+	message, err := converter.Convert(event)
+	assert.NoError(t, err)
+	assert.Equal(t, SchemaTypeProtobuf, registry.schemas[0].SchemaType)
+	_, payload, err := DecodeWireFormat(message.Value)
+	assert.NoError(t, err)
+	assert.Equal(t, byte(0), payload[0], "first message of the file must be encoded as a single 0 index")
+
+	restored := &TestProtobufEvent{AbstractEvent: &webEvent.AbstractEvent{}, Order: &timestamppb.Timestamp{}}
+	assert.NoError(t, converter.Restore(&core.ConsumerMessage{Value: message.Value}, restored))
+	assert.Equal(t, int64(1663493407), restored.Order.Seconds)
+}
+
+func TestEventConverter_WhenAutoRegisterDisabledAndSchemaNotRegistered_ShouldReturnError(t *testing.T) {
+	_, server := newTestRegistry()
+	defer server.Close()
+	converter := newTestConverter(t, server.URL, false)
+
+	_, err := converter.Convert(&TestAvroEvent{AbstractEvent: webEvent.NewAbstractEvent(context.Background(), "TestEvent")})
+	assert.Error(t, err)
+}
+
+func TestEventConverter_WhenEventHasNoSchema_ShouldUseDelegate(t *testing.T) {
+	_, server := newTestRegistry()
+	defer server.Close()
+	converter := newTestConverter(t, server.URL, true)
+
+	event := webEvent.NewAbstractEvent(context.Background(), "TestEvent")
+	message, err := converter.Convert(event)
+	assert.NoError(t, err)
+	assert.False(t, IsWireFormat(message.Value))
+	expected, err := json.Marshal(event)
+	assert.NoError(t, err)
+	assert.Equal(t, string(expected), string(message.Value))
+
+	restored := &webEvent.AbstractEvent{}
+	assert.NoError(t, converter.Restore(&core.ConsumerMessage{Value: message.Value}, restored))
+	assert.Equal(t, event.Identifier(), restored.Identifier())
+}
+
+type TestRawEvent struct {
+	*webEvent.AbstractEvent
+	Data []byte
+}
+
+func (e *TestRawEvent) UnmarshalBinary(data []byte) error {
+	e.Data = append(e.Data[:0], data...)
+	return nil
+}
+
+func TestEventConverter_WhenValueStartsWithMagicByteButContentTypeIsNotWireFormat_ShouldUseDelegate(t *testing.T) {
+	registry, server := newTestRegistry()
+	defer server.Close()
+	converter := newTestConverter(t, server.URL, true)
+
+	value := []byte{0, 0, 0, 0, 1, 42}
+	restored := &TestRawEvent{AbstractEvent: &webEvent.AbstractEvent{}}
+	err := converter.Restore(&core.ConsumerMessage{
+		Value:   value,
+		Headers: []core.MessageHeader{{Key: []byte(kafkaConstant.HeaderContentType), Value: []byte("application/octet-stream")}},
+	}, restored)
+	assert.NoError(t, err)
+	assert.Equal(t, value, restored.Data)
+	assert.Equal(t, 0, registry.requests)
+}
+
+func TestSubjectName(t *testing.T) {
+	subject, err := SubjectName(SubjectNameStrategyTopic, "topic1", "com.example.Order")
+	assert.NoError(t, err)
+	assert.Equal(t, "topic1-value", subject)
+	subject, err = SubjectName(SubjectNameStrategyRecord, "topic1", "com.example.Order")
+	assert.NoError(t, err)
+	assert.Equal(t, "com.example.Order", subject)
+	subject, err = SubjectName(SubjectNameStrategyTopicRecord, "topic1", "com.example.Order")
+	assert.NoError(t, err)
+	assert.Equal(t, "topic1-com.example.Order", subject)
+	_, err = SubjectName("unknown", "topic1", "com.example.Order")
+	assert.Error(t, err)
+}
@@ -0,0 +1,126 @@
+package schemaregistry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/golibs-starter/golib-message-bus/kafka/properties"
+	"github.com/pkg/errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+const contentType = "application/vnd.schemaregistry.v1+json"
+
+// HttpClient is a Client using the schema registry REST API.
+// Schemas and ids are cached since they are immutable once registered.
+type HttpClient struct {
+	props        *properties.SchemaRegistry
+	httpClient   *http.Client
+	mu           sync.RWMutex
+	schemasById  map[int]*Schema
+	idsBySubject map[string]int
+}
+
+func NewHttpClient(props *properties.SchemaRegistry) (*HttpClient, error) {
+	if props.Url == "" {
+		return nil, errors.New("schema registry url is required")
+	}
+	return &HttpClient{
+		props:        props,
+		httpClient:   &http.Client{Timeout: props.Timeout},
+		schemasById:  make(map[int]*Schema),
+		idsBySubject: make(map[string]int),
+	}, nil
+}
+
+func (c *HttpClient) GetSchema(id int) (*Schema, error) {
+	c.mu.RLock()
+	schema, exists := c.schemasById[id]
+	c.mu.RUnlock()
+	if exists {
+		return schema, nil
+	}
+	var result Schema
+	if err := c.do(http.MethodGet, fmt.Sprintf("/schemas/ids/%d", id), nil, &result); err != nil {
+		return nil, errors.WithMessagef(err, "get schema [%d] failed", id)
+	}
+	c.mu.Lock()
+	c.schemasById[id] = &result
+	c.mu.Unlock()
+	return &result, nil
+}
+
+func (c *HttpClient) Register(subject string, schema *Schema) (int, error) {
+	return c.resolveId(subject, schema, "/subjects/%s/versions")
+}
+
+func (c *HttpClient) Lookup(subject string, schema *Schema) (int, error) {
+	return c.resolveId(subject, schema, "/subjects/%s")
+}
+
+func (c *HttpClient) resolveId(subject string, schema *Schema, pathFormat string) (int, error) {
+	cacheKey := c.cacheKey(subject, schema)
+	c.mu.RLock()
+	id, exists := c.idsBySubject[cacheKey]
+	c.mu.RUnlock()
+	if exists {
+		return id, nil
+	}
+	var result struct {
+		Id int `json:"id"`
+	}
+	if err := c.do(http.MethodPost, fmt.Sprintf(pathFormat, url.PathEscape(subject)), schema, &result); err != nil {
+		return 0, errors.WithMessagef(err, "resolve schema id of subject [%s] failed", subject)
+	}
+	c.mu.Lock()
+	c.idsBySubject[cacheKey] = result.Id
+	c.schemasById[result.Id] = schema
+	c.mu.Unlock()
+	return result.Id, nil
+}
+
+func (c *HttpClient) cacheKey(subject string, schema *Schema) string {
+	return subject + "\x00" + schema.Type() + "\x00" + schema.Schema
+}
+
+func (c *HttpClient) do(method string, path string, body interface{}, result interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return errors.WithMessage(err, "marshalling request failed")
+		}
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequest(method, strings.TrimRight(c.props.Url, "/")+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", contentType)
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.props.Username != "" {
+		req.SetBasicAuth(c.props.Username, c.props.Password)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errors.WithMessage(err, "read response failed")
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("schema registry responds status [%d], body [%s]", resp.StatusCode, string(respBody))
+	}
+	if err := json.Unmarshal(respBody, result); err != nil {
+		return errors.WithMessage(err, "unmarshal response failed")
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package schemaregistry
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/golibs-starter/golib/pubsub"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// ProtobufEvent is an event whose payload is serialized with Protobuf
+type ProtobufEvent interface {
+
+	// ProtoPayload returns the message to serialize.
+	// When restoring, it has to return a non nil message to deserialize into.
+	ProtoPayload() proto.Message
+
+	// ProtoSchema returns the .proto definition of the payload
+	ProtoSchema() string
+}
+
+type ProtobufSerde struct {
+}
+
+func NewProtobufSerde() *ProtobufSerde {
+	return &ProtobufSerde{}
+}
+
+func (p ProtobufSerde) SchemaType() string {
+	return SchemaTypeProtobuf
+}
+
+func (p ProtobufSerde) Supports(event pubsub.Event) bool {
+	_, ok := event.(ProtobufEvent)
+	return ok
+}
+
+func (p ProtobufSerde) Schema(event pubsub.Event) (*Schema, string, error) {
+	protobufEvent, ok := event.(ProtobufEvent)
+	if !ok {
+		return nil, "", fmt.Errorf("event [%s] is not a ProtobufEvent", event.Name())
+	}
+	payload := protobufEvent.ProtoPayload()
+	if payload == nil {
+		return nil, "", fmt.Errorf("protobuf payload of event [%s] is nil", event.Name())
+	}
+	recordName := string(payload.ProtoReflect().Descriptor().FullName())
+	return &Schema{Schema: protobufEvent.ProtoSchema(), SchemaType: SchemaTypeProtobuf}, recordName, nil
+}
+
+func (p ProtobufSerde) Serialize(event pubsub.Event) ([]byte, error) {
+	protobufEvent, ok := event.(ProtobufEvent)
+	if !ok {
+		return nil, fmt.Errorf("event [%s] is not a ProtobufEvent", event.Name())
+	}
+	payload := protobufEvent.ProtoPayload()
+	if payload == nil {
+		return nil, fmt.Errorf("protobuf payload of event [%s] is nil", event.Name())
+	}
+	data, err := proto.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return append(encodeMessageIndexes(payload.ProtoReflect().Descriptor()), data...), nil
+}
+
+func (p ProtobufSerde) Deserialize(payload []byte, _ *Schema, dest pubsub.Event) error {
+	protobufEvent, ok := dest.(ProtobufEvent)
+	if !ok {
+		return fmt.Errorf("destination event [%T] is not a ProtobufEvent", dest)
+	}
+	message := protobufEvent.ProtoPayload()
+	if message == nil {
+		return fmt.Errorf("protobuf payload of destination event [%T] is nil", dest)
+	}
+	data, err := skipMessageIndexes(payload)
+	if err != nil {
+		return err
+	}
+	return proto.Unmarshal(data, message)
+}
+
+// encodeMessageIndexes encodes the path of the message in its .proto file,
+// the first top level message is encoded as a single 0 byte.
+func encodeMessageIndexes(descriptor protoreflect.MessageDescriptor) []byte {
+	indexes := make([]int, 0)
+	var current protoreflect.Descriptor = descriptor
+	for {
+		messageDescriptor, ok := current.(protoreflect.MessageDescriptor)
+		if !ok {
+			break
+		}
+		indexes = append([]int{messageDescriptor.Index()}, indexes...)
+		current = messageDescriptor.Parent()
+	}
+	if len(indexes) == 1 && indexes[0] == 0 {
+		return []byte{0}
+	}
+	buf := make([]byte, 0, binary.MaxVarintLen64*(len(indexes)+1))
+	buf = binary.AppendVarint(buf, int64(len(indexes)))
+	for _, index := range indexes {
+		buf = binary.AppendVarint(buf, int64(index))
+	}
+	return buf
+}
+
+func skipMessageIndexes(payload []byte) ([]byte, error) {
+	count, n := binary.Varint(payload)
+	if n <= 0 {
+		return nil, errors.New("invalid protobuf message indexes")
+	}
+	payload = payload[n:]
+	for i := int64(0); i < count; i++ {
+		_, n = binary.Varint(payload)
+		if n <= 0 {
+			return nil, errors.New("invalid protobuf message indexes")
+		}
+		payload = payload[n:]
+	}
+	return payload, nil
+}
@@ -0,0 +1,22 @@
+package schemaregistry
+
+import "github.com/golibs-starter/golib/pubsub"
+
+// Serde serializes and deserializes events with a schema stored in the schema registry
+type Serde interface {
+
+	// SchemaType returns the type of schemas handled by this serde
+	SchemaType() string
+
+	// Supports reports whether the event can be serialized by this serde
+	Supports(event pubsub.Event) bool
+
+	// Schema returns the schema and the fully qualified record name of the event
+	Schema(event pubsub.Event) (*Schema, string, error)
+
+	// Serialize the event, the result doesn't contain the wire format header
+	Serialize(event pubsub.Event) ([]byte, error)
+
+	// Deserialize the payload written with the writer schema into the destination event
+	Deserialize(payload []byte, writer *Schema, dest pubsub.Event) error
+}
@@ -0,0 +1,22 @@
+package schemaregistry
+
+import "fmt"
+
+const SubjectNameStrategyTopic = "topic"
+const SubjectNameStrategyRecord = "record"
+const SubjectNameStrategyTopicRecord = "topic-record"
+
+// SubjectName returns the subject of the message value
+// for the given topic and fully qualified record name.
+func SubjectName(strategy string, topic string, recordName string) (string, error) {
+	switch strategy {
+	case "", SubjectNameStrategyTopic:
+		return topic + "-value", nil
+	case SubjectNameStrategyRecord:
+		return recordName, nil
+	case SubjectNameStrategyTopicRecord:
+		return topic + "-" + recordName, nil
+	default:
+		return "", fmt.Errorf("subject name strategy [%s] is not supported", strategy)
+	}
+}
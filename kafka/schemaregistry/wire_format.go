@@ -0,0 +1,41 @@
+package schemaregistry
+
+import (
+	"encoding/binary"
+	"errors"
+	"strings"
+)
+
+// magicByte is the first byte of messages in the schema registry wire format
+const magicByte byte = 0
+
+const wireHeaderSize = 5
+
+// WireFormatContentType is advertised in the content-type header of messages in the wire format,
+// followed by the lower case schema type, eg: application/vnd.schemaregistry+avro
+const WireFormatContentType = "application/vnd.schemaregistry"
+
+func wireFormatContentType(schemaType string) string {
+	return WireFormatContentType + "+" + strings.ToLower(schemaType)
+}
+
+// IsWireFormat reports whether the value looks like a schema registry framed payload
+func IsWireFormat(value []byte) bool {
+	return len(value) >= wireHeaderSize && value[0] == magicByte
+}
+
+// EncodeWireFormat prefixes the payload with the magic byte and the schema id
+func EncodeWireFormat(schemaId int, payload []byte) []byte {
+	data := make([]byte, wireHeaderSize, wireHeaderSize+len(payload))
+	data[0] = magicByte
+	binary.BigEndian.PutUint32(data[1:wireHeaderSize], uint32(schemaId))
+	return append(data, payload...)
+}
+
+// DecodeWireFormat returns the schema id and the payload of a framed value
+func DecodeWireFormat(value []byte) (int, []byte, error) {
+	if !IsWireFormat(value) {
+		return 0, nil, errors.New("value is not in the schema registry wire format")
+	}
+	return int(binary.BigEndian.Uint32(value[1:wireHeaderSize])), value[wireHeaderSize:], nil
+}
@@ -0,0 +1,390 @@
+package spool
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	kafkaConstant "github.com/golibs-starter/golib-message-bus/kafka/constant"
+	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"github.com/golibs-starter/golib-message-bus/kafka/properties"
+	"github.com/golibs-starter/golib/log"
+	"github.com/pkg/errors"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const journalFileName = "spool.log"
+const checkpointFileName = "spool.offset"
+
+// recordHeaderSize is the size of the length and the checksum prefixing each record
+const recordHeaderSize = 8
+
+type record struct {
+	Topic     string
+	Key       []byte
+	Value     []byte
+	Headers   []core.MessageHeader
+	Partition int32
+	Timestamp time.Time
+	Metadata  map[string]interface{}
+
+	// EventId and EventName are only read from records written before Metadata was recorded
+	EventId   string `json:",omitempty"`
+	EventName string `json:",omitempty"`
+}
+
+// FileSpool is a Spool backed by an append-only journal file.
+// The read position is kept in a checkpoint file next to the journal,
+// the journal is truncated each time the spool is fully drained and compacted
+// when replayed records take half of MaxBytes or prevent a message from being appended.
+type FileSpool struct {
+	mu          sync.Mutex
+	props       *properties.Spool
+	journal     *os.File
+	readOffset  int64
+	writeOffset int64
+	stats       Stats
+	dirty       bool
+	stopCh      chan struct{}
+	wg          sync.WaitGroup
+}
+
+func NewFileSpool(props *properties.Spool) (*FileSpool, error) {
+	if props.FsyncPolicy != FsyncPolicyAlways && props.FsyncPolicy != FsyncPolicyInterval &&
+		props.FsyncPolicy != FsyncPolicyNever {
+		return nil, fmt.Errorf("spool fsync policy [%s] is not supported", props.FsyncPolicy)
+	}
+	if err := os.MkdirAll(props.Dir, 0755); err != nil {
+		return nil, errors.WithMessagef(err, "create spool directory [%s] failed", props.Dir)
+	}
+	journal, err := os.OpenFile(filepath.Join(props.Dir, journalFileName), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, errors.WithMessage(err, "open spool journal failed")
+	}
+	s := &FileSpool{
+		props:   props,
+		journal: journal,
+		stopCh:  make(chan struct{}),
+	}
+	if err := s.recover(); err != nil {
+		_ = journal.Close()
+		return nil, errors.WithMessage(err, "recover spool journal failed")
+	}
+	if props.FsyncPolicy == FsyncPolicyInterval {
+		s.wg.Add(1)
+		go s.syncPeriodically()
+	}
+	return s, nil
+}
+
+// recover restores the read position and scans the journal to rebuild the depth.
+// A partially written record at the tail is discarded.
+func (s *FileSpool) recover() error {
+	info, err := s.journal.Stat()
+	if err != nil {
+		return err
+	}
+	readOffset, err := s.readCheckpoint()
+	if err != nil {
+		return err
+	}
+	if readOffset > info.Size() {
+		readOffset = 0
+	}
+	s.readOffset = readOffset
+	offset := readOffset
+	for {
+		_, size, err := s.readRecordAt(offset)
+		if err != nil {
+			break
+		}
+		offset += size
+		s.stats.Depth++
+	}
+	if offset < info.Size() {
+		log.Warnf("Spool journal has [%d] corrupted bytes at the tail, they will be discarded", info.Size()-offset)
+		if err := s.journal.Truncate(offset); err != nil {
+			return err
+		}
+	}
+	s.writeOffset = offset
+	s.stats.Bytes = s.writeOffset - s.readOffset
+	if s.stats.Depth > 0 {
+		log.Infof("Spool journal is recovered with [%d] pending messages", s.stats.Depth)
+	}
+	return nil
+}
+
+func (s *FileSpool) Append(m *core.Message) error {
+	data, err := s.encode(m)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.writeOffset+int64(len(data)) > s.props.MaxBytes && s.readOffset > 0 {
+		if err := s.compact(); err != nil {
+			return err
+		}
+	}
+	if s.writeOffset+int64(len(data)) > s.props.MaxBytes {
+		s.stats.Rejected++
+		return ErrSpoolFull
+	}
+	if _, err := s.journal.WriteAt(data, s.writeOffset); err != nil {
+		return errors.WithMessage(err, "write spool journal failed")
+	}
+	s.writeOffset += int64(len(data))
+	s.stats.Depth++
+	s.stats.Bytes = s.writeOffset - s.readOffset
+	s.stats.Appended++
+	return s.afterWrite()
+}
+
+func (s *FileSpool) Peek() (*core.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stats.Depth == 0 {
+		return nil, nil
+	}
+	rec, _, err := s.readRecordAt(s.readOffset)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "read spool journal at offset [%d] failed", s.readOffset)
+	}
+	metadata := rec.Metadata
+	if metadata == nil {
+		metadata = map[string]interface{}{
+			kafkaConstant.EventId:   rec.EventId,
+			kafkaConstant.EventName: rec.EventName,
+		}
+	}
+	return &core.Message{
+		Topic:     rec.Topic,
+		Key:       rec.Key,
+		Value:     rec.Value,
+		Headers:   rec.Headers,
+		Partition: rec.Partition,
+		Timestamp: rec.Timestamp,
+		Metadata:  metadata,
+	}, nil
+}
+
+func (s *FileSpool) Ack() error {
+	return s.remove(func(stats *Stats) { stats.Replayed++ })
+}
+
+func (s *FileSpool) Drop() error {
+	return s.remove(func(stats *Stats) { stats.Dropped++ })
+}
+
+func (s *FileSpool) remove(count func(stats *Stats)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stats.Depth == 0 {
+		return nil
+	}
+	_, size, err := s.readRecordAt(s.readOffset)
+	if err != nil {
+		return errors.WithMessagef(err, "read spool journal at offset [%d] failed", s.readOffset)
+	}
+	s.readOffset += size
+	s.stats.Depth--
+	count(&s.stats)
+	if s.stats.Depth == 0 {
+		// The spool is drained, reclaim the disk space
+		if err := s.journal.Truncate(0); err != nil {
+			return errors.WithMessage(err, "truncate spool journal failed")
+		}
+		s.readOffset = 0
+		s.writeOffset = 0
+	} else if s.readOffset >= s.props.MaxBytes/2 {
+		if err := s.compact(); err != nil {
+			return err
+		}
+	}
+	s.stats.Bytes = s.writeOffset - s.readOffset
+	if err := s.writeCheckpoint(); err != nil {
+		return err
+	}
+	return s.afterWrite()
+}
+
+// compact replaces the journal by a new one containing only the pending records.
+// When the process crashes between the checkpoint reset and the journal replacement,
+// the replayed records are replayed again.
+func (s *FileSpool) compact() error {
+	path := filepath.Join(s.props.Dir, journalFileName)
+	tmpPath := path + ".tmp"
+	journal, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return errors.WithMessage(err, "create compacted spool journal failed")
+	}
+	pending := io.NewSectionReader(s.journal, s.readOffset, s.writeOffset-s.readOffset)
+	if _, err := io.Copy(journal, pending); err != nil {
+		_ = journal.Close()
+		return errors.WithMessage(err, "write compacted spool journal failed")
+	}
+	if err := journal.Sync(); err != nil {
+		_ = journal.Close()
+		return errors.WithMessage(err, "sync compacted spool journal failed")
+	}
+	readOffset := s.readOffset
+	s.readOffset = 0
+	if err := s.writeCheckpoint(); err != nil {
+		s.readOffset = readOffset
+		_ = journal.Close()
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		s.readOffset = readOffset
+		_ = journal.Close()
+		if err := s.writeCheckpoint(); err != nil {
+			log.WithErrors(err).Errorf("Cannot restore spool checkpoint")
+		}
+		return errors.WithMessage(err, "replace spool journal failed")
+	}
+	if err := s.journal.Close(); err != nil {
+		log.WithErrors(err).Errorf("Cannot close replaced spool journal")
+	}
+	s.journal = journal
+	s.writeOffset -= readOffset
+	s.dirty = false
+	log.Debugf("Spool journal is compacted, [%d] bytes are reclaimed", readOffset)
+	return nil
+}
+
+func (s *FileSpool) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
+
+func (s *FileSpool) Close() error {
+	close(s.stopCh)
+	s.wg.Wait()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.journal.Sync(); err != nil {
+		log.WithErrors(err).Errorf("Cannot sync spool journal")
+	}
+	return s.journal.Close()
+}
+
+func (s *FileSpool) afterWrite() error {
+	if s.props.FsyncPolicy == FsyncPolicyAlways {
+		if err := s.journal.Sync(); err != nil {
+			return errors.WithMessage(err, "sync spool journal failed")
+		}
+		return nil
+	}
+	s.dirty = true
+	return nil
+}
+
+func (s *FileSpool) syncPeriodically() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(s.props.FsyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.mu.Lock()
+			if s.dirty {
+				if err := s.journal.Sync(); err != nil {
+					log.WithErrors(err).Errorf("Cannot sync spool journal")
+				}
+				s.dirty = false
+			}
+			s.mu.Unlock()
+		case <-s.stopCh:
+			return
+		}
+	}
+}
+
+func (s *FileSpool) encode(m *core.Message) ([]byte, error) {
+	rec := record{
+		Topic:     m.Topic,
+		Key:       m.Key,
+		Value:     m.Value,
+		Headers:   m.Headers,
+		Partition: m.Partition,
+		Timestamp: m.Timestamp,
+	}
+	// Metadata values are restored as their JSON types, eg: numbers are restored as float64
+	if metadata, ok := m.Metadata.(map[string]interface{}); ok {
+		rec.Metadata = metadata
+	}
+	payload, err := json.Marshal(rec)
+	if err != nil {
+		return nil, errors.WithMessage(err, "marshalling spool record failed")
+	}
+	data := make([]byte, recordHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(data[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(data[4:8], crc32.ChecksumIEEE(payload))
+	copy(data[recordHeaderSize:], payload)
+	return data, nil
+}
+
+// readRecordAt decodes the record at offset,
+// returns the record and its size in the journal.
+func (s *FileSpool) readRecordAt(offset int64) (*record, int64, error) {
+	header := make([]byte, recordHeaderSize)
+	if _, err := s.journal.ReadAt(header, offset); err != nil {
+		return nil, 0, err
+	}
+	length := binary.BigEndian.Uint32(header[0:4])
+	if int64(length) > s.props.MaxBytes {
+		return nil, 0, errors.New("spool record length exceeds the spool size")
+	}
+	payload := make([]byte, length)
+	if _, err := s.journal.ReadAt(payload, offset+recordHeaderSize); err != nil {
+		if err == io.EOF {
+			return nil, 0, io.ErrUnexpectedEOF
+		}
+		return nil, 0, err
+	}
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:8]) {
+		return nil, 0, errors.New("spool record checksum mismatch")
+	}
+	var rec record
+	if err := json.Unmarshal(payload, &rec); err != nil {
+		return nil, 0, err
+	}
+	return &rec, recordHeaderSize + int64(length), nil
+}
+
+func (s *FileSpool) readCheckpoint() (int64, error) {
+	data, err := os.ReadFile(filepath.Join(s.props.Dir, checkpointFileName))
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, errors.WithMessage(err, "read spool checkpoint failed")
+	}
+	offset, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		log.WithErrors(err).Warnf("Spool checkpoint is invalid, spool will be replayed from the beginning")
+		return 0, nil
+	}
+	return offset, nil
+}
+
+// writeCheckpoint persists the read position atomically
+func (s *FileSpool) writeCheckpoint() error {
+	path := filepath.Join(s.props.Dir, checkpointFileName)
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, []byte(strconv.FormatInt(s.readOffset, 10)), 0644); err != nil {
+		return errors.WithMessage(err, "write spool checkpoint failed")
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return errors.WithMessage(err, "write spool checkpoint failed")
+	}
+	return nil
+}
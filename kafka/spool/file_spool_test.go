@@ -0,0 +1,235 @@
+package spool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	kafkaConstant "github.com/golibs-starter/golib-message-bus/kafka/constant"
+	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"github.com/golibs-starter/golib-message-bus/kafka/properties"
+	"github.com/golibs-starter/golib/event"
+	assert "github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestSpoolProps(t *testing.T) *properties.Spool {
+	return &properties.Spool{
+		Dir:         t.TempDir(),
+		MaxBytes:    1024,
+		FsyncPolicy: FsyncPolicyAlways,
+	}
+}
+
+func TestFileSpool_WhenAppendMessages_ShouldPeekAndAckInOrder(t *testing.T) {
+	s, err := NewFileSpool(newTestSpoolProps(t))
+	assert.NoError(t, err)
+	defer s.Close()
+
+	assert.NoError(t, s.Append(&core.Message{
+		Topic:   "topic1",
+		Key:     []byte("key1"),
+		Value:   []byte("value1"),
+		Headers: []core.MessageHeader{{Key: []byte("h1"), Value: []byte("hv1")}},
+		Metadata: map[string]interface{}{
+			kafkaConstant.EventId:   "event-id-1",
+			kafkaConstant.EventName: "TestEvent",
+		},
+	}))
+	assert.NoError(t, s.Append(&core.Message{Topic: "topic1", Value: []byte("value2")}))
+	assert.Equal(t, int64(2), s.Stats().Depth)
+
+	message, err := s.Peek()
+	assert.NoError(t, err)
+	assert.Equal(t, "topic1", message.Topic)
+	assert.Equal(t, "key1", string(message.Key))
+	assert.Equal(t, "value1", string(message.Value))
+	assert.Equal(t, []core.MessageHeader{{Key: []byte("h1"), Value: []byte("hv1")}}, message.Headers)
+	assert.Equal(t, "event-id-1", message.Metadata.(map[string]interface{})[kafkaConstant.EventId])
+	assert.NoError(t, s.Ack())
+
+	message, err = s.Peek()
+	assert.NoError(t, err)
+	assert.Equal(t, "value2", string(message.Value))
+	assert.NoError(t, s.Ack())
+
+	message, err = s.Peek()
+	assert.NoError(t, err)
+	assert.Nil(t, message)
+	stats := s.Stats()
+	assert.Equal(t, int64(0), stats.Depth)
+	assert.Equal(t, int64(0), stats.Bytes)
+	assert.Equal(t, int64(2), stats.Replayed)
+
+	info, err := os.Stat(filepath.Join(s.props.Dir, journalFileName))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), info.Size())
+}
+
+func TestFileSpool_WhenReopen_ShouldResumeFromCheckpoint(t *testing.T) {
+	props := newTestSpoolProps(t)
+	s, err := NewFileSpool(props)
+	assert.NoError(t, err)
+	assert.NoError(t, s.Append(&core.Message{Topic: "topic1", Value: []byte("value1")}))
+	assert.NoError(t, s.Append(&core.Message{Topic: "topic1", Value: []byte("value2")}))
+	assert.NoError(t, s.Ack())
+	assert.NoError(t, s.Close())
+
+	// Simulate a partially written record
+	journal, err := os.OpenFile(filepath.Join(props.Dir, journalFileName), os.O_APPEND|os.O_WRONLY, 0644)
+	assert.NoError(t, err)
+	_, err = journal.Write([]byte{0, 0, 0, 10, 1})
+	assert.NoError(t, err)
+	assert.NoError(t, journal.Close())
+
+	s, err = NewFileSpool(props)
+	assert.NoError(t, err)
+	defer s.Close()
+	assert.Equal(t, int64(1), s.Stats().Depth)
+	message, err := s.Peek()
+	assert.NoError(t, err)
+	assert.Equal(t, "value2", string(message.Value))
+
+	assert.NoError(t, s.Append(&core.Message{Topic: "topic1", Value: []byte("value3")}))
+	assert.NoError(t, s.Ack())
+	message, err = s.Peek()
+	assert.NoError(t, err)
+	assert.Equal(t, "value3", string(message.Value))
+}
+
+func TestFileSpool_WhenExceedMaxBytes_ShouldReturnSpoolFull(t *testing.T) {
+	props := newTestSpoolProps(t)
+	props.MaxBytes = 100
+	s, err := NewFileSpool(props)
+	assert.NoError(t, err)
+	defer s.Close()
+
+	err = s.Append(&core.Message{Topic: "topic1", Value: make([]byte, 100)})
+	assert.ErrorIs(t, err, ErrSpoolFull)
+	assert.Equal(t, int64(0), s.Stats().Depth)
+	assert.Equal(t, int64(1), s.Stats().Rejected)
+}
+
+func TestFileSpool_WhenPartiallyDrained_ShouldCompactToAcceptNewMessages(t *testing.T) {
+	props := newTestSpoolProps(t)
+	props.MaxBytes = 600
+	s, err := NewFileSpool(props)
+	assert.NoError(t, err)
+
+	// The spool is never fully drained, one message stays pending
+	for i := 0; i < 20; i++ {
+		assert.NoError(t, s.Append(&core.Message{Topic: "topic1", Value: []byte(fmt.Sprintf("value%d", i))}))
+		if i > 0 {
+			assert.NoError(t, s.Ack())
+		}
+	}
+	assert.Equal(t, int64(0), s.Stats().Rejected)
+	assert.Equal(t, int64(1), s.Stats().Depth)
+	message, err := s.Peek()
+	assert.NoError(t, err)
+	assert.Equal(t, "value19", string(message.Value))
+	info, err := os.Stat(filepath.Join(props.Dir, journalFileName))
+	assert.NoError(t, err)
+	assert.LessOrEqual(t, info.Size(), props.MaxBytes)
+
+	// The compacted journal is recovered from the checkpoint
+	assert.NoError(t, s.Close())
+	s, err = NewFileSpool(props)
+	assert.NoError(t, err)
+	defer s.Close()
+	message, err = s.Peek()
+	assert.NoError(t, err)
+	assert.Equal(t, "value19", string(message.Value))
+}
+
+func TestFileSpool_WhenReplay_ShouldRestoreMessageMetadata(t *testing.T) {
+	s, err := NewFileSpool(newTestSpoolProps(t))
+	assert.NoError(t, err)
+	defer s.Close()
+	assert.NoError(t, s.Append(&core.Message{Topic: "topic1", Metadata: map[string]interface{}{
+		kafkaConstant.EventId:      "event-id-1",
+		kafkaConstant.DeliveryMode: kafkaConstant.DeliveryModeAsync,
+	}}))
+
+	message, err := s.Peek()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		kafkaConstant.EventId:      "event-id-1",
+		kafkaConstant.DeliveryMode: kafkaConstant.DeliveryModeAsync,
+	}, message.Metadata)
+}
+
+type TestProducer struct {
+	messages []*core.Message
+	err      error
+}
+
+func (t *TestProducer) Send(m *core.Message) (partition int32, offset int64, err error) {
+	if t.err != nil {
+		return -1, -1, t.err
+	}
+	t.messages = append(t.messages, m)
+	return 0, int64(len(t.messages)), nil
+}
+
+func (t *TestProducer) Close() error {
+	return nil
+}
+
+var errRetriable = errors.New("retriable")
+
+func isTestRetriable(err error) bool {
+	return errors.Is(err, errRetriable)
+}
+
+func TestSyncProducer_WhenRetriableError_ShouldSpoolAndReplayInOrder(t *testing.T) {
+	s, err := NewFileSpool(newTestSpoolProps(t))
+	assert.NoError(t, err)
+	defer s.Close()
+	producer := &TestProducer{err: errRetriable}
+	spoolingProducer := NewSyncProducer(producer, s, isTestRetriable, &event.Properties{})
+
+	partition, offset, err := spoolingProducer.Send(&core.Message{Topic: "topic1", Value: []byte("value1")})
+	assert.NoError(t, err)
+	assert.Equal(t, core.PartitionSpooled, partition)
+	assert.Equal(t, int64(-1), offset)
+	producer.err = nil
+	// The spool is not empty, the message has to be spooled to keep the order
+	_, _, err = spoolingProducer.Send(&core.Message{Topic: "topic1", Value: []byte("value2")})
+	assert.NoError(t, err)
+	assert.Empty(t, producer.messages)
+	assert.Equal(t, int64(2), s.Stats().Depth)
+
+	replayer := NewReplayer(s, producer, isTestRetriable, &properties.Spool{}, &event.Properties{})
+	replayed, err := replayer.Replay(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, replayed)
+	assert.Len(t, producer.messages, 2)
+	assert.Equal(t, "value1", string(producer.messages[0].Value))
+	assert.Equal(t, "value2", string(producer.messages[1].Value))
+	assert.Equal(t, int64(0), s.Stats().Depth)
+}
+
+func TestSyncProducer_WhenNonRetriableError_ShouldReturnError(t *testing.T) {
+	s, err := NewFileSpool(newTestSpoolProps(t))
+	assert.NoError(t, err)
+	defer s.Close()
+	producer := &TestProducer{err: errors.New("message too large")}
+	spoolingProducer := NewSyncProducer(producer, s, isTestRetriable, &event.Properties{})
+
+	_, _, err = spoolingProducer.Send(&core.Message{Topic: "topic1"})
+	assert.Error(t, err)
+	assert.Equal(t, int64(0), s.Stats().Depth)
+}
+
+func TestReplayer_WhenStopRightAfterStart_ShouldWaitForTheReplayer(t *testing.T) {
+	s, err := NewFileSpool(newTestSpoolProps(t))
+	assert.NoError(t, err)
+	defer s.Close()
+	replayer := NewReplayer(s, &TestProducer{}, isTestRetriable, &properties.Spool{ReplayInterval: time.Millisecond},
+		&event.Properties{})
+	replayer.Start(context.Background())
+	replayer.Stop()
+}
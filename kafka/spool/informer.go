@@ -0,0 +1,20 @@
+package spool
+
+import "github.com/golibs-starter/golib/actuator"
+
+// Informer exposes the spool statistics through the actuator info endpoint
+type Informer struct {
+	spool Spool
+}
+
+func NewInformer(spool Spool) actuator.Informer {
+	return &Informer{spool: spool}
+}
+
+func (i Informer) Key() string {
+	return "kafka_spool"
+}
+
+func (i Informer) Value() interface{} {
+	return i.spool.Stats()
+}
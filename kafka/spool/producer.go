@@ -0,0 +1,130 @@
+package spool
+
+import (
+	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"github.com/golibs-starter/golib-message-bus/kafka/log"
+	"github.com/golibs-starter/golib/event"
+	coreLog "github.com/golibs-starter/golib/log"
+)
+
+// RetriableChecker reports whether a produce error is worth spooling.
+type RetriableChecker func(err error) bool
+
+// SyncProducer is a core.SyncProducer which spools messages that fail with retriable errors.
+// While the spool is not empty, new messages are spooled directly to keep them in order.
+// A spooled message is reported as sent with partition core.PartitionSpooled and offset -1.
+type SyncProducer struct {
+	producer    core.SyncProducer
+	spool       Spool
+	isRetriable RetriableChecker
+	eventProps  *event.Properties
+}
+
+func NewSyncProducer(
+	producer core.SyncProducer,
+	spool Spool,
+	isRetriable RetriableChecker,
+	eventProps *event.Properties,
+) *SyncProducer {
+	return &SyncProducer{
+		producer:    producer,
+		spool:       spool,
+		isRetriable: isRetriable,
+		eventProps:  eventProps,
+	}
+}
+
+func (s *SyncProducer) Send(m *core.Message) (partition int32, offset int64, err error) {
+	if s.spool.Stats().Depth > 0 {
+		if err := s.spool.Append(m); err != nil {
+			return -1, -1, err
+		}
+		return core.PartitionSpooled, -1, nil
+	}
+	partition, offset, err = s.producer.Send(m)
+	if err == nil || !s.isRetriable(err) {
+		return partition, offset, err
+	}
+	if spoolErr := s.spool.Append(m); spoolErr != nil {
+		coreLog.WithErrors(spoolErr).Errorf("Cannot spool kafka message %s",
+			log.DescMessage(m, s.eventProps.Log.NotLogPayloadForEvents))
+		return -1, -1, err
+	}
+	coreLog.WithErrors(err).Warnf("Kafka message is spooled %s",
+		log.DescMessage(m, s.eventProps.Log.NotLogPayloadForEvents))
+	return core.PartitionSpooled, -1, nil
+}
+
+func (s *SyncProducer) Close() error {
+	return s.producer.Close()
+}
+
+// AsyncProducer is a core.AsyncProducer which spools messages that fail with retriable errors.
+// Spooled messages are not reported to the Errors channel.
+type AsyncProducer struct {
+	producer    core.AsyncProducer
+	spool       Spool
+	isRetriable RetriableChecker
+	eventProps  *event.Properties
+	errorsCh    chan *core.ProducerError
+}
+
+func NewAsyncProducer(
+	producer core.AsyncProducer,
+	spool Spool,
+	isRetriable RetriableChecker,
+	eventProps *event.Properties,
+) *AsyncProducer {
+	p := &AsyncProducer{
+		producer:    producer,
+		spool:       spool,
+		isRetriable: isRetriable,
+		eventProps:  eventProps,
+		errorsCh:    make(chan *core.ProducerError),
+	}
+	go func() {
+		defer close(p.errorsCh)
+		for e := range producer.Errors() {
+			if p.trySpool(e) {
+				continue
+			}
+			p.errorsCh <- e
+		}
+	}()
+	return p
+}
+
+func (p *AsyncProducer) trySpool(e *core.ProducerError) bool {
+	if !p.isRetriable(e.Err) {
+		return false
+	}
+	if err := p.spool.Append(e.Msg); err != nil {
+		coreLog.WithErrors(err).Errorf("Cannot spool kafka message %s",
+			log.DescMessage(e.Msg, p.eventProps.Log.NotLogPayloadForEvents))
+		return false
+	}
+	coreLog.WithErrors(e.Err).Warnf("Kafka message is spooled %s",
+		log.DescMessage(e.Msg, p.eventProps.Log.NotLogPayloadForEvents))
+	return true
+}
+
+func (p *AsyncProducer) Send(m *core.Message) {
+	if p.spool.Stats().Depth > 0 {
+		if err := p.spool.Append(m); err == nil {
+			return
+		}
+	}
+	p.producer.Send(m)
+}
+
+func (p *AsyncProducer) Successes() <-chan *core.Message {
+	return p.producer.Successes()
+}
+
+func (p *AsyncProducer) Errors() <-chan *core.ProducerError {
+	return p.errorsCh
+}
+
+func (p *AsyncProducer) Close() error {
+	return p.producer.Close()
+}
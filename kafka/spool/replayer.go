@@ -0,0 +1,123 @@
+package spool
+
+import (
+	"context"
+	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"github.com/golibs-starter/golib-message-bus/kafka/log"
+	"github.com/golibs-starter/golib-message-bus/kafka/properties"
+	"github.com/golibs-starter/golib/event"
+	coreLog "github.com/golibs-starter/golib/log"
+	"github.com/pkg/errors"
+	"sync"
+	"time"
+)
+
+// Replayer drains the spool in order once the cluster is reachable again.
+// The producer must not be the spooling one, otherwise messages would be spooled again.
+type Replayer struct {
+	spool       Spool
+	producer    core.SyncProducer
+	isRetriable RetriableChecker
+	props       *properties.Spool
+	eventProps  *event.Properties
+	stopCh      chan struct{}
+	stopOnce    sync.Once
+	wg          sync.WaitGroup
+}
+
+func NewReplayer(
+	spool Spool,
+	producer core.SyncProducer,
+	isRetriable RetriableChecker,
+	props *properties.Spool,
+	eventProps *event.Properties,
+) *Replayer {
+	return &Replayer{
+		spool:       spool,
+		producer:    producer,
+		isRetriable: isRetriable,
+		props:       props,
+		eventProps:  eventProps,
+		stopCh:      make(chan struct{}),
+	}
+}
+
+// Start runs the replayer in background until ctx is done or Stop is called.
+func (r *Replayer) Start(ctx context.Context) {
+	coreLog.Infof("Spool replayer is starting")
+	// Counted before spawning so that Stop never closes the producer under a replay
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		r.run(ctx)
+	}()
+}
+
+func (r *Replayer) run(ctx context.Context) {
+	ticker := time.NewTicker(r.props.ReplayInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := r.Replay(ctx); err != nil {
+				coreLog.WithErrors(err).Warnf("Spool replayer cannot drain the spool, depth [%d]",
+					r.spool.Stats().Depth)
+			}
+		case <-ctx.Done():
+			coreLog.Infof("Spool replayer is stopped by context")
+			return
+		case <-r.stopCh:
+			coreLog.Infof("Spool replayer is stopped")
+			return
+		}
+	}
+}
+
+// Stop the replayer, wait for the in-flight replay to finish then close the producer.
+func (r *Replayer) Stop() {
+	r.stopOnce.Do(func() {
+		close(r.stopCh)
+	})
+	r.wg.Wait()
+	if err := r.producer.Close(); err != nil {
+		coreLog.WithErrors(err).Errorf("Spool replayer cannot close the producer")
+	}
+}
+
+// Replay sends the spooled messages in order until the spool is empty
+// or a retriable error occurred. Messages failed with non-retriable errors are dropped.
+// Returns number of replayed messages.
+func (r *Replayer) Replay(ctx context.Context) (int, error) {
+	replayed := 0
+	for ctx.Err() == nil {
+		message, err := r.spool.Peek()
+		if err != nil {
+			return replayed, errors.WithMessage(err, "peek spool failed")
+		}
+		if message == nil {
+			if replayed > 0 {
+				coreLog.Infof("Spool replayer drained [%d] messages", replayed)
+			}
+			return replayed, nil
+		}
+		descMessage := log.DescMessage(message, r.eventProps.Log.NotLogPayloadForEvents)
+		partition, offset, err := r.producer.Send(message)
+		if err != nil {
+			if r.isRetriable(err) {
+				return replayed, errors.WithMessage(err, "replay spooled message failed")
+			}
+			coreLog.WithErrors(err).Errorf("Spooled message %s cannot be produced and is dropped", descMessage)
+			if err := r.spool.Drop(); err != nil {
+				return replayed, errors.WithMessage(err, "drop spooled message failed")
+			}
+			continue
+		}
+		if err := r.spool.Ack(); err != nil {
+			return replayed, errors.WithMessage(err, "ack spooled message failed")
+		}
+		replayed++
+		coreLog.Debugf("Success to replay spooled message to kafka partition [%d], offset [%d], message %s",
+			partition, offset, descMessage)
+	}
+	return replayed, ctx.Err()
+}
@@ -0,0 +1,47 @@
+package spool
+
+import (
+	"errors"
+	"github.com/golibs-starter/golib-message-bus/kafka/core"
+)
+
+const FsyncPolicyAlways = "always"
+const FsyncPolicyInterval = "interval"
+const FsyncPolicyNever = "never"
+
+// ErrSpoolFull is returned when a message cannot be appended
+// because the spool reached its size limit.
+var ErrSpoolFull = errors.New("kafka spool is full")
+
+// Spool is an ordered, durable queue of messages which could not be produced.
+type Spool interface {
+
+	// Append a message to the tail of the spool
+	Append(m *core.Message) error
+
+	// Peek returns the message at the head of the spool without removing it.
+	// Returns nil if the spool is empty.
+	Peek() (*core.Message, error)
+
+	// Ack removes the message at the head of the spool after it is delivered
+	Ack() error
+
+	// Drop removes the message at the head of the spool without delivering it
+	Drop() error
+
+	// Stats returns the current spool statistics
+	Stats() Stats
+
+	// Close the spool
+	Close() error
+}
+
+// Stats describes the spool depth and activity
+type Stats struct {
+	Depth    int64 `json:"depth"`
+	Bytes    int64 `json:"bytes"`
+	Appended int64 `json:"appended"`
+	Replayed int64 `json:"replayed"`
+	Dropped  int64 `json:"dropped"`
+	Rejected int64 `json:"rejected"`
+}
@@ -0,0 +1,64 @@
+package validator
+
+import (
+	"encoding/json"
+	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"github.com/golibs-starter/golib-message-bus/kafka/properties"
+	"github.com/golibs-starter/golib-message-bus/kafka/relayer"
+	"github.com/golibs-starter/golib/pubsub"
+	"github.com/pkg/errors"
+	"strings"
+)
+
+// EventConverter is a relayer.EventConverter which refuses to convert events
+// whose payload violates the JSON schema configured in their EventTopic.
+// The JSON document of the event payload is validated before the event is converted,
+// so the same document is validated whatever the codec, the CloudEvents mode or the serializer are.
+type EventConverter struct {
+	delegate   relayer.EventConverter
+	validators map[string]Validator
+}
+
+// NewEventConverter compiles the schemas of all event mappings eagerly,
+// so an invalid schema fails the application startup.
+func NewEventConverter(
+	delegate relayer.EventConverter,
+	eventProducerProps *properties.EventProducer,
+	loader *SchemaLoader,
+) (*EventConverter, error) {
+	validators := make(map[string]Validator)
+	for event, eventTopic := range eventProducerProps.EventMappings {
+		if eventTopic.JsonSchema == "" {
+			continue
+		}
+		validator, err := loader.Load(eventTopic.JsonSchema)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "load JSON schema for event [%s] failed", event)
+		}
+		validators[strings.ToLower(event)] = validator
+	}
+	return &EventConverter{delegate: delegate, validators: validators}, nil
+}
+
+func (c EventConverter) Convert(event pubsub.Event) (*core.Message, error) {
+	if validator, exists := c.validators[strings.ToLower(event.Name())]; exists {
+		payload, err := json.Marshal(event.Payload())
+		if err != nil {
+			return nil, errors.WithMessagef(err, "marshalling payload of event [%s] failed", event.Name())
+		}
+		if err := validator.Validate(payload); err != nil {
+			return nil, errors.WithMessagef(err, "event [%s] is invalid", event.Name())
+		}
+	}
+	return c.delegate.Convert(event)
+}
+
+func (c EventConverter) Restore(msg *core.ConsumerMessage, dest pubsub.Event) error {
+	return c.delegate.Restore(msg, dest)
+}
+
+func (c EventConverter) RestoreAttributes(msg *core.ConsumerMessage, dest pubsub.Event) {
+	if restorer, ok := c.delegate.(relayer.EventAttributesRestorer); ok {
+		restorer.RestoreAttributes(msg, dest)
+	}
+}
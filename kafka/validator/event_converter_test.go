@@ -0,0 +1,114 @@
+package validator
+
+import (
+	"context"
+	kafkaConstant "github.com/golibs-starter/golib-message-bus/kafka/constant"
+	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"github.com/golibs-starter/golib-message-bus/kafka/properties"
+	"github.com/golibs-starter/golib-message-bus/kafka/relayer"
+	"github.com/golibs-starter/golib/config"
+	"github.com/golibs-starter/golib/event"
+	assert "github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+const testSchema = `{
+	"type": "object",
+	"required": ["order_id"],
+	"properties": {"order_id": {"type": "string"}}
+}`
+
+func newTestConverter(t *testing.T, loader *SchemaLoader, schemaLocation string) *EventConverter {
+	appProps := &config.AppProperties{Name: "TestApp"}
+	eventProducerProps := &properties.EventProducer{EventMappings: map[string]properties.EventTopic{
+		"testevent": {TopicName: "test.topic", JsonSchema: schemaLocation},
+	}}
+	converter, err := NewEventConverter(relayer.NewDefaultEventConverter(appProps, eventProducerProps),
+		eventProducerProps, loader)
+	assert.NoError(t, err)
+	return converter
+}
+
+func TestEventConverter_WhenSchemaIsLoadedFromFile_ShouldValidateEvent(t *testing.T) {
+	schemaFile := filepath.Join(t.TempDir(), "test_event.json")
+	assert.NoError(t, os.WriteFile(schemaFile, []byte(testSchema), 0644))
+	converter := newTestConverter(t, NewSchemaLoader(nil), schemaFile)
+
+	message, err := converter.Convert(event.NewApplicationEvent(context.Background(), "TestEvent",
+		event.WithPayload(map[string]interface{}{"order_id": "1"})))
+	assert.NoError(t, err)
+	assert.Equal(t, "test.topic", message.Topic)
+
+	_, err = converter.Convert(event.NewApplicationEvent(context.Background(), "TestEvent",
+		event.WithPayload(map[string]interface{}{"order_id": 1})))
+	assert.Error(t, err)
+}
+
+func TestEventConverter_WhenSchemaIsLoadedFromEmbeddedFS_ShouldValidateEvent(t *testing.T) {
+	fsys := fstest.MapFS{"schemas/test_event.json": &fstest.MapFile{Data: []byte(testSchema)}}
+	converter := newTestConverter(t, NewSchemaLoader(fsys), "embed://schemas/test_event.json")
+
+	_, err := converter.Convert(event.NewApplicationEvent(context.Background(), "TestEvent"))
+	assert.Error(t, err)
+
+	_, err = converter.Convert(event.NewApplicationEvent(context.Background(), "TestEvent",
+		event.WithPayload(map[string]interface{}{"order_id": "1"})))
+	assert.NoError(t, err)
+}
+
+func TestEventConverter_WhenCloudEventsModeIsEnabled_ShouldValidateEventPayload(t *testing.T) {
+	fsys := fstest.MapFS{"test_event.json": &fstest.MapFile{Data: []byte(testSchema)}}
+	appProps := &config.AppProperties{Name: "TestApp"}
+	for _, mode := range []string{"", kafkaConstant.CloudEventsModeBinary, kafkaConstant.CloudEventsModeStructured} {
+		eventProducerProps := &properties.EventProducer{EventMappings: map[string]properties.EventTopic{
+			"testevent": {TopicName: "test.topic", JsonSchema: "embed://test_event.json", CloudEventsMode: mode},
+		}}
+		delegate, err := relayer.NewCloudEventsConverter(
+			relayer.NewDefaultEventConverter(appProps, eventProducerProps), appProps, eventProducerProps)
+		assert.NoError(t, err)
+		converter, err := NewEventConverter(delegate, eventProducerProps, NewSchemaLoader(fsys))
+		assert.NoError(t, err)
+
+		_, err = converter.Convert(event.NewApplicationEvent(context.Background(), "TestEvent",
+			event.WithPayload(map[string]interface{}{"order_id": "1"})))
+		assert.NoError(t, err, mode)
+
+		_, err = converter.Convert(event.NewApplicationEvent(context.Background(), "TestEvent",
+			event.WithPayload(map[string]interface{}{"order_id": 1})))
+		assert.Error(t, err, mode)
+	}
+}
+
+func TestEventConverter_WhenSchemaNotFound_ShouldReturnError(t *testing.T) {
+	eventProducerProps := &properties.EventProducer{EventMappings: map[string]properties.EventTopic{
+		"testevent": {TopicName: "test.topic", JsonSchema: "embed://schemas/not_found.json"},
+	}}
+	_, err := NewEventConverter(relayer.NewDefaultEventConverter(&config.AppProperties{}, eventProducerProps),
+		eventProducerProps, NewSchemaLoader(fstest.MapFS{}))
+	assert.Error(t, err)
+}
+
+func TestJsonSchemaValidator_WhenPayloadIsNotJson_ShouldReturnError(t *testing.T) {
+	fsys := fstest.MapFS{"test_event.json": &fstest.MapFile{Data: []byte(testSchema)}}
+	validator, err := NewSchemaLoader(fsys).Load("embed://test_event.json")
+	assert.NoError(t, err)
+	assert.Error(t, validator.Validate([]byte("not-a-json")))
+	assert.NoError(t, validator.Validate([]byte(`{"order_id": "1"}`)))
+}
+
+func TestPayloadDocument_WhenMessageIsNotAGolibEvent_ShouldReturnTheValueOrNothing(t *testing.T) {
+	document, err := PayloadDocument(&core.ConsumerMessage{Value: []byte(`{"order_id":"1"}`)})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"order_id":"1"}`, string(document))
+
+	document, err = PayloadDocument(&core.ConsumerMessage{Value: []byte{0, 0, 0, 0, 1, 2}})
+	assert.NoError(t, err)
+	assert.Nil(t, document)
+
+	_, err = PayloadDocument(&core.ConsumerMessage{Value: []byte("not json"),
+		Headers: []core.MessageHeader{{Key: []byte(kafkaConstant.HeaderContentType), Value: []byte("application/json")}}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,27 @@
+package validator
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// JsonSchemaValidator validates JSON payloads against a JSON Schema
+type JsonSchemaValidator struct {
+	location string
+	schema   *jsonschema.Schema
+}
+
+func (v JsonSchemaValidator) Validate(payload []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return errors.WithMessage(err, "payload is not a valid JSON")
+	}
+	if err := v.schema.Validate(document); err != nil {
+		return errors.WithMessagef(err, "payload violates JSON schema [%s]", v.location)
+	}
+	return nil
+}
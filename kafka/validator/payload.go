@@ -0,0 +1,50 @@
+package validator
+
+import (
+	"encoding/json"
+	"github.com/golibs-starter/golib-message-bus/kafka/constant"
+	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"github.com/golibs-starter/golib-message-bus/kafka/relayer"
+	"github.com/golibs-starter/golib-message-bus/kafka/schemaregistry"
+	"github.com/pkg/errors"
+	"strings"
+)
+
+// PayloadDocument returns the JSON document of the event payload carried by the message,
+// it's the document validated by EventConverter before the event is produced.
+// Values without payload field are not golib events, they are returned as is.
+// Returns nil when the message carries no JSON document, such as schema registry wire format messages.
+func PayloadDocument(msg *core.ConsumerMessage) ([]byte, error) {
+	var contentType string
+	for _, header := range msg.Headers {
+		switch strings.ToLower(string(header.Key)) {
+		case relayer.HeaderCeSpecVersion:
+			// Binary cloud events carry the payload as value
+			return msg.Value, nil
+		case constant.HeaderContentType:
+			contentType = strings.ToLower(string(header.Value))
+		}
+	}
+	switch {
+	case strings.HasPrefix(contentType, schemaregistry.WireFormatContentType),
+		contentType == "" && schemaregistry.IsWireFormat(msg.Value):
+		return nil, nil
+	case strings.HasPrefix(contentType, relayer.CloudEventsContentType):
+		return field(msg.Value, "data")
+	default:
+		return field(msg.Value, "payload")
+	}
+}
+
+// field returns the JSON document of the field of the object, or the object when it has no such field
+func field(value []byte, name string) ([]byte, error) {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(value, &object); err != nil {
+		return nil, errors.WithMessage(err, "message value is not a JSON object")
+	}
+	document, exists := object[name]
+	if !exists {
+		return value, nil
+	}
+	return document, nil
+}
@@ -0,0 +1,59 @@
+package validator
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"io"
+	"io/fs"
+	"strings"
+	"sync"
+)
+
+// EmbeddedSchemaPrefix is the prefix of schema locations loaded from the embedded file system,
+// eg: embed://schemas/order_created.json
+const EmbeddedSchemaPrefix = "embed://"
+
+// SchemaLoader loads and compiles JSON schemas from files or from an embedded file system.
+// Compiled schemas are cached by location.
+type SchemaLoader struct {
+	fsys       fs.FS
+	mu         sync.Mutex
+	validators map[string]*JsonSchemaValidator
+}
+
+// NewSchemaLoader creates a loader, fsys is used for embed:// locations and may be nil
+func NewSchemaLoader(fsys fs.FS) *SchemaLoader {
+	return &SchemaLoader{
+		fsys:       fsys,
+		validators: make(map[string]*JsonSchemaValidator),
+	}
+}
+
+// Load returns the validator of the schema at location
+func (l *SchemaLoader) Load(location string) (*JsonSchemaValidator, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if validator, exists := l.validators[location]; exists {
+		return validator, nil
+	}
+	compiler := jsonschema.NewCompiler()
+	compiler.LoadURL = l.loadURL
+	schema, err := compiler.Compile(location)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "compile JSON schema [%s] failed", location)
+	}
+	validator := &JsonSchemaValidator{location: location, schema: schema}
+	l.validators[location] = validator
+	return validator, nil
+}
+
+func (l *SchemaLoader) loadURL(url string) (io.ReadCloser, error) {
+	if !strings.HasPrefix(url, EmbeddedSchemaPrefix) {
+		return jsonschema.LoadURL(url)
+	}
+	if l.fsys == nil {
+		return nil, fmt.Errorf("no embedded file system is provided to load [%s]", url)
+	}
+	return l.fsys.Open(strings.TrimPrefix(url, EmbeddedSchemaPrefix))
+}
@@ -0,0 +1,8 @@
+package validator
+
+// Validator validates a message payload against a contract
+type Validator interface {
+
+	// Validate returns an error describing the violations when the payload is invalid
+	Validate(payload []byte) error
+}
@@ -0,0 +1,86 @@
+package golibmsg
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/golibs-starter/golib-message-bus/kafka/codec"
+	"github.com/golibs-starter/golib-message-bus/kafka/core"
+	"github.com/golibs-starter/golib-message-bus/kafka/encryption"
+	"github.com/golibs-starter/golib-message-bus/kafka/properties"
+	"github.com/golibs-starter/golib-message-bus/kafka/schemaregistry"
+	"github.com/golibs-starter/golib-message-bus/kafka/validator"
+	"github.com/golibs-starter/golib/config"
+	webEvent "github.com/golibs-starter/golib/web/event"
+	assert "github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type testSchemaRegistry struct {
+	schemas []*schemaregistry.Schema
+}
+
+func (t *testSchemaRegistry) GetSchema(id int) (*schemaregistry.Schema, error) {
+	return t.schemas[id-1], nil
+}
+
+func (t *testSchemaRegistry) Register(_ string, schema *schemaregistry.Schema) (int, error) {
+	t.schemas = append(t.schemas, schema)
+	return len(t.schemas), nil
+}
+
+func (t *testSchemaRegistry) Lookup(subject string, schema *schemaregistry.Schema) (int, error) {
+	return t.Register(subject, schema)
+}
+
+type testAvroEvent struct {
+	*webEvent.AbstractEvent `avro:"-"`
+	Email                   string `avro:"email"`
+}
+
+func (t testAvroEvent) AvroSchema() string {
+	return `{"type":"record","name":"UserCreated","fields":[{"name":"email","type":"string"}]}`
+}
+
+func newTestEventConverterIn(t *testing.T) EventConverterIn {
+	keyFile := filepath.Join(t.TempDir(), "keys.json")
+	content, err := json.Marshal(map[string]string{
+		"key-1": base64.StdEncoding.EncodeToString(make([]byte, encryption.DataKeySize)),
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(keyFile, content, 0600))
+	kms, err := encryption.NewLocalKms(&properties.Encryption{KeyFile: keyFile})
+	assert.NoError(t, err)
+	return EventConverterIn{
+		AppProps: &config.AppProperties{Name: "TestApp"},
+		EventProducerProps: &properties.EventProducer{EventMappings: map[string]properties.EventTopic{
+			"usercreated": {TopicName: "user.created", EncryptionKeyId: "key-1"},
+		}},
+		SchemaLoader:        validator.NewSchemaLoader(nil),
+		Codecs:              codec.NewRegistry(),
+		Encrypter:           encryption.NewEncrypter(kms),
+		Decrypter:           encryption.NewDecrypter(kms),
+		SchemaRegistry:      &testSchemaRegistry{},
+		SchemaRegistryProps: &properties.SchemaRegistry{AutoRegisterSchemas: true, SubjectNameStrategy: "topic"},
+	}
+}
+
+func TestNewEventConverter_WhenSchemaRegistryAndEncryptionAreEnabled_ShouldEncryptTheWireFormat(t *testing.T) {
+	converter, err := NewEventConverter(newTestEventConverterIn(t))
+	assert.NoError(t, err)
+
+	message, err := converter.Convert(&testAvroEvent{
+		AbstractEvent: webEvent.NewAbstractEvent(context.Background(), "UserCreated"),
+		Email:         "user@example.com",
+	})
+	assert.NoError(t, err)
+	assert.NotContains(t, string(message.Value), "user@example.com")
+
+	restored := &testAvroEvent{AbstractEvent: &webEvent.AbstractEvent{}}
+	consumerMessage := &core.ConsumerMessage{Topic: message.Topic, Value: message.Value, Headers: message.Headers}
+	assert.True(t, encryption.IsEncrypted(consumerMessage))
+	assert.NoError(t, converter.Restore(consumerMessage, restored))
+	assert.Equal(t, "user@example.com", restored.Email)
+}